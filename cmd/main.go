@@ -19,14 +19,20 @@ package main
 import (
 	"flag"
 	"fmt"
+	"log/slog"
 	"os"
-	"sync"
+	"regexp"
+	"sort"
+	"strings"
 	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	"github.com/spf13/pflag"
-	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/version"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	cliflag "k8s.io/component-base/cli/flag"
 	"k8s.io/klog/v2"
@@ -49,6 +55,58 @@ var (
 	webhookPort          int
 	syncPeriod           time.Duration
 	concurrentReconciles int
+	conflictPolicy       string
+	adoptionPolicy       string
+	autoTargetLabel      string
+	eventFilter          string
+	minClaudieVersion    string
+	deletionRetention    time.Duration
+	specDefaultsFile     string
+	specDefaultsCM       string
+	namespaceRate        float64
+	namespaceBurst       int
+	allowedNamespaces    []string
+	deniedNamespaces     []string
+	skipOwnerReferences  bool
+	controllerOwnerRef   bool
+	driftInterval        time.Duration
+	resyncPeriod         time.Duration
+	sveltosClusterVer    string
+	annotateSecret       bool
+	uniqueNameSuffix     bool
+	retainOnLabelRemoval bool
+	enforcedSpecFields   []string
+	specMapping          []string
+	labelMapping         []string
+	batchWindow          time.Duration
+	claudieLabel         string
+	kubeconfigLabel      string
+	clusterLabel         string
+	capiSecrets          bool
+	kubeconfigDataKey    string
+	clusterProfileStub   string
+	auditLog             bool
+	staleSweepInterval   time.Duration
+	disableStaleSweep    bool
+	propagatedLabels     []string
+	namespaceMap         []string
+	extraAnnotations     map[string]string
+	watchNamespaces      []string
+	enableWebhook        bool
+	controllerUser       string
+	leaderElect          bool
+	leaderElectionID     string
+	leaderElectionNS     string
+	enableDebugEndpoints bool
+	apiCallTimeout       time.Duration
+	staleGracePeriod     time.Duration
+	retainOnDelete       bool
+	secretSelector       string
+	failureBackoffBase   time.Duration
+	failureBackoffMax    time.Duration
+	serverSideApply      bool
+	connectivityProbe    bool
+	probeTimeout         time.Duration
 )
 
 func main() {
@@ -56,6 +114,10 @@ func main() {
 	if err != nil {
 		os.Exit(1)
 	}
+	if err := controller.ValidateScheme(scheme); err != nil {
+		setupLog.Error(err, "invalid scheme")
+		os.Exit(1)
+	}
 
 	klog.InitFlags(nil)
 
@@ -66,6 +128,47 @@ func main() {
 
 	ctrl.SetLogger(klog.Background())
 
+	if err := validateFlags(); err != nil {
+		setupLog.Error(err, "invalid flags")
+		os.Exit(1)
+	}
+
+	autoTargetLabelKey, autoTargetLabelValue := parseLabel(autoTargetLabel)
+
+	var minVersion *version.Version
+	if minClaudieVersion != "" {
+		minVersion = version.MustParseGeneric(minClaudieVersion)
+	}
+
+	// Already validated by validateFlags
+	annotationToSpec, _ := controller.ParseSpecMapping(specMapping)
+	annotationToLabel, _ := controller.ParseLabelMapping(labelMapping)
+	secretToClusterNamespace, _ := controller.ParseNamespaceMap(namespaceMap)
+	selector, _ := labels.Parse(secretSelector)
+
+	var specDefaults *controller.SpecDefaults
+	if specDefaultsFile != "" {
+		specDefaults, err = controller.LoadSpecDefaults(specDefaultsFile)
+		if err != nil {
+			setupLog.Error(err, "unable to load SveltosCluster spec defaults")
+			os.Exit(1)
+		}
+	}
+
+	var profileStub *unstructured.Unstructured
+	if clusterProfileStub != "" {
+		profileStub, err = controller.LoadClusterProfileStub(clusterProfileStub)
+		if err != nil {
+			setupLog.Error(err, "unable to load ClusterProfile stub")
+			os.Exit(1)
+		}
+	}
+
+	var auditSink controller.AuditSink
+	if auditLog {
+		auditSink = controller.NewSlogAuditSink(slog.New(slog.NewJSONHandler(os.Stdout, nil)))
+	}
+
 	ctrlOptions := ctrl.Options{
 		Scheme:                 scheme,
 		HealthProbeBindAddress: probeAddr,
@@ -79,6 +182,16 @@ func main() {
 		Cache: cache.Options{
 			SyncPeriod: &syncPeriod,
 		},
+		LeaderElection:          leaderElect,
+		LeaderElectionID:        leaderElectionID,
+		LeaderElectionNamespace: leaderElectionNS,
+		// Controller exits right after the manager stops, so the lease can be released
+		// to let another replica take over immediately
+		LeaderElectionReleaseOnCancel: true,
+	}
+
+	if len(watchNamespaces) != 0 {
+		ctrlOptions.Cache.DefaultNamespaces = getCacheNamespaces(watchNamespaces, secretToClusterNamespace)
 	}
 
 	restConfig := ctrl.GetConfigOrDie()
@@ -94,23 +207,114 @@ func main() {
 	// Setup the context that's going to be used in controllers and for the manager.
 	ctx := ctrl.SetupSignalHandler()
 
-	if err = (&controller.SecretReconciler{
-		Client:               mgr.GetClient(),
-		Scheme:               mgr.GetScheme(),
-		ConcurrentReconciles: concurrentReconciles,
-		Mux:                  sync.Mutex{},
-		SecretToCluster:      make(map[types.NamespacedName]types.NamespacedName),
-	}).SetupWithManager(ctx, mgr, ctrl.Log.WithName("clean-stale-resources")); err != nil {
+	// Fail right away on misconfigured RBAC instead of failing every reconciliation.
+	// Permissions are only required in the namespaces the cache is restricted to, if any.
+	permissionNamespaces := make([]string, 0, len(ctrlOptions.Cache.DefaultNamespaces))
+	for namespace := range ctrlOptions.Cache.DefaultNamespaces {
+		permissionNamespaces = append(permissionNamespaces, namespace)
+	}
+	sort.Strings(permissionNamespaces)
+	if err := controller.CheckPermissions(ctx, mgr.GetClient(), permissionNamespaces); err != nil {
+		setupLog.Error(err, "controller is not allowed to manage Secrets and SveltosClusters. Fix its RBAC and restart it")
+		os.Exit(1)
+	}
+
+	// Cache is not started yet, so ConfigMap is read straight from the API server
+	if specDefaultsCM != "" {
+		configMapKey, _ := controller.ParseSpecDefaultsConfigMap(specDefaultsCM)
+		specDefaults, err = controller.LoadSpecDefaultsFromConfigMap(ctx, mgr.GetAPIReader(), configMapKey)
+		if err != nil {
+			setupLog.Error(err, "unable to load SveltosCluster spec defaults")
+			os.Exit(1)
+		}
+	}
+
+	secretReconciler := &controller.SecretReconciler{
+		Client:                   mgr.GetClient(),
+		Scheme:                   mgr.GetScheme(),
+		ConcurrentReconciles:     concurrentReconciles,
+		ConflictPolicy:           controller.ConflictPolicy(conflictPolicy),
+		AdoptionPolicy:           controller.AdoptionPolicy(adoptionPolicy),
+		AutoTargetLabelKey:       autoTargetLabelKey,
+		AutoTargetLabelValue:     autoTargetLabelValue,
+		EventFilter:              controller.EventFilter(eventFilter),
+		MinClaudieVersion:        minVersion,
+		DeletionRetention:        deletionRetention,
+		SpecDefaults:             specDefaults,
+		NamespaceReconcileRate:   namespaceRate,
+		NamespaceReconcileBurst:  namespaceBurst,
+		SkipOwnerReferences:      skipOwnerReferences,
+		ControllerOwnerReference: controllerOwnerRef,
+		DriftReconcileInterval:   driftInterval,
+		ResyncPeriod:             resyncPeriod,
+		SveltosClusterVersion:    sveltosClusterVer,
+		AnnotateSecret:           annotateSecret,
+		UniqueNameSuffix:         uniqueNameSuffix,
+		RetainOnLabelRemoval:     retainOnLabelRemoval,
+		RetainOnSecretDelete:     retainOnDelete,
+		EnforcedSpecFields:       enforcedSpecFields,
+		SpecMapping:              annotationToSpec,
+		LabelMapping:             annotationToLabel,
+		BatchWindow:              batchWindow,
+		ClaudieLabel:             claudieLabel,
+		ClaudieKubeconfigLabel:   kubeconfigLabel,
+		ClaudieClusterLabel:      clusterLabel,
+		ClusterAPISecrets:        capiSecrets,
+		KubeconfigDataKey:        kubeconfigDataKey,
+		ClusterProfileStub:       profileStub,
+		AuditSink:                auditSink,
+		StaleSweepInterval:       staleSweepInterval,
+		DisableStaleSweep:        disableStaleSweep,
+		APICallTimeout:           apiCallTimeout,
+		StaleGracePeriod:         staleGracePeriod,
+		FailureBackoffBase:       failureBackoffBase,
+		FailureBackoffMax:        failureBackoffMax,
+		ServerSideApply:          serverSideApply,
+		ConnectivityProbe:        connectivityProbe,
+		ConnectivityProbeTimeout: probeTimeout,
+		PropagatedLabels:         propagatedLabels,
+		NamespaceMap:             secretToClusterNamespace,
+		WatchNamespaces:          watchNamespaces,
+		ExtraAnnotations:         extraAnnotations,
+		SecretFilters: controller.SecretFilters{
+			Selector:          selector,
+			AllowedNamespaces: allowedNamespaces,
+			DeniedNamespaces:  deniedNamespaces,
+		},
+	}
+	if err = secretReconciler.SetupWithManager(ctx, mgr, ctrl.Log.WithName("clean-stale-resources")); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Secret")
 		os.Exit(1)
 	}
+	if enableWebhook {
+		if err = (&controller.SveltosClusterValidator{
+			AllowedUsers: []string{controllerUser, controller.GarbageCollectorUser},
+		}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "SveltosCluster")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
+	if enableDebugEndpoints {
+		if err := mgr.AddMetricsServerExtraHandler(controller.DebugMappingsPath, secretReconciler.MappingsHandler()); err != nil {
+			setupLog.Error(err, "unable to set up debug endpoint")
+			os.Exit(1)
+		}
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
 	}
-	if err := mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
+	// Not ready if RBAC permissions are revoked while running. This is a ready check, not a health
+	// check: restarting the pod would not give back the permissions.
+	if err := mgr.AddReadyzCheck("rbac", controller.NewPermissionsCheck(mgr.GetClient(), permissionNamespaces)); err != nil {
+		setupLog.Error(err, "unable to set up RBAC ready check")
+		os.Exit(1)
+	}
+	// Ready only once SecretToCluster map is rebuilt and the cache is synced
+	if err := mgr.AddReadyzCheck("readyz", secretReconciler.ReadyzCheck); err != nil {
 		setupLog.Error(err, "unable to set up ready check")
 		os.Exit(1)
 	}
@@ -129,9 +333,9 @@ func initFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&probeAddr, "health-probe-bind-address", ":8081",
 		"The address the probe endpoint binds to.")
 
-	const defaultReconcilers = 10
-	fs.IntVar(&concurrentReconciles, "concurrent-reconciles", defaultReconcilers,
-		"concurrent reconciles is the maximum number of concurrent Reconciles which can be run. Defaults to 10")
+	fs.IntVar(&concurrentReconciles, "concurrent-reconciles", controller.DefaultConcurrentReconciles,
+		fmt.Sprintf("concurrent reconciles is the maximum number of concurrent Reconciles which can be run. Must be at least 1. "+
+			"Defaults to %d", controller.DefaultConcurrentReconciles))
 
 	const defautlRestConfigQPS = 20
 	fs.Float32Var(&restConfigQPS, "kube-api-qps", defautlRestConfigQPS,
@@ -147,8 +351,382 @@ func initFlags(fs *pflag.FlagSet) {
 	fs.IntVar(&webhookPort, "webhook-port", defaultWebhookPort,
 		"Webhook Server port")
 
+	fs.BoolVar(&enableWebhook, "enable-webhook", false,
+		"If true, a validating webhook rejects changes to kubeconfigName and Secret OwnerReferences of Claudie "+
+			"managed SveltosClusters made by anyone but this controller. Requires the webhook manifests to be deployed")
+
+	fs.StringVar(&controllerUser, "controller-user", "system:serviceaccount:projectsveltos:claudie-sveltos-controller",
+		"User this controller runs as. Only this user can modify kubeconfigName and Secret OwnerReferences of "+
+			"Claudie managed SveltosClusters when enable-webhook is set")
+
+	fs.BoolVar(&leaderElect, "leader-elect", false,
+		"Enable leader election for controller manager. Enabling this will ensure there is only one active controller "+
+			"replica reconciling Secrets and removing stale SveltosClusters")
+
+	fs.StringVar(&leaderElectionID, "leader-election-id", "claudie-sveltos-controller.projectsveltos.io",
+		"Name of the Lease used for leader election")
+
+	fs.StringVar(&leaderElectionNS, "leader-election-namespace", "",
+		"Namespace of the Lease used for leader election. Defaults to the namespace the controller runs in")
+
+	fs.BoolVar(&enableDebugEndpoints, "enable-debug-endpoints", false,
+		fmt.Sprintf("If true, the Secret to SveltosCluster mappings currently tracked are served as JSON at %s on the metrics server",
+			controller.DebugMappingsPath))
+
 	const defaultSyncPeriod = 10
 	fs.DurationVar(&syncPeriod, "sync-period", defaultSyncPeriod*time.Minute,
 		fmt.Sprintf("The minimum interval at which watched resources are reconciled (e.g. 15m). Default: %d minutes",
 			defaultSyncPeriod))
+
+	fs.StringVar(&conflictPolicy, "conflict-policy", string(controller.ConflictPolicyRefuse),
+		fmt.Sprintf("What to do when the SveltosCluster for a Claudie Secret is already owned by a different Secret: %s or %s. Default: %s",
+			controller.ConflictPolicyRefuse, controller.ConflictPolicyTakeOver, controller.ConflictPolicyRefuse))
+
+	fs.StringVar(&adoptionPolicy, "adoption-policy", string(controller.AdoptionPolicyAdopt),
+		fmt.Sprintf("What to do when the SveltosCluster for a Claudie Secret already exists but is not managed by Claudie "+
+			"(no Claudie annotation nor Secret owner): %s, %s or %s. Default: %s",
+			controller.AdoptionPolicyAdopt, controller.AdoptionPolicyRefuse, controller.AdoptionPolicySkip,
+			controller.AdoptionPolicyAdopt))
+
+	fs.StringVar(&autoTargetLabel, "auto-target-label", "",
+		"Label, in the form key=value, added to every SveltosCluster created for a Claudie Secret. "+
+			"Users can override its value. If empty (default), no label is added")
+
+	fs.StringVar(&eventFilter, "event-types", string(controller.EventFilterAll),
+		fmt.Sprintf("Which event types are recorded: %s, %s (Normal only) or %s (Warning only). Default: %s",
+			controller.EventFilterAll, controller.EventFilterNormal, controller.EventFilterWarning, controller.EventFilterAll))
+
+	fs.StringVar(&minClaudieVersion, "min-claudie-version", "",
+		"Minimum supported Claudie version (e.g. v0.8.0). Secrets produced by older Claudie versions are ignored. "+
+			"If empty (default), no version check is performed")
+
+	fs.DurationVar(&deletionRetention, "deletion-retention", 0,
+		"How long, after the SveltosCluster for a removed Secret is deleted, events for such Secret are ignored (e.g. 30s). "+
+			"Protects against late-arriving events recreating the SveltosCluster. Default: 0 (disabled)")
+
+	fs.StringVar(&specDefaultsFile, "sveltoscluster-defaults", "",
+		"Path to a YAML file containing the Spec used when creating a SveltosCluster, with optional per Claudie provider "+
+			"overrides (keyed by the claudie.io/provider Secret label)")
+
+	fs.StringVar(&specDefaultsCM, "sveltoscluster-defaults-configmap", "",
+		"ConfigMap, in the form namespace/name, whose defaults.yaml key contains SveltosCluster Spec defaults "+
+			"(same format as sveltoscluster-defaults). Read once at startup. Mutually exclusive with sveltoscluster-defaults")
+
+	fs.Float64Var(&namespaceRate, "namespace-reconcile-rate", 0,
+		"Maximum number of Claudie Secret reconciles per second in a single namespace. Reconciles exceeding it are requeued, "+
+			"so a noisy namespace does not starve the others. Default: 0 (unlimited)")
+
+	const defaultNamespaceBurst = 10
+	fs.IntVar(&namespaceBurst, "namespace-reconcile-burst", defaultNamespaceBurst,
+		fmt.Sprintf("Maximum burst of Claudie Secret reconciles in a single namespace on top of namespace-reconcile-rate. Default: %d",
+			defaultNamespaceBurst))
+
+	fs.StringSliceVar(&allowedNamespaces, "allowed-namespaces", nil,
+		"Comma-separated list of namespaces Claudie Secrets are managed from. If empty (default), all namespaces are allowed")
+
+	fs.StringVar(&secretSelector, "secret-selector", "",
+		"Label selector (e.g. team=a,env!=prod) Claudie Secrets must match, on top of the Claudie labels, to be managed. "+
+			"Lets multiple controller instances each manage a subset of Claudie Secrets. If empty (default), all are managed")
+
+	fs.StringSliceVar(&deniedNamespaces, "denied-namespaces", nil,
+		"Comma-separated list of namespaces whose Claudie Secrets are never managed. Takes precedence over allowed-namespaces")
+
+	fs.BoolVar(&skipOwnerReferences, "skip-owner-references", false,
+		"If true, Claudie Secrets are not added as SveltosCluster OwnerReferences and ownership is tracked via the "+
+			"projectsveltos.io/claudie-secret annotation only. Use it when SveltosCluster metadata is managed by a GitOps tool")
+
+	fs.BoolVar(&controllerOwnerRef, "controller-owner-reference", false,
+		"If true, the Claudie Secret OwnerReference on SveltosClusters has controller and blockOwnerDeletion set, so "+
+			"garbage collection treats the Secret as the SveltosCluster managing owner")
+
+	fs.DurationVar(&driftInterval, "drift-reconcile-interval", 0,
+		"Interval at which all managed SveltosClusters are reconciled, independently of Secret events, to correct drift "+
+			"(e.g. 30m). Default: 0 (disabled)")
+
+	fs.DurationVar(&resyncPeriod, "resync-period", 0,
+		"Interval at which all Claudie Secrets are listed and enqueued for reconciliation, so SveltosClusters which "+
+			"drifted are corrected even when events are missed (e.g. 1h). Default: 0 (disabled)")
+
+	fs.StringVar(&sveltosClusterVer, "sveltoscluster-api-version", "",
+		fmt.Sprintf("lib.projectsveltos.io API version (e.g. v1beta1) SveltosClusters are written at. "+
+			"If %s, the version preferred by the API server is used. "+
+			"If empty (default), the version this controller is built against is used",
+			controller.SveltosClusterVersionPreferred))
+
+	fs.BoolVar(&annotateSecret, "annotate-secret", false,
+		"If true, each Claudie Secret is annotated with the namespace/name of the SveltosCluster created for it")
+
+	fs.BoolVar(&uniqueNameSuffix, "unique-name-suffix", false,
+		"If true, when the claudie.io/cluster label is not a valid resource name and has to be sanitized, a short hash "+
+			"derived from the Secret namespace/name is appended to the SveltosCluster name to guarantee uniqueness")
+
+	fs.BoolVar(&retainOnLabelRemoval, "retain-on-label-removal", false,
+		"If true, the SveltosCluster is left in place when its Secret loses the Claudie labels. "+
+			"By default it is removed, and recreated if labels are added back")
+
+	fs.BoolVar(&retainOnDelete, "retain-on-secret-delete", false,
+		"If true, SveltosClusters are kept when their Claudie Secret is deleted. Secrets are not added as SveltosCluster "+
+			"OwnerReferences, so SveltosClusters are not garbage collected either")
+
+	fs.StringSliceVar(&enforcedSpecFields, "enforced-spec-fields", nil,
+		"Comma-separated list of SveltosCluster spec fields (e.g. kubeconfigName,paused) the controller "+
+			"is allowed to overwrite on existing SveltosClusters. If empty (default), all fields can be enforced")
+
+	fs.StringSliceVar(&specMapping, "annotation-spec-mapping", nil,
+		"Comma-separated list of annotationKey=specFieldPath entries (e.g. example.com/paused=paused). The value of each "+
+			"annotation on a Claudie Secret is set on the corresponding SveltosCluster spec field")
+
+	fs.StringSliceVar(&labelMapping, "annotation-label-mapping", nil,
+		"Comma-separated list of annotationKey=labelKey entries (e.g. claudie.io/provider=topology.kubernetes.io/region). "+
+			"The value of each annotation on a Claudie Secret is set on the corresponding SveltosCluster label. "+
+			"Other SveltosCluster labels are never touched")
+
+	fs.DurationVar(&batchWindow, "batch-window", 0,
+		"When positive, new Claudie Secrets appearing within this window are processed together once the window closes. "+
+			"Useful to smooth bursts of Secrets created by bulk provisioning. Zero (default) disables batching")
+
+	fs.StringVar(&claudieLabel, "claudie-part-of-label", controller.DefaultClaudieLabel,
+		"Label key identifying Secrets produced by Claudie")
+
+	fs.StringVar(&kubeconfigLabel, "claudie-kubeconfig-label", controller.DefaultClaudieKubeconfigLabel,
+		"Label key identifying Claudie Secrets containing a cluster kubeconfig")
+
+	fs.StringVar(&clusterLabel, "claudie-cluster-label", controller.DefaultClaudieClusterLabel,
+		"Label key containing the Claudie cluster name, used as SveltosCluster name")
+
+	fs.BoolVar(&capiSecrets, "enable-capi-secrets", false,
+		"If true, SveltosClusters are created for Cluster API kubeconfig Secrets ({cluster}-kubeconfig) as well, "+
+			"on top of Claudie ones")
+
+	fs.StringVar(&kubeconfigDataKey, "kubeconfig-data-key", "kubeconfig",
+		"Key, in the Claudie Secret, containing the cluster kubeconfig. If not present, the value of the "+
+			"Claudie kubeconfig label is used as key")
+
+	fs.StringVar(&clusterProfileStub, "cluster-profile-stub", "",
+		"Path to a YAML file containing a ClusterProfile (or Profile) template. If set, a stub created from it and "+
+			"targeting only the SveltosCluster is created for each Claudie cluster, and removed with the SveltosCluster")
+
+	fs.BoolVar(&auditLog, "audit-log", false,
+		"If true, every SveltosCluster create, update and delete is logged to stdout as a JSON audit record")
+
+	const defaultStaleSweepInterval = 2
+	fs.DurationVar(&staleSweepInterval, "stale-sweep-interval", defaultStaleSweepInterval*time.Minute,
+		fmt.Sprintf("Interval at which SveltosClusters whose Claudie Secret does not exist anymore are removed. Default: %d minutes",
+			defaultStaleSweepInterval))
+
+	fs.BoolVar(&disableStaleSweep, "disable-stale-sweep", false,
+		"If true, the stale SveltosCluster sweep is not started and cleanup relies solely on Secret events, "+
+			"finalizers and garbage collection")
+
+	fs.DurationVar(&staleGracePeriod, "stale-grace-period", 0,
+		"How long the Claudie Secret of a SveltosCluster must be missing before the stale sweep removes the SveltosCluster "+
+			"(e.g. 10m). Avoids churn when Secrets briefly disappear, for instance during Claudie upgrades. Default: 0 (disabled)")
+
+	fs.BoolVar(&serverSideApply, "server-side-apply", false,
+		"If true, existing SveltosClusters are updated with Server-Side Apply (field manager claudie-sveltos-integration), "+
+			"applying only the fields this controller owns, so fields managed by users or other controllers are never overwritten")
+
+	fs.BoolVar(&connectivityProbe, "enable-connectivity-probe", false,
+		"If true, every time a SveltosCluster is created or its kubeconfig changes, the controller checks in the background "+
+			"whether the cluster API server is reachable with the kubeconfig and reports it with the "+
+			"projectsveltos.io/claudie-reachable and projectsveltos.io/claudie-probed-at SveltosCluster annotations")
+
+	const defaultProbeTimeout = 10
+	fs.DurationVar(&probeTimeout, "connectivity-probe-timeout", defaultProbeTimeout*time.Second,
+		fmt.Sprintf("Timeout of the connectivity probe. Default: %d seconds", defaultProbeTimeout))
+
+	const defaultFailureBackoffBase = 10
+	fs.DurationVar(&failureBackoffBase, "failure-backoff-base", defaultFailureBackoffBase*time.Second,
+		fmt.Sprintf("Delay before retrying a failed reconciliation. It doubles on every consecutive failure of the same "+
+			"Claudie Secret, up to failure-backoff-max, and is reset on success. Default: %d seconds", defaultFailureBackoffBase))
+
+	const defaultFailureBackoffMax = 5
+	fs.DurationVar(&failureBackoffMax, "failure-backoff-max", defaultFailureBackoffMax*time.Minute,
+		fmt.Sprintf("Maximum delay before retrying a failed reconciliation. Default: %d minutes", defaultFailureBackoffMax))
+
+	const defaultAPICallTimeout = 30
+	fs.DurationVar(&apiCallTimeout, "api-call-timeout", defaultAPICallTimeout*time.Second,
+		fmt.Sprintf("Timeout of each call to the API server made while reconciling. Requests exceeding it fail and are "+
+			"retried later. 0 disables it. Default: %d seconds", defaultAPICallTimeout))
+
+	fs.StringSliceVar(&propagatedLabels, "propagated-labels", nil,
+		"Comma-separated list of label keys (e.g. topology.kubernetes.io/region) copied from the Claudie Secret "+
+			"to the SveltosCluster, so ClusterProfiles can match on them. Other SveltosCluster labels are never touched")
+
+	fs.StringSliceVar(&namespaceMap, "namespace-map", nil,
+		"Comma-separated list of secretNamespace=sveltosClusterNamespace entries. SveltosClusters for Claudie Secrets "+
+			"in secretNamespace are created in sveltosClusterNamespace. By default SveltosCluster and Secret share the namespace")
+
+	fs.StringSliceVar(&watchNamespaces, "watch-namespaces", nil,
+		"Comma-separated list of namespaces Secrets are watched in. SveltosClusters must live in one of these namespaces "+
+			"(namespace-map targets are added automatically). If empty (default), Secrets are watched cluster-wide")
+
+	fs.StringToStringVar(&extraAnnotations, "sveltoscluster-annotations", nil,
+		"Comma-separated list of key=value annotations (e.g. cost-center=1234) added to every SveltosCluster created "+
+			"for a Claudie Secret. Other SveltosCluster annotations are never touched")
+}
+
+// apiVersionRegex matches Kubernetes API versions (e.g. v1, v1alpha1, v1beta2)
+var apiVersionRegex = regexp.MustCompile(`^v[1-9][0-9]*((alpha|beta)[1-9][0-9]*)?$`)
+
+// validateFlags verifies flag values are valid
+func validateFlags() error {
+	switch controller.ConflictPolicy(conflictPolicy) {
+	case controller.ConflictPolicyRefuse, controller.ConflictPolicyTakeOver:
+	default:
+		return fmt.Errorf("unknown conflict-policy %q", conflictPolicy)
+	}
+
+	switch controller.AdoptionPolicy(adoptionPolicy) {
+	case controller.AdoptionPolicyAdopt, controller.AdoptionPolicyRefuse, controller.AdoptionPolicySkip:
+	default:
+		return fmt.Errorf("unknown adoption-policy %q", adoptionPolicy)
+	}
+
+	switch controller.EventFilter(eventFilter) {
+	case controller.EventFilterAll, controller.EventFilterNormal, controller.EventFilterWarning:
+	default:
+		return fmt.Errorf("unknown event-types %q", eventFilter)
+	}
+
+	if minClaudieVersion != "" {
+		if _, err := version.ParseGeneric(minClaudieVersion); err != nil {
+			return fmt.Errorf("invalid min-claudie-version %q: %w", minClaudieVersion, err)
+		}
+	}
+
+	if specDefaultsCM != "" {
+		if specDefaultsFile != "" {
+			return fmt.Errorf("sveltoscluster-defaults and sveltoscluster-defaults-configmap are mutually exclusive")
+		}
+		if _, err := controller.ParseSpecDefaultsConfigMap(specDefaultsCM); err != nil {
+			return fmt.Errorf("invalid sveltoscluster-defaults-configmap: %w", err)
+		}
+	}
+
+	if namespaceRate < 0 {
+		return fmt.Errorf("namespace-reconcile-rate must not be negative")
+	}
+
+	if sveltosClusterVer != "" && sveltosClusterVer != controller.SveltosClusterVersionPreferred &&
+		!apiVersionRegex.MatchString(sveltosClusterVer) {
+		return fmt.Errorf("invalid sveltoscluster-api-version %q", sveltosClusterVer)
+	}
+
+	if _, err := controller.ParseSpecMapping(specMapping); err != nil {
+		return fmt.Errorf("invalid annotation-spec-mapping: %w", err)
+	}
+
+	if _, err := controller.ParseLabelMapping(labelMapping); err != nil {
+		return fmt.Errorf("invalid annotation-label-mapping: %w", err)
+	}
+
+	if _, err := labels.Parse(secretSelector); err != nil {
+		return fmt.Errorf("invalid secret-selector: %w", err)
+	}
+
+	if _, err := controller.ParseNamespaceMap(namespaceMap); err != nil {
+		return fmt.Errorf("invalid namespace-map: %w", err)
+	}
+
+	if err := controller.ValidateSpecFields(enforcedSpecFields); err != nil {
+		return fmt.Errorf("invalid enforced-spec-fields: %w", err)
+	}
+
+	if staleSweepInterval <= 0 {
+		return fmt.Errorf("stale-sweep-interval must be positive")
+	}
+
+	if staleGracePeriod < 0 {
+		return fmt.Errorf("stale-grace-period must not be negative")
+	}
+
+	if failureBackoffBase <= 0 || failureBackoffMax <= 0 {
+		return fmt.Errorf("failure-backoff-base and failure-backoff-max must be positive")
+	}
+
+	if failureBackoffBase > failureBackoffMax {
+		return fmt.Errorf("failure-backoff-base must not be greater than failure-backoff-max")
+	}
+
+	if concurrentReconciles < 1 {
+		return fmt.Errorf("concurrent-reconciles must be at least 1")
+	}
+
+	if probeTimeout <= 0 {
+		return fmt.Errorf("connectivity-probe-timeout must be positive")
+	}
+
+	if apiCallTimeout < 0 {
+		return fmt.Errorf("api-call-timeout must not be negative")
+	}
+
+	if driftInterval < 0 {
+		return fmt.Errorf("drift-reconcile-interval must not be negative")
+	}
+
+	if resyncPeriod < 0 {
+		return fmt.Errorf("resync-period must not be negative")
+	}
+
+	if claudieLabel == "" || kubeconfigLabel == "" || clusterLabel == "" {
+		return fmt.Errorf("claudie-part-of-label, claudie-kubeconfig-label and claudie-cluster-label must not be empty")
+	}
+
+	if batchWindow < 0 {
+		return fmt.Errorf("batch-window must not be negative")
+	}
+
+	if namespaceBurst < 1 {
+		return fmt.Errorf("namespace-reconcile-burst must be at least 1")
+	}
+
+	for _, key := range propagatedLabels {
+		if errs := validation.IsQualifiedName(key); len(errs) != 0 {
+			return fmt.Errorf("invalid propagated-labels key %q: %s", key, strings.Join(errs, ", "))
+		}
+	}
+
+	for _, namespace := range watchNamespaces {
+		if errs := validation.IsDNS1123Label(namespace); len(errs) != 0 {
+			return fmt.Errorf("invalid watch-namespaces namespace %q: %s", namespace, strings.Join(errs, ", "))
+		}
+	}
+
+	for key := range extraAnnotations {
+		if errs := validation.IsQualifiedName(key); len(errs) != 0 {
+			return fmt.Errorf("invalid sveltoscluster-annotations key %q: %s", key, strings.Join(errs, ", "))
+		}
+	}
+
+	if autoTargetLabel != "" {
+		key, value := parseLabel(autoTargetLabel)
+		if errs := validation.IsQualifiedName(key); len(errs) != 0 {
+			return fmt.Errorf("invalid auto-target-label key %q: %s", key, strings.Join(errs, ", "))
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) != 0 {
+			return fmt.Errorf("invalid auto-target-label value %q: %s", value, strings.Join(errs, ", "))
+		}
+	}
+
+	return nil
+}
+
+// getCacheNamespaces returns the namespaces the cache is restricted to: watched namespaces and the
+// namespaces SveltosClusters (and mirrored kubeconfigs) are created in according to namespace-map
+func getCacheNamespaces(watched []string, secretToClusterNamespace map[string]string) map[string]cache.Config {
+	namespaces := make(map[string]cache.Config)
+	for _, namespace := range watched {
+		namespaces[namespace] = cache.Config{}
+	}
+	for _, namespace := range secretToClusterNamespace {
+		namespaces[namespace] = cache.Config{}
+	}
+	return namespaces
+}
+
+// parseLabel parses a label in the form key=value
+func parseLabel(label string) (key, value string) {
+	key, value, _ = strings.Cut(label, "=")
+	return key, value
 }
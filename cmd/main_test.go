@@ -0,0 +1,49 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/spf13/pflag"
+
+	"gianlucam76/claudie-sveltos-integration/internal/controller"
+)
+
+var _ = Describe("Flags", func() {
+	var fs *pflag.FlagSet
+
+	BeforeEach(func() {
+		fs = pflag.NewFlagSet("test", pflag.ContinueOnError)
+		initFlags(fs)
+		Expect(fs.Parse([]string{})).To(Succeed())
+	})
+
+	It("concurrent-reconciles defaults to the controller default", func() {
+		Expect(concurrentReconciles).To(Equal(controller.DefaultConcurrentReconciles))
+		Expect(concurrentReconciles).To(Equal(10))
+		Expect(validateFlags()).To(Succeed())
+	})
+
+	It("validateFlags rejects concurrent-reconciles lower than 1", func() {
+		for _, value := range []string{"0", "-1"} {
+			Expect(fs.Set("concurrent-reconciles", value)).To(Succeed())
+			Expect(validateFlags()).To(MatchError(ContainSubstring("concurrent-reconciles must be at least 1")))
+		}
+	})
+})
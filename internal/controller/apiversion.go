@@ -0,0 +1,224 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+	logs "github.com/projectsveltos/libsveltos/lib/logsettings"
+)
+
+const (
+	// SveltosClusterVersionPreferred makes SveltosClusters be written at the version the API server
+	// prefers for lib.projectsveltos.io
+	SveltosClusterVersionPreferred = "preferred"
+)
+
+// getSveltosClusterAPIVersion returns the apiVersion SveltosClusters are written at
+func (r *SecretReconciler) getSveltosClusterAPIVersion() string {
+	version := r.SveltosClusterVersion
+	if version == SveltosClusterVersionPreferred {
+		version = r.getPreferredSveltosClusterVersion()
+	}
+	if version == "" {
+		version = libsveltosv1alpha1.GroupVersion.Version
+	}
+
+	return schema.GroupVersion{Group: libsveltosv1alpha1.GroupVersion.Group, Version: version}.String()
+}
+
+// getPreferredSveltosClusterVersion returns the SveltosCluster version preferred by the API server.
+// While libsveltos is being migrated, a SveltosCluster is served at more than one version. All of them
+// are views of the same object, so SveltosClusters are always written at the preferred one only.
+// Returns an empty string if the preferred version cannot be discovered.
+func (r *SecretReconciler) getPreferredSveltosClusterVersion() string {
+	mapping, err := r.RESTMapper().RESTMapping(
+		schema.GroupKind{Group: libsveltosv1alpha1.GroupVersion.Group, Kind: libsveltosv1alpha1.SveltosClusterKind})
+	if err != nil {
+		return ""
+	}
+
+	return mapping.GroupVersionKind.Version
+}
+
+// isDefaultSveltosClusterVersion returns true if SveltosClusters are written at the version
+// this controller is built against
+func (r *SecretReconciler) isDefaultSveltosClusterVersion() bool {
+	return r.getSveltosClusterAPIVersion() == libsveltosv1alpha1.GroupVersion.String()
+}
+
+// toSveltosClusterVersion returns sveltosCluster at the configured apiVersion. Metadata (including
+// annotations and OwnerReferences) and Spec are preserved. SveltosCluster Spec is the same across
+// served versions and the API server takes care of any conversion.
+func (r *SecretReconciler) toSveltosClusterVersion(sveltosCluster *libsveltosv1alpha1.SveltosCluster,
+) (*unstructured.Unstructured, error) {
+
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(sveltosCluster)
+	if err != nil {
+		return nil, err
+	}
+
+	u := &unstructured.Unstructured{Object: content}
+	u.SetAPIVersion(r.getSveltosClusterAPIVersion())
+	u.SetKind(libsveltosv1alpha1.SveltosClusterKind)
+	// Status is managed by Sveltos
+	delete(u.Object, "status")
+
+	return u, nil
+}
+
+// writeSveltosCluster creates (or updates) sveltosCluster at the configured apiVersion
+func (r *SecretReconciler) writeSveltosCluster(ctx context.Context, sveltosCluster *libsveltosv1alpha1.SveltosCluster,
+	create bool) error {
+
+	if r.isDefaultSveltosClusterVersion() {
+		if create {
+			return r.Create(ctx, sveltosCluster)
+		}
+		return r.Update(ctx, sveltosCluster)
+	}
+
+	u, err := r.toSveltosClusterVersion(sveltosCluster)
+	if err != nil {
+		return err
+	}
+
+	if create {
+		err = r.Create(ctx, u)
+	} else {
+		err = r.Update(ctx, u)
+	}
+	if err != nil {
+		return err
+	}
+
+	// UID is needed by objects owned by SveltosCluster
+	sveltosCluster.UID = u.GetUID()
+	sveltosCluster.ResourceVersion = u.GetResourceVersion()
+	return nil
+}
+
+func (r *SecretReconciler) getSveltosClusterOwnerReference(sveltosCluster *libsveltosv1alpha1.SveltosCluster,
+) metav1.OwnerReference {
+
+	return metav1.OwnerReference{
+		APIVersion: r.getSveltosClusterAPIVersion(),
+		Kind:       libsveltosv1alpha1.SveltosClusterKind,
+		Name:       sveltosCluster.Name,
+		UID:        sveltosCluster.UID,
+	}
+}
+
+// getServedSveltosClusterVersions returns all versions SveltosClusters are served at.
+// Returns nil if they cannot be discovered.
+func (r *SecretReconciler) getServedSveltosClusterVersions() []string {
+	mappings, err := r.RESTMapper().RESTMappings(
+		schema.GroupKind{Group: libsveltosv1alpha1.GroupVersion.Group, Kind: libsveltosv1alpha1.SveltosClusterKind})
+	if err != nil {
+		return nil
+	}
+
+	versions := make([]string, 0, len(mappings))
+	for i := range mappings {
+		versions = append(versions, mappings[i].GroupVersionKind.Version)
+	}
+	return versions
+}
+
+// removeDuplicateSveltosClusters converges, while SveltosClusters are served at more than one version, to the
+// single SveltosCluster kept at the version this controller writes at. Any other SveltosCluster created
+// for secret (e.g. by a previous release writing at another version) is deleted. A SveltosCluster seen
+// at several versions with the UID of the kept one is the same object, and it is left alone.
+func (r *SecretReconciler) removeDuplicateSveltosClusters(ctx context.Context, secret *corev1.Secret,
+	keep types.NamespacedName, logger logr.Logger) error {
+
+	versions := r.getServedSveltosClusterVersions()
+	if len(versions) < 2 {
+		return nil
+	}
+
+	writeGV, err := schema.ParseGroupVersion(r.getSveltosClusterAPIVersion())
+	if err != nil {
+		return err
+	}
+
+	kept := &unstructured.Unstructured{}
+	kept.SetGroupVersionKind(writeGV.WithKind(libsveltosv1alpha1.SveltosClusterKind))
+	if err := r.Get(ctx, keep, kept); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	secretKey := client.ObjectKeyFromObject(secret)
+	for _, version := range versions {
+		if version == writeGV.Version {
+			continue
+		}
+
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(schema.GroupVersionKind{Group: writeGV.Group, Version: version,
+			Kind: libsveltosv1alpha1.SveltosClusterKind + "List"})
+		if err := r.List(ctx, list, client.InNamespace(keep.Namespace)); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+
+		for i := range list.Items {
+			duplicate := &list.Items[i]
+			if duplicate.GetUID() == kept.GetUID() || !duplicate.GetDeletionTimestamp().IsZero() {
+				continue
+			}
+
+			sveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(duplicate.Object, sveltosCluster); err != nil {
+				logger.V(logs.LogInfo).Info(fmt.Sprintf("failed to convert SveltosCluster %s/%s at version %s: %v",
+					duplicate.GetNamespace(), duplicate.GetName(), version, err))
+				continue
+			}
+			if !isSveltosClusterForClaudie(sveltosCluster) || isUnmanaged(sveltosCluster) {
+				continue
+			}
+			if claudieSecret := getClaudieSecret(sveltosCluster); claudieSecret == nil || *claudieSecret != secretKey {
+				continue
+			}
+
+			if err := deleteWithRetry(ctx, r.Client, duplicate); err != nil {
+				return err
+			}
+
+			msg := fmt.Sprintf("removed SveltosCluster %s/%s at version %s duplicating SveltosCluster %s at version %s",
+				duplicate.GetNamespace(), duplicate.GetName(), version, keep, writeGV.Version)
+			logger.V(logs.LogInfo).Info(msg)
+			r.recordEvent(secret, corev1.EventTypeNormal, reasonDuplicateSveltosClusterRemoved, msg)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,245 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"gianlucam76/claudie-sveltos-integration/internal/controller"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+)
+
+var _ = Describe("API version", func() {
+	const preferredVersion = "v1beta1"
+
+	It("toSveltosClusterVersion preserves metadata and spec", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := getSecretReconciler(c)
+		reconciler.SveltosClusterVersion = preferredVersion
+
+		sveltosCluster := &libsveltosv1alpha1.SveltosCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: randomString(),
+				Name:      randomString(),
+				Labels:    map[string]string{randomString(): randomString()},
+				Annotations: map[string]string{
+					controller.SveltosClusterClaudieAnnotation: "ok",
+				},
+				OwnerReferences: []metav1.OwnerReference{
+					{Kind: "Secret", APIVersion: "v1", Name: randomString()},
+				},
+			},
+			Spec: libsveltosv1alpha1.SveltosClusterSpec{
+				KubeconfigName:              randomString(),
+				ConsecutiveFailureThreshold: 5,
+				Paused:                      true,
+			},
+		}
+
+		u, err := controller.ToSveltosClusterVersion(reconciler, sveltosCluster)
+		Expect(err).To(BeNil())
+		Expect(u.GetAPIVersion()).To(Equal(libsveltosv1alpha1.GroupVersion.Group + "/" + preferredVersion))
+		Expect(u.GetKind()).To(Equal(libsveltosv1alpha1.SveltosClusterKind))
+		Expect(u.GetNamespace()).To(Equal(sveltosCluster.Namespace))
+		Expect(u.GetName()).To(Equal(sveltosCluster.Name))
+		Expect(u.GetLabels()).To(Equal(sveltosCluster.Labels))
+		Expect(u.GetAnnotations()).To(Equal(sveltosCluster.Annotations))
+		Expect(u.GetOwnerReferences()).To(Equal(sveltosCluster.OwnerReferences))
+
+		kubeconfigName, _, err := unstructured.NestedString(u.Object, "spec", "kubeconfigName")
+		Expect(err).To(BeNil())
+		Expect(kubeconfigName).To(Equal(sveltosCluster.Spec.KubeconfigName))
+		threshold, _, err := unstructured.NestedInt64(u.Object, "spec", "consecutiveFailureThreshold")
+		Expect(err).To(BeNil())
+		Expect(threshold).To(Equal(int64(sveltosCluster.Spec.ConsecutiveFailureThreshold)))
+		paused, _, err := unstructured.NestedBool(u.Object, "spec", "paused")
+		Expect(err).To(BeNil())
+		Expect(paused).To(BeTrue())
+		Expect(u.Object).ToNot(HaveKey("status"))
+	})
+
+	It("createSveltosCluster writes SveltosCluster at the configured version", func() {
+		var created client.Object
+		c := fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(interceptor.Funcs{
+			Create: func(ctx context.Context, wc client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				if obj.GetObjectKind().GroupVersionKind().Kind == libsveltosv1alpha1.SveltosClusterKind {
+					created = obj
+					return nil
+				}
+				return wc.Create(ctx, obj, opts...)
+			},
+		}).Build()
+		reconciler := getSecretReconciler(c)
+		reconciler.SveltosClusterVersion = preferredVersion
+
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+
+		Expect(created).ToNot(BeNil())
+		u, ok := created.(*unstructured.Unstructured)
+		Expect(ok).To(BeTrue())
+		Expect(u.GetAPIVersion()).To(Equal(libsveltosv1alpha1.GroupVersion.Group + "/" + preferredVersion))
+		Expect(u.GetAnnotations()).To(HaveKey(controller.SveltosClusterClaudieAnnotation))
+		Expect(len(u.GetOwnerReferences())).To(Equal(1))
+		Expect(u.GetOwnerReferences()[0].Name).To(Equal(secret.Name))
+		kubeconfigName, _, err := unstructured.NestedString(u.Object, "spec", "kubeconfigName")
+		Expect(err).To(BeNil())
+		Expect(kubeconfigName).To(Equal(secret.Name))
+	})
+
+	It("createSveltosCluster uses typed SveltosCluster when no version is configured", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := getSecretReconciler(c)
+		reconciler.SveltosClusterVersion = libsveltosv1alpha1.GroupVersion.Version
+
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+
+		currentSveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+		Expect(c.Get(context.TODO(),
+			types.NamespacedName{Namespace: secret.Namespace, Name: secret.Labels[controller.ClaudieCluster]},
+			currentSveltosCluster)).To(Succeed())
+	})
+
+	It("getSveltosClusterAPIVersion uses the API server preferred version when so configured", func() {
+		preferred := schema.GroupVersion{Group: libsveltosv1alpha1.GroupVersion.Group, Version: preferredVersion}
+
+		// SveltosCluster is served at two versions, with v1beta1 preferred
+		mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{preferred, libsveltosv1alpha1.GroupVersion})
+		mapper.Add(preferred.WithKind(libsveltosv1alpha1.SveltosClusterKind), meta.RESTScopeNamespace)
+		mapper.Add(libsveltosv1alpha1.GroupVersion.WithKind(libsveltosv1alpha1.SveltosClusterKind), meta.RESTScopeNamespace)
+
+		var created []client.Object
+		c := fake.NewClientBuilder().WithScheme(scheme).WithRESTMapper(mapper).WithInterceptorFuncs(interceptor.Funcs{
+			Create: func(ctx context.Context, wc client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				if obj.GetObjectKind().GroupVersionKind().Kind == libsveltosv1alpha1.SveltosClusterKind {
+					created = append(created, obj)
+					return nil
+				}
+				return wc.Create(ctx, obj, opts...)
+			},
+		}).Build()
+		reconciler := getSecretReconciler(c)
+		reconciler.SveltosClusterVersion = controller.SveltosClusterVersionPreferred
+		Expect(controller.GetSveltosClusterAPIVersion(reconciler)).To(Equal(preferred.String()))
+
+		// SveltosCluster is written once, at the preferred version only
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+		Expect(created).To(HaveLen(1))
+		Expect(created[0].GetObjectKind().GroupVersionKind().GroupVersion()).To(Equal(preferred))
+
+		// Preferred version cannot be discovered, the built-in one is used
+		c = fake.NewClientBuilder().WithScheme(scheme).WithRESTMapper(meta.NewDefaultRESTMapper(nil)).Build()
+		reconciler = getSecretReconciler(c)
+		reconciler.SveltosClusterVersion = controller.SveltosClusterVersionPreferred
+		Expect(controller.GetSveltosClusterAPIVersion(reconciler)).To(Equal(libsveltosv1alpha1.GroupVersion.String()))
+	})
+
+	DescribeTable("createSveltosCluster converges SveltosClusters present at two versions to one",
+		func(configuredVersion, writtenVersion, duplicateVersion string) {
+			preferred := schema.GroupVersion{Group: libsveltosv1alpha1.GroupVersion.Group, Version: preferredVersion}
+
+			// SveltosCluster is served at two versions, with v1beta1 preferred
+			mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{preferred, libsveltosv1alpha1.GroupVersion})
+			mapper.Add(preferred.WithKind(libsveltosv1alpha1.SveltosClusterKind), meta.RESTScopeNamespace)
+			mapper.Add(libsveltosv1alpha1.GroupVersion.WithKind(libsveltosv1alpha1.SveltosClusterKind), meta.RESTScopeNamespace)
+
+			secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+			Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+
+			// A previous release wrote the SveltosCluster for the same Secret at another version,
+			// under another name
+			duplicate := getSveltosClusterAtVersion(duplicateVersion, secret.Namespace, randomString(), secret)
+			// SveltosCluster for another Secret is left alone
+			other := getSveltosClusterAtVersion(duplicateVersion, secret.Namespace, randomString(),
+				getClaudieSecretWithKubeconfig("cluster-b", "cluster-b"))
+
+			c := fake.NewClientBuilder().WithScheme(scheme).WithRESTMapper(mapper).
+				WithObjects(secret, duplicate, other).Build()
+			reconciler := getSecretReconciler(c)
+			reconciler.SveltosClusterVersion = configuredVersion
+
+			Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+
+			written := listSveltosClustersAtVersion(c, writtenVersion, secret.Namespace)
+			Expect(written).To(HaveLen(1))
+			Expect(written[0].GetName()).To(Equal(secret.Labels[controller.ClaudieCluster]))
+
+			duplicates := listSveltosClustersAtVersion(c, duplicateVersion, secret.Namespace)
+			Expect(duplicates).To(HaveLen(1))
+			Expect(duplicates[0].GetName()).To(Equal(other.GetName()))
+
+			// The API server serves the kept SveltosCluster at every version, always with the same UID.
+			// It is the same object, so it is not removed.
+			sameObject := getSveltosClusterAtVersion(duplicateVersion, secret.Namespace, written[0].GetName(), secret)
+			sameObject.SetUID(written[0].GetUID())
+			Expect(c.Create(context.TODO(), sameObject)).To(Succeed())
+			sveltosClusterKey := types.NamespacedName{Namespace: secret.Namespace, Name: written[0].GetName()}
+			Expect(controller.RemoveDuplicateSveltosClusters(reconciler, context.TODO(), secret, sveltosClusterKey,
+				logr.Logger{})).To(Succeed())
+			Expect(listSveltosClustersAtVersion(c, writtenVersion, secret.Namespace)).To(HaveLen(1))
+			Expect(listSveltosClustersAtVersion(c, duplicateVersion, secret.Namespace)).To(HaveLen(2))
+		},
+		Entry("preferred version written, stale one at built-in version", controller.SveltosClusterVersionPreferred,
+			preferredVersion, libsveltosv1alpha1.GroupVersion.Version),
+		Entry("built-in version written, stale one at another version", libsveltosv1alpha1.GroupVersion.Version,
+			libsveltosv1alpha1.GroupVersion.Version, preferredVersion),
+	)
+})
+
+// getSveltosClusterAtVersion returns a SveltosCluster at version created by this controller for secret
+func getSveltosClusterAtVersion(version, namespace, name string, secret *corev1.Secret) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion(libsveltosv1alpha1.GroupVersion.Group + "/" + version)
+	u.SetKind(libsveltosv1alpha1.SveltosClusterKind)
+	u.SetNamespace(namespace)
+	u.SetName(name)
+	u.SetUID(types.UID(randomString()))
+	u.SetAnnotations(map[string]string{controller.SveltosClusterClaudieAnnotation: "ok"})
+	u.SetOwnerReferences([]metav1.OwnerReference{
+		{Kind: "Secret", APIVersion: "v1", Name: secret.Name, UID: secret.UID},
+	})
+	Expect(unstructured.SetNestedField(u.Object, secret.Name, "spec", "kubeconfigName")).To(Succeed())
+	return u
+}
+
+// listSveltosClustersAtVersion returns the SveltosClusters in namespace stored at version
+func listSveltosClustersAtVersion(c client.Client, version, namespace string) []unstructured.Unstructured {
+	list := &unstructured.UnstructuredList{}
+	list.SetAPIVersion(libsveltosv1alpha1.GroupVersion.Group + "/" + version)
+	list.SetKind(libsveltosv1alpha1.SveltosClusterKind + "List")
+	Expect(c.List(context.TODO(), list, client.InNamespace(namespace))).To(Succeed())
+	return list.Items
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+)
+
+const (
+	// sveltosClusterFieldManager is the field manager used when SveltosClusters are written
+	// with Server-Side Apply
+	sveltosClusterFieldManager = "claudie-sveltos-integration"
+
+	// claudieAnnotationPrefix is the prefix of all annotations this controller sets on SveltosClusters
+	claudieAnnotationPrefix = "projectsveltos.io/claudie"
+)
+
+// getOwnedSpecFields returns the top level SveltosCluster Spec fields this controller sets for secret
+func (r *SecretReconciler) getOwnedSpecFields(secret *corev1.Secret) map[string]bool {
+	owned := map[string]bool{"kubeconfigName": true}
+
+	for annotation, path := range r.SpecMapping {
+		if _, ok := secret.Annotations[annotation]; ok {
+			owned[strings.Split(path, ".")[0]] = true
+		}
+	}
+
+	for i := range r.EnforcedSpecFields {
+		owned[r.EnforcedSpecFields[i]] = true
+	}
+
+	if _, ok := secret.Annotations[pausedAnnotation]; ok {
+		owned["paused"] = true
+	}
+
+	return owned
+}
+
+// getOwnedLabels returns the SveltosCluster label keys this controller sets: the auto-target label,
+// the propagated labels and the labels Secret annotations are mapped to
+func (r *SecretReconciler) getOwnedLabels() []string {
+	var owned []string
+	if r.AutoTargetLabelKey != "" {
+		owned = append(owned, r.AutoTargetLabelKey)
+	}
+
+	owned = append(owned, r.PropagatedLabels...)
+
+	for _, label := range r.LabelMapping {
+		owned = append(owned, label)
+	}
+
+	return owned
+}
+
+// getSveltosClusterApplyObject returns the Server-Side Apply configuration for desired, the SveltosCluster
+// as this controller wants it for secret. It only contains the fields this controller owns: Claudie
+// annotations (and ExtraAnnotations), auto-target and propagated labels, Secret OwnerReferences and
+// the Spec fields it sets. Any other field is left to its manager (e.g. user set labels and annotations).
+func (r *SecretReconciler) getSveltosClusterApplyObject(desired *libsveltosv1alpha1.SveltosCluster,
+	secret *corev1.Secret) (*unstructured.Unstructured, error) {
+
+	apply := &unstructured.Unstructured{}
+	apply.SetAPIVersion(r.getSveltosClusterAPIVersion())
+	apply.SetKind(libsveltosv1alpha1.SveltosClusterKind)
+	apply.SetNamespace(desired.Namespace)
+	apply.SetName(desired.Name)
+
+	annotations := make(map[string]string)
+	for key, value := range desired.Annotations {
+		if _, extra := r.ExtraAnnotations[key]; extra || strings.HasPrefix(key, claudieAnnotationPrefix) {
+			annotations[key] = value
+		}
+	}
+	apply.SetAnnotations(annotations)
+
+	labels := make(map[string]string)
+	for _, key := range r.getOwnedLabels() {
+		if value, ok := desired.Labels[key]; ok {
+			labels[key] = value
+		}
+	}
+	if len(labels) != 0 {
+		apply.SetLabels(labels)
+	}
+
+	ownerReferences := make([]metav1.OwnerReference, 0)
+	for _, ref := range desired.OwnerReferences {
+		if ref.Kind == "Secret" {
+			ownerReferences = append(ownerReferences, ref)
+		}
+	}
+	if len(ownerReferences) != 0 {
+		apply.SetOwnerReferences(ownerReferences)
+	}
+
+	desiredSpec, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&desired.Spec)
+	if err != nil {
+		return nil, err
+	}
+	spec := make(map[string]interface{})
+	for field := range r.getOwnedSpecFields(secret) {
+		if value, ok := desiredSpec[field]; ok {
+			spec[field] = value
+		}
+	}
+	if err := unstructured.SetNestedField(apply.Object, spec, "spec"); err != nil {
+		return nil, err
+	}
+
+	return apply, nil
+}
+
+// applySveltosCluster writes, with Server-Side Apply, the fields this controller owns in desired.
+// Ownership of such fields is forced, so the controller always converges them.
+func (r *SecretReconciler) applySveltosCluster(ctx context.Context, desired *libsveltosv1alpha1.SveltosCluster,
+	secret *corev1.Secret) error {
+
+	apply, err := r.getSveltosClusterApplyObject(desired, secret)
+	if err != nil {
+		return err
+	}
+
+	err = r.Patch(ctx, apply, client.Apply, client.FieldOwner(sveltosClusterFieldManager), client.ForceOwnership)
+	if err != nil {
+		return err
+	}
+
+	// UID is needed by objects owned by SveltosCluster
+	desired.UID = apply.GetUID()
+	desired.ResourceVersion = apply.GetResourceVersion()
+	return nil
+}
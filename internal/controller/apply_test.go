@@ -0,0 +1,153 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+
+	"gianlucam76/claudie-sveltos-integration/internal/controller"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+)
+
+var _ = Describe("Server-Side Apply", func() {
+	It("getSveltosClusterApplyObject only contains the fields this controller owns", func() {
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := getSecretReconciler(c)
+		reconciler.ExtraAnnotations = map[string]string{"example.com/team": "a"}
+		reconciler.PropagatedLabels = []string{"env"}
+
+		userLabel := randomString()
+		userAnnotation := randomString()
+		desired := &libsveltosv1alpha1.SveltosCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: secret.Namespace,
+				Name:      randomString(),
+				Labels: map[string]string{
+					userLabel: randomString(),
+					"env":     "prod",
+				},
+				Annotations: map[string]string{
+					userAnnotation: randomString(),
+					controller.SveltosClusterClaudieAnnotation: "ok",
+					"example.com/team":                         "a",
+				},
+				OwnerReferences: []metav1.OwnerReference{
+					{APIVersion: "v1", Kind: "Secret", Name: secret.Name},
+					{APIVersion: "example.com/v1", Kind: randomString(), Name: randomString()},
+				},
+			},
+			Spec: libsveltosv1alpha1.SveltosClusterSpec{
+				KubeconfigName:              secret.Name,
+				ConsecutiveFailureThreshold: 5,
+			},
+		}
+
+		apply, err := controller.GetSveltosClusterApply(reconciler, desired, secret)
+		Expect(err).To(BeNil())
+		Expect(apply.GetName()).To(Equal(desired.Name))
+		Expect(apply.GetAnnotations()).To(Equal(map[string]string{
+			controller.SveltosClusterClaudieAnnotation: "ok",
+			"example.com/team":                         "a",
+		}))
+		Expect(apply.GetLabels()).To(Equal(map[string]string{"env": "prod"}))
+		Expect(apply.GetOwnerReferences()).To(HaveLen(1))
+		Expect(apply.GetOwnerReferences()[0].Kind).To(Equal("Secret"))
+
+		spec, _, err := unstructured.NestedMap(apply.Object, "spec")
+		Expect(err).To(BeNil())
+		Expect(spec).To(Equal(map[string]interface{}{"kubeconfigName": secret.Name}))
+	})
+
+	It("createSveltosCluster updates SveltosCluster with Server-Side Apply leaving user fields alone", func() {
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+
+		userLabel := randomString()
+		sveltosCluster := &libsveltosv1alpha1.SveltosCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: secret.Namespace,
+				Name:      secret.Labels[controller.ClaudieCluster],
+				Labels:    map[string]string{userLabel: "true"},
+			},
+			Spec: libsveltosv1alpha1.SveltosClusterSpec{
+				ConsecutiveFailureThreshold: 5,
+			},
+		}
+
+		// Fake client does not support Server-Side Apply. Apply configuration is captured instead.
+		var applied *unstructured.Unstructured
+		patchOptions := &client.PatchOptions{}
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret, sveltosCluster).
+			WithInterceptorFuncs(interceptor.Funcs{
+				Update: func(ctx context.Context, wc client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+					_, ok := obj.(*libsveltosv1alpha1.SveltosCluster)
+					Expect(ok).To(BeFalse(), "SveltosCluster must not be updated")
+					return wc.Update(ctx, obj, opts...)
+				},
+				Patch: func(ctx context.Context, wc client.WithWatch, obj client.Object, patch client.Patch,
+					opts ...client.PatchOption) error {
+
+					if u, ok := obj.(*unstructured.Unstructured); ok && patch == client.Apply {
+						applied = u.DeepCopy()
+						patchOptions.ApplyOptions(opts)
+						return nil
+					}
+					return wc.Patch(ctx, obj, patch, opts...)
+				},
+			}).Build()
+
+		reconciler := getSecretReconciler(c)
+		reconciler.ServerSideApply = true
+
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+
+		Expect(applied).ToNot(BeNil())
+		Expect(patchOptions.FieldManager).To(Equal(controller.SveltosClusterFieldManager))
+		Expect(patchOptions.Force).ToNot(BeNil())
+		Expect(*patchOptions.Force).To(BeTrue())
+
+		Expect(applied.GetAnnotations()).To(HaveKey(controller.SveltosClusterClaudieAnnotation))
+		Expect(applied.GetOwnerReferences()).To(HaveLen(1))
+		Expect(applied.GetOwnerReferences()[0].Name).To(Equal(secret.Name))
+		kubeconfigName, _, _ := unstructured.NestedString(applied.Object, "spec", "kubeconfigName")
+		Expect(kubeconfigName).To(Equal(secret.Name))
+
+		// User set fields are not part of the apply configuration, so they survive it
+		Expect(applied.GetLabels()).ToNot(HaveKey(userLabel))
+		_, found, _ := unstructured.NestedFieldNoCopy(applied.Object, "spec", "consecutiveFailureThreshold")
+		Expect(found).To(BeFalse())
+
+		currentSveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+		Expect(c.Get(context.TODO(), types.NamespacedName{Namespace: sveltosCluster.Namespace, Name: sveltosCluster.Name},
+			currentSveltosCluster)).To(Succeed())
+		Expect(currentSveltosCluster.Labels).To(HaveKey(userLabel))
+		Expect(currentSveltosCluster.Spec.ConsecutiveFailureThreshold).To(Equal(5))
+	})
+})
@@ -0,0 +1,99 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// auditActor is the actor reported for all actions taken by this controller
+	auditActor = "claudie-sveltos-controller"
+)
+
+// AuditAction describes a change this controller made to a SveltosCluster
+type AuditAction struct {
+	// Action is one of create, update or delete
+	Action string
+
+	// Secret is the Claudie Secret the SveltosCluster is for
+	Secret types.NamespacedName
+
+	// SveltosCluster is the SveltosCluster which was changed
+	SveltosCluster types.NamespacedName
+
+	// Actor is who took the action
+	Actor string
+
+	// Timestamp is when the action was taken
+	Timestamp time.Time
+}
+
+// AuditSink receives every change this controller makes to SveltosClusters, so an audit trail
+// can be shipped to an external system (e.g. a SIEM)
+type AuditSink interface {
+	RecordAction(ctx context.Context, action AuditAction)
+}
+
+// NoopAuditSink discards all actions
+type NoopAuditSink struct{}
+
+// RecordAction does nothing
+func (NoopAuditSink) RecordAction(_ context.Context, _ AuditAction) {}
+
+// SlogAuditSink logs each action as a structured log record
+type SlogAuditSink struct {
+	Logger *slog.Logger
+}
+
+// NewSlogAuditSink returns an AuditSink logging actions with logger
+func NewSlogAuditSink(logger *slog.Logger) *SlogAuditSink {
+	return &SlogAuditSink{Logger: logger}
+}
+
+// RecordAction logs action
+func (s *SlogAuditSink) RecordAction(ctx context.Context, action AuditAction) {
+	s.Logger.InfoContext(ctx, "SveltosCluster audit",
+		slog.String("action", action.Action),
+		slog.String("secret", action.Secret.String()),
+		slog.String("sveltosCluster", action.SveltosCluster.String()),
+		slog.String("actor", action.Actor),
+		slog.Time("timestamp", action.Timestamp))
+}
+
+// getAuditSink returns the configured AuditSink. Defaults to NoopAuditSink.
+func (r *SecretReconciler) getAuditSink() AuditSink {
+	if r.AuditSink == nil {
+		return NoopAuditSink{}
+	}
+	return r.AuditSink
+}
+
+// recordAudit reports to the AuditSink that action was taken on the SveltosCluster of secret
+func (r *SecretReconciler) recordAudit(ctx context.Context, action string, secret, sveltosCluster types.NamespacedName) {
+	r.getAuditSink().RecordAction(ctx, AuditAction{
+		Action:         action,
+		Secret:         secret,
+		SveltosCluster: sveltosCluster,
+		Actor:          auditActor,
+		Timestamp:      time.Now().UTC(),
+	})
+}
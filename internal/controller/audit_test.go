@@ -0,0 +1,114 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"gianlucam76/claudie-sveltos-integration/internal/controller"
+)
+
+// capturingAuditSink records all actions it receives
+type capturingAuditSink struct {
+	mux     sync.Mutex
+	actions []controller.AuditAction
+}
+
+func (s *capturingAuditSink) RecordAction(_ context.Context, action controller.AuditAction) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.actions = append(s.actions, action)
+}
+
+var _ = Describe("Audit", func() {
+	It("records SveltosCluster create, update and delete", func() {
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+		reconciler := getSecretReconciler(c)
+		sink := &capturingAuditSink{}
+		reconciler.AuditSink = sink
+
+		secretKey := types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name}
+		sveltosClusterKey := types.NamespacedName{Namespace: secret.Namespace, Name: secret.Labels[controller.ClaudieCluster]}
+
+		before := time.Now().UTC()
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+
+		// No-op reconcile is not audited
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+
+		secret.Labels[controller.ZoneLabel] = randomString()
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+
+		Expect(controller.CleanSveltosCluster(reconciler, context.TODO(), ctrl.Request{NamespacedName: secretKey},
+			logr.Logger{})).To(Succeed())
+
+		Expect(sink.actions).To(HaveLen(3))
+		for i, action := range []string{controller.ActionCreate, controller.ActionUpdate, controller.ActionDelete} {
+			Expect(sink.actions[i].Action).To(Equal(action))
+			Expect(sink.actions[i].Secret).To(Equal(secretKey))
+			Expect(sink.actions[i].SveltosCluster).To(Equal(sveltosClusterKey))
+			Expect(sink.actions[i].Actor).ToNot(BeEmpty())
+			Expect(sink.actions[i].Timestamp).ToNot(BeTemporally("<", before))
+		}
+	})
+
+	It("SlogAuditSink logs actions as structured records", func() {
+		var buffer bytes.Buffer
+		sink := controller.NewSlogAuditSink(slog.New(slog.NewJSONHandler(&buffer, nil)))
+
+		action := controller.AuditAction{
+			Action:         controller.ActionCreate,
+			Secret:         types.NamespacedName{Namespace: randomString(), Name: randomString()},
+			SveltosCluster: types.NamespacedName{Namespace: randomString(), Name: randomString()},
+			Actor:          randomString(),
+			Timestamp:      time.Now().UTC(),
+		}
+		sink.RecordAction(context.TODO(), action)
+
+		record := map[string]interface{}{}
+		Expect(json.Unmarshal(buffer.Bytes(), &record)).To(Succeed())
+		Expect(record["action"]).To(Equal(action.Action))
+		Expect(record["secret"]).To(Equal(action.Secret.String()))
+		Expect(record["sveltosCluster"]).To(Equal(action.SveltosCluster.String()))
+		Expect(record["actor"]).To(Equal(action.Actor))
+		Expect(record["timestamp"]).ToNot(BeNil())
+	})
+
+	It("NoopAuditSink is used by default", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := getSecretReconciler(c)
+
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+	})
+})
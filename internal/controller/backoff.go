@@ -0,0 +1,62 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// defaultFailureBackoffMax is the default maximum delay before a failed reconciliation is retried
+	defaultFailureBackoffMax = 5 * time.Minute
+
+	// Overall retry rate, shared by all Secrets, as in controller-runtime default rate limiter
+	failureRetryQPS   = 10
+	failureRetryBurst = 100
+)
+
+// newFailureRateLimiter returns the rate limiter used to requeue failed reconciliations: the delay
+// starts at base, doubles on every consecutive failure of the same Secret up to maxDelay, and is
+// reset once the Secret reconciles successfully. So a persistent error (e.g. a webhook being down)
+// does not hammer the API server, while transient errors are still retried quickly.
+func newFailureRateLimiter(base, maxDelay time.Duration) workqueue.TypedRateLimiter[reconcile.Request] {
+	return workqueue.NewTypedMaxOfRateLimiter(
+		workqueue.NewTypedItemExponentialFailureRateLimiter[reconcile.Request](base, maxDelay),
+		&workqueue.TypedBucketRateLimiter[reconcile.Request]{
+			Limiter: rate.NewLimiter(rate.Limit(failureRetryQPS), failureRetryBurst)},
+	)
+}
+
+// getFailureBackoffBase returns the delay before the first retry of a failed reconciliation
+func (r *SecretReconciler) getFailureBackoffBase() time.Duration {
+	if r.FailureBackoffBase <= 0 {
+		return normalRequeueAfter
+	}
+	return r.FailureBackoffBase
+}
+
+// getFailureBackoffMax returns the maximum delay before a failed reconciliation is retried
+func (r *SecretReconciler) getFailureBackoffMax() time.Duration {
+	if r.FailureBackoffMax <= 0 {
+		return defaultFailureBackoffMax
+	}
+	return r.FailureBackoffMax
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"gianlucam76/claudie-sveltos-integration/internal/controller"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+)
+
+var _ = Describe("Failure backoff", func() {
+	It("newFailureRateLimiter doubles the delay on every failure up to the maximum and resets it", func() {
+		rateLimiter := controller.NewFailureRateLimiter(time.Second, 3*time.Second)
+
+		secretRef := reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: randomString(), Name: randomString()},
+		}
+		for _, expected := range []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second} {
+			Expect(rateLimiter.When(secretRef)).To(Equal(expected))
+		}
+
+		// Failures of other Secrets do not affect it
+		otherRef := reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: randomString(), Name: randomString()},
+		}
+		Expect(rateLimiter.When(otherRef)).To(Equal(time.Second))
+
+		rateLimiter.Forget(secretRef)
+		Expect(rateLimiter.When(secretRef)).To(Equal(time.Second))
+	})
+
+	It("Reconcile returns failures as errors so they are retried by the rate limiter", func() {
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+
+		failCreate := true
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).WithInterceptorFuncs(interceptor.Funcs{
+			Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				if _, ok := obj.(*libsveltosv1alpha1.SveltosCluster); ok && failCreate {
+					return errors.New("webhook unavailable")
+				}
+				return c.Create(ctx, obj, opts...)
+			},
+		}).Build()
+
+		reconciler := getSecretReconciler(c)
+
+		secretRef := reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name},
+		}
+
+		result, err := reconciler.Reconcile(context.TODO(), secretRef)
+		Expect(err).To(MatchError(ContainSubstring("webhook unavailable")))
+		Expect(result).To(Equal(reconcile.Result{}))
+
+		failCreate = false
+		result, err = reconciler.Reconcile(context.TODO(), secretRef)
+		Expect(err).To(BeNil())
+		Expect(result.Requeue).To(BeFalse())
+	})
+})
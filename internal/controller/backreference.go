@@ -0,0 +1,82 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	logs "github.com/projectsveltos/libsveltos/lib/logsettings"
+)
+
+const (
+	// secretSveltosClusterAnnotation is set, when AnnotateSecret is true, on the Claudie Secret
+	// and contains the namespace/name of the SveltosCluster created for it
+	secretSveltosClusterAnnotation = "projectsveltos.io/sveltoscluster"
+)
+
+// annotateSecret records, if AnnotateSecret is set, the SveltosCluster created for secret as
+// annotation on the Secret. Secret is updated only if the annotation changed.
+// Failures (for instance Secret cannot be updated) are logged only, as the back-reference
+// is informational and must not block SveltosCluster reconciliation.
+func (r *SecretReconciler) annotateSecret(ctx context.Context, secret *corev1.Secret,
+	sveltosCluster types.NamespacedName, logger logr.Logger) {
+
+	if !r.AnnotateSecret {
+		return
+	}
+
+	value := sveltosCluster.String()
+	if secret.Annotations[secretSveltosClusterAnnotation] == value {
+		return
+	}
+
+	currentSecret := secret.DeepCopy()
+	if currentSecret.Annotations == nil {
+		currentSecret.Annotations = make(map[string]string)
+	}
+	currentSecret.Annotations[secretSveltosClusterAnnotation] = value
+
+	if err := r.Update(ctx, currentSecret); err != nil {
+		logger.V(logs.LogInfo).Info(fmt.Sprintf("failed to annotate Secret with SveltosCluster %s: %v", value, err))
+		return
+	}
+
+	secret.Annotations = currentSecret.Annotations
+	secret.ResourceVersion = currentSecret.ResourceVersion
+}
+
+// removeSecretBackReference removes, if present, the SveltosCluster back-reference annotation from secret
+func (r *SecretReconciler) removeSecretBackReference(ctx context.Context, secret *corev1.Secret) error {
+	if _, ok := secret.Annotations[secretSveltosClusterAnnotation]; !ok {
+		return nil
+	}
+
+	currentSecret := secret.DeepCopy()
+	delete(currentSecret.Annotations, secretSveltosClusterAnnotation)
+	if err := r.Update(ctx, currentSecret); err != nil {
+		return err
+	}
+
+	secret.Annotations = currentSecret.Annotations
+	secret.ResourceVersion = currentSecret.ResourceVersion
+	return nil
+}
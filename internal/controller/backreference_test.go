@@ -0,0 +1,118 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	"gianlucam76/claudie-sveltos-integration/internal/controller"
+)
+
+var _ = Describe("Back reference", func() {
+	It("createSveltosCluster annotates Secret with SveltosCluster and updates it on rename", func() {
+		secretUpdates := 0
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		secret.Finalizers = []string{controller.ClaudieCleanupFinalizer}
+		Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).WithInterceptorFuncs(interceptor.Funcs{
+			Update: func(ctx context.Context, wc client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+				if _, ok := obj.(*corev1.Secret); ok {
+					secretUpdates++
+				}
+				return wc.Update(ctx, obj, opts...)
+			},
+		}).Build()
+		reconciler := getSecretReconciler(c)
+		reconciler.AnnotateSecret = true
+
+		secretKey := types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name}
+		Expect(c.Get(context.TODO(), secretKey, secret)).To(Succeed())
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+
+		currentSecret := &corev1.Secret{}
+		Expect(c.Get(context.TODO(), secretKey, currentSecret)).To(Succeed())
+		Expect(currentSecret.Annotations[controller.SecretSveltosClusterAnnotation]).
+			To(Equal(secret.Namespace + "/" + secret.Labels[controller.ClaudieCluster]))
+		Expect(secretUpdates).To(Equal(1))
+
+		// No change, no update
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), currentSecret, logr.Logger{})).To(Succeed())
+		Expect(secretUpdates).To(Equal(1))
+
+		// SveltosCluster name changes
+		newName := randomString()
+		currentSecret.Labels[controller.ClaudieCluster] = newName
+		Expect(c.Update(context.TODO(), currentSecret)).To(Succeed())
+		secretUpdates = 0
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), currentSecret, logr.Logger{})).To(Succeed())
+
+		Expect(c.Get(context.TODO(), secretKey, currentSecret)).To(Succeed())
+		Expect(currentSecret.Annotations[controller.SecretSveltosClusterAnnotation]).
+			To(Equal(secret.Namespace + "/" + newName))
+		Expect(secretUpdates).To(Equal(1))
+	})
+
+	It("createSveltosCluster succeeds when Secret cannot be annotated", func() {
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		immutable := true
+		secret.Immutable = &immutable
+		secret.Finalizers = []string{controller.ClaudieCleanupFinalizer}
+		Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).WithInterceptorFuncs(interceptor.Funcs{
+			Update: func(ctx context.Context, wc client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+				if _, ok := obj.(*corev1.Secret); ok {
+					return apierrors.NewInvalid(schema.GroupKind{Kind: "Secret"}, obj.GetName(),
+						field.ErrorList{field.Forbidden(field.NewPath("metadata"), "field is immutable")})
+				}
+				return wc.Update(ctx, obj, opts...)
+			},
+		}).Build()
+		reconciler := getSecretReconciler(c)
+		reconciler.AnnotateSecret = true
+
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+		Expect(secret.Annotations).ToNot(HaveKey(controller.SecretSveltosClusterAnnotation))
+	})
+
+	It("createSveltosCluster does not annotate Secret by default", func() {
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+		reconciler := getSecretReconciler(c)
+
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+
+		currentSecret := &corev1.Secret{}
+		Expect(c.Get(context.TODO(), types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name},
+			currentSecret)).To(Succeed())
+		Expect(currentSecret.Annotations).ToNot(HaveKey(controller.SecretSveltosClusterAnnotation))
+	})
+})
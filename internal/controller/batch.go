@@ -0,0 +1,71 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// getBatchDelay returns how long reconciliation of a Secret not yet tracked must be delayed.
+// Secrets first seen while a batch is open join that batch and are all released when the batch
+// window closes, so bursts of new Secrets (bulk Claudie provisioning) are processed together
+// instead of competing for the SecretToCluster lock as they appear.
+// Returns zero if the Secret can be processed now.
+func (r *SecretReconciler) getBatchDelay(secretKey types.NamespacedName) time.Duration {
+	if r.BatchWindow <= 0 {
+		return 0
+	}
+
+	if _, tracked := r.secretToCluster.Get(secretKey); tracked {
+		return 0
+	}
+
+	r.batchMux.Lock()
+	defer r.batchMux.Unlock()
+
+	now := time.Now()
+	if deadline, ok := r.batchedSecrets[secretKey]; ok {
+		if now.Before(deadline) {
+			return deadline.Sub(now)
+		}
+		// Batch window closed. Secret is processed now.
+		delete(r.batchedSecrets, secretKey)
+		return 0
+	}
+
+	if r.batchedSecrets == nil {
+		r.batchedSecrets = make(map[types.NamespacedName]time.Time)
+	}
+
+	if !now.Before(r.batchDeadline) {
+		// No open batch. Open a new one.
+		r.batchDeadline = now.Add(r.BatchWindow)
+	}
+
+	r.batchedSecrets[secretKey] = r.batchDeadline
+	return r.batchDeadline.Sub(now)
+}
+
+// removeFromBatch forgets a Secret waiting for its batch window to close
+func (r *SecretReconciler) removeFromBatch(secretKey types.NamespacedName) {
+	r.batchMux.Lock()
+	defer r.batchMux.Unlock()
+
+	delete(r.batchedSecrets, secretKey)
+}
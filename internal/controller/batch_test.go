@@ -0,0 +1,89 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"gianlucam76/claudie-sveltos-integration/internal/controller"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+)
+
+var _ = Describe("Batching", func() {
+	It("Reconcile processes a burst of new Secrets once the batch window closes", func() {
+		const burst = 5
+		secrets := make([]*corev1.Secret, burst)
+		objects := make([]client.Object, burst)
+		for i := 0; i < burst; i++ {
+			secrets[i] = getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+			Expect(addTypeInformationToObject(scheme, secrets[i])).To(Succeed())
+			objects[i] = secrets[i]
+		}
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+		reconciler := getSecretReconciler(c)
+		reconciler.BatchWindow = 200 * time.Millisecond
+
+		request := func(secret *corev1.Secret) reconcile.Request {
+			return reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name},
+			}
+		}
+
+		// All Secrets join the same batch and are delayed
+		for i := range secrets {
+			result, err := reconciler.Reconcile(context.TODO(), request(secrets[i]))
+			Expect(err).To(BeNil())
+			Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+			Expect(result.RequeueAfter).To(BeNumerically("<=", reconciler.BatchWindow))
+		}
+
+		sveltosClusters := &libsveltosv1alpha1.SveltosClusterList{}
+		Expect(c.List(context.TODO(), sveltosClusters)).To(Succeed())
+		Expect(sveltosClusters.Items).To(BeEmpty())
+
+		time.Sleep(reconciler.BatchWindow)
+
+		for i := range secrets {
+			result, err := reconciler.Reconcile(context.TODO(), request(secrets[i]))
+			Expect(err).To(BeNil())
+			Expect(result.RequeueAfter).To(BeZero())
+		}
+
+		for i := range secrets {
+			sveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+			Expect(c.Get(context.TODO(), types.NamespacedName{Namespace: secrets[i].Namespace,
+				Name: secrets[i].Labels[controller.ClaudieCluster]}, sveltosCluster)).To(Succeed())
+			Expect(sveltosCluster.Spec.KubeconfigName).To(Equal(secrets[i].Name))
+		}
+
+		// Secrets already managed are never delayed
+		result, err := reconciler.Reconcile(context.TODO(), request(secrets[0]))
+		Expect(err).To(BeNil())
+		Expect(result.RequeueAfter).To(BeZero())
+	})
+})
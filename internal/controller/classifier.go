@@ -0,0 +1,199 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// capiClusterNameLabel is set by Cluster API on the kubeconfig Secret of a cluster and contains
+	// the cluster name
+	capiClusterNameLabel = "cluster.x-k8s.io/cluster-name"
+
+	// capiSecretType is the type of the Secrets generated by Cluster API
+	capiSecretType corev1.SecretType = "cluster.x-k8s.io/secret"
+
+	// capiKubeconfigSuffix is appended by Cluster API to the cluster name to get the name of
+	// the Secret containing its kubeconfig
+	capiKubeconfigSuffix = "-kubeconfig"
+
+	// capiKubeconfigDataKey is the key, in Cluster API kubeconfig Secrets, containing the kubeconfig
+	capiKubeconfigDataKey = "value"
+)
+
+// secretClassifier recognizes the Secrets following a convention (for instance the labels Claudie
+// sets) and extracts from them what is needed to create a SveltosCluster.
+type secretClassifier interface {
+	// matches returns true if secret follows the convention
+	matches(secret *corev1.Secret) bool
+
+	// getClusterName returns the name of the cluster secret contains the kubeconfig of.
+	// It is used, once sanitized, as SveltosCluster name.
+	getClusterName(secret *corev1.Secret) string
+
+	// getClusterNamespace returns the namespace of the cluster secret contains the kubeconfig of.
+	// It is the default namespace of the SveltosCluster.
+	getClusterNamespace(secret *corev1.Secret) string
+
+	// getKubeconfigKey returns the key, in secret, containing the kubeconfig.
+	// Returns an empty string if the key cannot be determined.
+	getKubeconfigKey(secret *corev1.Secret) string
+
+	// getRequiredLabels returns the label keys all Secrets following the convention carry.
+	// It is used to list such Secrets.
+	getRequiredLabels() []string
+}
+
+// claudieClassifier recognizes the Secrets produced by Claudie, which carry the Claudie labels.
+// Cluster name is the value of the cluster label.
+type claudieClassifier struct {
+	claudieLabel      string
+	kubeconfigLabel   string
+	clusterLabel      string
+	kubeconfigDataKey string
+}
+
+func (c *claudieClassifier) matches(secret *corev1.Secret) bool {
+	for _, label := range c.getRequiredLabels() {
+		if _, ok := secret.Labels[label]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c *claudieClassifier) getClusterName(secret *corev1.Secret) string {
+	return secret.Labels[c.clusterLabel]
+}
+
+func (c *claudieClassifier) getClusterNamespace(secret *corev1.Secret) string {
+	return secret.Namespace
+}
+
+// getKubeconfigKey returns, in order, the configured data key and the value of the Claudie output
+// label when present. Otherwise, if the Secret contains a single key, that is considered the
+// (renamed) kubeconfig key.
+func (c *claudieClassifier) getKubeconfigKey(secret *corev1.Secret) string {
+	if _, ok := secret.Data[c.kubeconfigDataKey]; ok {
+		return c.kubeconfigDataKey
+	}
+
+	if output := secret.Labels[c.kubeconfigLabel]; output != "" {
+		if _, ok := secret.Data[output]; ok {
+			return output
+		}
+	}
+
+	if len(secret.Data) == 1 {
+		for k := range secret.Data {
+			return k
+		}
+	}
+
+	return ""
+}
+
+func (c *claudieClassifier) getRequiredLabels() []string {
+	return []string{c.claudieLabel, c.kubeconfigLabel, c.clusterLabel}
+}
+
+// capiClassifier recognizes the kubeconfig Secrets generated by Cluster API, named
+// {cluster}-kubeconfig and carrying the cluster name label
+type capiClassifier struct{}
+
+func (c *capiClassifier) matches(secret *corev1.Secret) bool {
+	if secret.Type != capiSecretType {
+		return false
+	}
+
+	clusterName, ok := secret.Labels[capiClusterNameLabel]
+	if !ok {
+		return false
+	}
+
+	// Cluster API generates other Secrets (CA, etcd, service account keys) for a cluster
+	return secret.Name == clusterName+capiKubeconfigSuffix
+}
+
+func (c *capiClassifier) getClusterName(secret *corev1.Secret) string {
+	if clusterName := secret.Labels[capiClusterNameLabel]; clusterName != "" {
+		return clusterName
+	}
+	return strings.TrimSuffix(secret.Name, capiKubeconfigSuffix)
+}
+
+func (c *capiClassifier) getClusterNamespace(secret *corev1.Secret) string {
+	// Cluster API kubeconfig Secrets are in the namespace of the Cluster
+	return secret.Namespace
+}
+
+func (c *capiClassifier) getKubeconfigKey(secret *corev1.Secret) string {
+	if _, ok := secret.Data[capiKubeconfigDataKey]; ok {
+		return capiKubeconfigDataKey
+	}
+	return ""
+}
+
+func (c *capiClassifier) getRequiredLabels() []string {
+	return []string{capiClusterNameLabel}
+}
+
+// getSecretClassifiers returns the classifiers of the Secret conventions this controller manages.
+// Claudie one is always first.
+func (r *SecretReconciler) getSecretClassifiers() []secretClassifier {
+	key := r.KubeconfigDataKey
+	if key == "" {
+		key = kubeconfigDataKey
+	}
+
+	classifiers := []secretClassifier{
+		&claudieClassifier{
+			claudieLabel:      r.getClaudieLabel(),
+			kubeconfigLabel:   r.getClaudieKubeconfigLabel(),
+			clusterLabel:      r.getClaudieClusterLabel(),
+			kubeconfigDataKey: key,
+		},
+	}
+
+	if r.ClusterAPISecrets {
+		classifiers = append(classifiers, &capiClassifier{})
+	}
+
+	return classifiers
+}
+
+// getSecretClassifier returns the first classifier matching secret. When none matches, Claudie
+// one (the default) is returned, so callers must use matches to know whether secret is managed.
+func (r *SecretReconciler) getSecretClassifier(secret *corev1.Secret) secretClassifier {
+	classifiers := r.getSecretClassifiers()
+	for i := range classifiers {
+		if classifiers[i].matches(secret) {
+			return classifiers[i]
+		}
+	}
+
+	return classifiers[0]
+}
+
+// getClusterName returns the name of the cluster secret contains the kubeconfig of
+func (r *SecretReconciler) getClusterName(secret *corev1.Secret) string {
+	return r.getSecretClassifier(secret).getClusterName(secret)
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"gianlucam76/claudie-sveltos-integration/internal/controller"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+)
+
+var _ = Describe("Secret classifiers", func() {
+	It("Cluster API Secrets are managed only when ClusterAPISecrets is set", func() {
+		secret := getCAPISecret("cluster-a")
+
+		reconciler := getSecretReconciler(fake.NewClientBuilder().WithScheme(scheme).Build())
+		Expect(controller.ShouldReconcileSecret(reconciler, secret)).To(BeFalse())
+
+		reconciler.ClusterAPISecrets = true
+		Expect(controller.ShouldReconcileSecret(reconciler, secret)).To(BeTrue())
+		Expect(controller.GetSveltosClusterName(reconciler, secret)).To(Equal("cluster-a"))
+		Expect(controller.GetSveltosClusterNamespace(reconciler, secret)).To(Equal(secret.Namespace))
+		Expect(controller.GetKubeconfigKey(reconciler, secret)).To(Equal(controller.CAPIKubeconfigDataKey))
+
+		// Claudie Secrets are still managed
+		Expect(controller.ShouldReconcileSecret(reconciler,
+			getClaudieSecretWithKubeconfig("cluster-b", "cluster-b"))).To(BeTrue())
+	})
+
+	DescribeTable("Cluster API Secrets not containing a kubeconfig are ignored",
+		func(mutate func(secret *corev1.Secret)) {
+			secret := getCAPISecret("cluster-a")
+			mutate(secret)
+
+			reconciler := getSecretReconciler(fake.NewClientBuilder().WithScheme(scheme).Build())
+			reconciler.ClusterAPISecrets = true
+			Expect(controller.ShouldReconcileSecret(reconciler, secret)).To(BeFalse())
+		},
+		Entry("different type", func(secret *corev1.Secret) { secret.Type = corev1.SecretTypeOpaque }),
+		Entry("no cluster name label", func(secret *corev1.Secret) {
+			delete(secret.Labels, controller.CAPIClusterNameLabel)
+		}),
+		Entry("other cluster Secret", func(secret *corev1.Secret) { secret.Name = "cluster-a-ca" }),
+	)
+
+	It("Reconcile creates a SveltosCluster for a Cluster API Secret", func() {
+		secret := getCAPISecret("cluster-a")
+		Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+		reconciler := getSecretReconciler(c)
+		reconciler.ClusterAPISecrets = true
+
+		_, err := reconciler.Reconcile(context.TODO(), reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name},
+		})
+		Expect(err).To(BeNil())
+
+		sveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+		Expect(c.Get(context.TODO(), types.NamespacedName{Namespace: secret.Namespace, Name: "cluster-a"},
+			sveltosCluster)).To(Succeed())
+		Expect(sveltosCluster.Spec.KubeconfigName).To(Equal(secret.Name))
+		claudieSecret := controller.GetClaudieSecret(sveltosCluster)
+		Expect(claudieSecret).ToNot(BeNil())
+		Expect(*claudieSecret).To(Equal(client.ObjectKeyFromObject(secret)))
+	})
+})
+
+// getCAPISecret returns a Cluster API kubeconfig Secret for cluster clusterName
+func getCAPISecret(clusterName string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: randomString(),
+			Name:      clusterName + "-kubeconfig",
+			Labels: map[string]string{
+				controller.CAPIClusterNameLabel: clusterName,
+			},
+		},
+		Type: controller.CAPISecretType,
+		Data: map[string][]byte{
+			controller.CAPIKubeconfigDataKey: getKubeconfig(clusterName, clusterName),
+		},
+	}
+}
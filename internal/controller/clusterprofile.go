@@ -0,0 +1,157 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+)
+
+const (
+	// clusterProfileStubPrefix is prepended to SveltosCluster namespace and name to get the name
+	// of the ClusterProfile stub created for it
+	clusterProfileStubPrefix = "claudie"
+)
+
+// LoadClusterProfileStub reads from the YAML file at path the template used to create a ClusterProfile
+// (or any other Sveltos profile kind) stub for each SveltosCluster created for a Claudie Secret.
+// Template must define apiVersion and kind. Name, namespace and spec.clusterRefs are set by the controller.
+func LoadClusterProfileStub(path string) (*unstructured.Unstructured, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read ClusterProfile stub from %s", path)
+	}
+
+	stub := &unstructured.Unstructured{}
+	if err := yaml.Unmarshal(data, &stub.Object); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse ClusterProfile stub from %s", path)
+	}
+
+	if stub.GetAPIVersion() == "" || stub.GetKind() == "" {
+		return nil, fmt.Errorf("ClusterProfile stub in %s must define apiVersion and kind", path)
+	}
+
+	return stub, nil
+}
+
+// getClusterProfileStubName returns the name of the ClusterProfile stub for the SveltosCluster.
+// ClusterProfiles are cluster wide, so SveltosCluster namespace is part of the name.
+func getClusterProfileStubName(sveltosCluster *libsveltosv1alpha1.SveltosCluster) string {
+	return fmt.Sprintf("%s-%s-%s", clusterProfileStubPrefix, sveltosCluster.Namespace, sveltosCluster.Name)
+}
+
+// getClusterProfileStub returns, if ClusterProfileStub is set, the stub for sveltosCluster.
+// Stub only targets sveltosCluster and is owned by it.
+func (r *SecretReconciler) getClusterProfileStub(sveltosCluster *libsveltosv1alpha1.SveltosCluster,
+) (*unstructured.Unstructured, error) {
+
+	stub := r.ClusterProfileStub.DeepCopy()
+	stub.SetName(getClusterProfileStubName(sveltosCluster))
+
+	namespaced, err := r.IsObjectNamespaced(stub)
+	if err != nil {
+		return nil, err
+	}
+	if namespaced {
+		stub.SetNamespace(sveltosCluster.Namespace)
+	} else {
+		stub.SetNamespace("")
+	}
+
+	clusterRefs := []interface{}{
+		map[string]interface{}{
+			"apiVersion": r.getSveltosClusterAPIVersion(),
+			"kind":       libsveltosv1alpha1.SveltosClusterKind,
+			"namespace":  sveltosCluster.Namespace,
+			"name":       sveltosCluster.Name,
+		},
+	}
+	if err := unstructured.SetNestedSlice(stub.Object, clusterRefs, "spec", "clusterRefs"); err != nil {
+		return nil, err
+	}
+
+	// Garbage collection only removes namespaced stubs. Cluster wide ones are removed
+	// explicitly when SveltosCluster is.
+	stub.SetOwnerReferences([]metav1.OwnerReference{r.getSveltosClusterOwnerReference(sveltosCluster)})
+
+	return stub, nil
+}
+
+// reconcileClusterProfileStub creates, if ClusterProfileStub is set and it does not exist yet,
+// the stub for sveltosCluster. Existing stubs are never updated so users can customize them.
+func (r *SecretReconciler) reconcileClusterProfileStub(ctx context.Context,
+	sveltosCluster *libsveltosv1alpha1.SveltosCluster) error {
+
+	if r.ClusterProfileStub == nil {
+		return nil
+	}
+
+	stub, err := r.getClusterProfileStub(sveltosCluster)
+	if err != nil {
+		return err
+	}
+
+	current := &unstructured.Unstructured{}
+	current.SetGroupVersionKind(stub.GroupVersionKind())
+	err = r.Get(ctx, client.ObjectKeyFromObject(stub), current)
+	if err == nil {
+		return nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return err
+	}
+
+	return client.IgnoreAlreadyExists(r.Create(ctx, stub))
+}
+
+// removeClusterProfileStub deletes, if ClusterProfileStub is set, the stub for sveltosCluster.
+// Stubs not owned by sveltosCluster are never removed.
+func (r *SecretReconciler) removeClusterProfileStub(ctx context.Context,
+	sveltosCluster *libsveltosv1alpha1.SveltosCluster) error {
+
+	if r.ClusterProfileStub == nil {
+		return nil
+	}
+
+	stub, err := r.getClusterProfileStub(sveltosCluster)
+	if err != nil {
+		return err
+	}
+
+	current := &unstructured.Unstructured{}
+	current.SetGroupVersionKind(stub.GroupVersionKind())
+	err = r.Get(ctx, client.ObjectKeyFromObject(stub), current)
+	if err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	if !isOwnedBySveltosCluster(current, sveltosCluster.Name) {
+		return nil
+	}
+
+	return client.IgnoreNotFound(r.Delete(ctx, current))
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"gianlucam76/claudie-sveltos-integration/internal/controller"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+)
+
+const clusterProfileStub = `apiVersion: config.projectsveltos.io/v1beta1
+kind: ClusterProfile
+spec:
+  syncMode: Continuous
+  clusterRefs:
+  - apiVersion: lib.projectsveltos.io/v1alpha1
+    kind: SveltosCluster
+    namespace: default
+    name: any
+`
+
+var clusterProfileGVK = schema.GroupVersionKind{
+	Group:   "config.projectsveltos.io",
+	Version: "v1beta1",
+	Kind:    "ClusterProfile",
+}
+
+var _ = Describe("ClusterProfile stub", func() {
+	var stubPath string
+
+	BeforeEach(func() {
+		stubPath = filepath.Join(GinkgoT().TempDir(), "stub.yaml")
+		Expect(os.WriteFile(stubPath, []byte(clusterProfileStub), 0600)).To(Succeed())
+	})
+
+	It("LoadClusterProfileStub requires apiVersion and kind", func() {
+		stub, err := controller.LoadClusterProfileStub(stubPath)
+		Expect(err).To(BeNil())
+		Expect(stub.GroupVersionKind()).To(Equal(clusterProfileGVK))
+
+		Expect(os.WriteFile(stubPath, []byte("spec:\n  syncMode: Continuous\n"), 0600)).To(Succeed())
+		_, err = controller.LoadClusterProfileStub(stubPath)
+		Expect(err).ToNot(BeNil())
+	})
+
+	It("creates a stub targeting the SveltosCluster and removes it with the SveltosCluster", func() {
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+
+		c := getClientWithClusterProfileMapping(secret)
+		reconciler := getSecretReconciler(c)
+		stub, err := controller.LoadClusterProfileStub(stubPath)
+		Expect(err).To(BeNil())
+		reconciler.ClusterProfileStub = stub
+
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+
+		sveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+		Expect(c.Get(context.TODO(),
+			types.NamespacedName{Namespace: secret.Namespace, Name: secret.Labels[controller.ClaudieCluster]},
+			sveltosCluster)).To(Succeed())
+
+		currentStub := &unstructured.Unstructured{}
+		currentStub.SetGroupVersionKind(clusterProfileGVK)
+		stubKey := types.NamespacedName{Name: controller.GetClusterProfileStubName(sveltosCluster)}
+		Expect(c.Get(context.TODO(), stubKey, currentStub)).To(Succeed())
+		Expect(currentStub.GetOwnerReferences()).To(HaveLen(1))
+		Expect(currentStub.GetOwnerReferences()[0].Kind).To(Equal(libsveltosv1alpha1.SveltosClusterKind))
+		Expect(currentStub.GetOwnerReferences()[0].Name).To(Equal(sveltosCluster.Name))
+
+		syncMode, _, _ := unstructured.NestedString(currentStub.Object, "spec", "syncMode")
+		Expect(syncMode).To(Equal("Continuous"))
+		clusterRefs, _, _ := unstructured.NestedSlice(currentStub.Object, "spec", "clusterRefs")
+		Expect(clusterRefs).To(HaveLen(1))
+		clusterRef := clusterRefs[0].(map[string]interface{})
+		Expect(clusterRef["namespace"]).To(Equal(sveltosCluster.Namespace))
+		Expect(clusterRef["name"]).To(Equal(sveltosCluster.Name))
+
+		// User customizations are preserved
+		Expect(unstructured.SetNestedField(currentStub.Object, "OneTime", "spec", "syncMode")).To(Succeed())
+		Expect(c.Update(context.TODO(), currentStub)).To(Succeed())
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+		Expect(c.Get(context.TODO(), stubKey, currentStub)).To(Succeed())
+		syncMode, _, _ = unstructured.NestedString(currentStub.Object, "spec", "syncMode")
+		Expect(syncMode).To(Equal("OneTime"))
+
+		Expect(c.Delete(context.TODO(), secret)).To(Succeed())
+		Expect(controller.CleanSveltosCluster(reconciler, context.TODO(),
+			ctrl.Request{NamespacedName: types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name}},
+			logr.Logger{})).To(Succeed())
+
+		err = c.Get(context.TODO(), stubKey, currentStub)
+		Expect(err).ToNot(BeNil())
+		Expect(client.IgnoreNotFound(err)).To(BeNil())
+	})
+
+	It("never removes a stub not owned by the SveltosCluster", func() {
+		sveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+		sveltosCluster.Namespace = randomString()
+		sveltosCluster.Name = randomString()
+
+		userProfile := &unstructured.Unstructured{}
+		userProfile.SetGroupVersionKind(clusterProfileGVK)
+		userProfile.SetName(controller.GetClusterProfileStubName(sveltosCluster))
+
+		c := getClientWithClusterProfileMapping(userProfile)
+		reconciler := getSecretReconciler(c)
+		stub, err := controller.LoadClusterProfileStub(stubPath)
+		Expect(err).To(BeNil())
+		reconciler.ClusterProfileStub = stub
+
+		Expect(controller.RemoveClusterProfileStub(reconciler, context.TODO(), sveltosCluster)).To(Succeed())
+		Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(userProfile), userProfile)).To(Succeed())
+	})
+})
+
+// getClientWithClusterProfileMapping returns a fake client aware of the cluster wide ClusterProfile kind
+func getClientWithClusterProfileMapping(objects ...client.Object) client.Client {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(clusterProfileGVK, meta.RESTScopeRoot)
+	mapper.Add(libsveltosv1alpha1.GroupVersion.WithKind(libsveltosv1alpha1.SveltosClusterKind), meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Secret"}, meta.RESTScopeNamespace)
+
+	return fake.NewClientBuilder().WithScheme(scheme).WithRESTMapper(mapper).WithObjects(objects...).Build()
+}
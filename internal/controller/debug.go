@@ -0,0 +1,66 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+)
+
+const (
+	// DebugMappingsPath is the path the Secret to SveltosCluster mappings are served at
+	DebugMappingsPath = "/debug/claudie/mappings"
+)
+
+// Mapping is a Claudie Secret to SveltosCluster association, as served by MappingsHandler
+type Mapping struct {
+	// Secret is the Claudie Secret, in the namespace/name form
+	Secret string `json:"secret"`
+
+	// SveltosCluster is the SveltosCluster tracked for Secret, in the namespace/name form
+	SveltosCluster string `json:"sveltosCluster"`
+}
+
+// MappingsHandler returns an http.Handler serving, as a JSON list sorted by Secret, the Claudie
+// Secret to SveltosCluster associations this controller currently tracks
+func (r *SecretReconciler) MappingsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		mappings := make([]Mapping, 0)
+		for _, secretKey := range r.secretToCluster.Secrets() {
+			sveltosClusterKey, ok := r.secretToCluster.Get(secretKey)
+			if !ok {
+				// Forgotten in the meantime
+				continue
+			}
+			mappings = append(mappings, Mapping{Secret: secretKey.String(), SveltosCluster: sveltosClusterKey.String()})
+		}
+		sort.Slice(mappings, func(i, j int) bool {
+			return mappings[i].Secret < mappings[j].Secret
+		})
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(mappings); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
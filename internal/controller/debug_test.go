@@ -0,0 +1,67 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/types"
+
+	"gianlucam76/claudie-sveltos-integration/internal/controller"
+)
+
+var _ = Describe("Debug endpoints", func() {
+	It("MappingsHandler serves the tracked Secret to SveltosCluster mappings sorted by Secret", func() {
+		reconciler := getSecretReconciler(nil)
+		reconciler.TrackSveltosCluster(types.NamespacedName{Namespace: "b", Name: "secret"},
+			types.NamespacedName{Namespace: "b", Name: "cluster"})
+		reconciler.TrackSveltosCluster(types.NamespacedName{Namespace: "a", Name: "secret"},
+			types.NamespacedName{Namespace: "projectsveltos", Name: "cluster"})
+
+		recorder := httptest.NewRecorder()
+		reconciler.MappingsHandler().ServeHTTP(recorder,
+			httptest.NewRequest(http.MethodGet, controller.DebugMappingsPath, http.NoBody))
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.Header().Get("Content-Type")).To(Equal("application/json"))
+
+		var mappings []controller.Mapping
+		Expect(json.Unmarshal(recorder.Body.Bytes(), &mappings)).To(Succeed())
+		Expect(mappings).To(Equal([]controller.Mapping{
+			{Secret: "a/secret", SveltosCluster: "projectsveltos/cluster"},
+			{Secret: "b/secret", SveltosCluster: "b/cluster"},
+		}))
+	})
+
+	It("MappingsHandler serves an empty list when nothing is tracked and only allows GET", func() {
+		reconciler := getSecretReconciler(nil)
+
+		recorder := httptest.NewRecorder()
+		reconciler.MappingsHandler().ServeHTTP(recorder,
+			httptest.NewRequest(http.MethodGet, controller.DebugMappingsPath, http.NoBody))
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.Body.String()).To(Equal("[]\n"))
+
+		recorder = httptest.NewRecorder()
+		reconciler.MappingsHandler().ServeHTTP(recorder,
+			httptest.NewRequest(http.MethodPost, controller.DebugMappingsPath, http.NoBody))
+		Expect(recorder.Code).To(Equal(http.StatusMethodNotAllowed))
+	})
+})
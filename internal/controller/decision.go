@@ -0,0 +1,88 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"reflect"
+	"strings"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+)
+
+const (
+	// sveltosClusterDecisionAnnotation summarizes the last decision this controller took
+	// reconciling the SveltosCluster (e.g. "created", "updated:spec,annotations"). SveltosClusters
+	// already in the desired state are not written, so it reports the last decision which changed it.
+	sveltosClusterDecisionAnnotation = "projectsveltos.io/claudie-decision"
+
+	// maxDecisionLength bounds the decision annotation value
+	maxDecisionLength = 128
+
+	decisionCreated   = "created"
+	decisionUpdated   = "updated"
+	decisionUnchanged = "unchanged"
+	decisionReleased  = "released"
+)
+
+// getUpdateDecision returns the decision summarizing what changed from original to current SveltosCluster
+func getUpdateDecision(original, current *libsveltosv1alpha1.SveltosCluster) string {
+	changes := make([]string, 0)
+
+	if !reflect.DeepEqual(original.Spec, current.Spec) {
+		changes = append(changes, "spec")
+	}
+	if !reflect.DeepEqual(original.Labels, current.Labels) {
+		changes = append(changes, "labels")
+	}
+	if !reflect.DeepEqual(withoutDecision(original.Annotations), withoutDecision(current.Annotations)) {
+		changes = append(changes, "annotations")
+	}
+	if !reflect.DeepEqual(original.OwnerReferences, current.OwnerReferences) {
+		changes = append(changes, "ownerReferences")
+	}
+
+	if len(changes) == 0 {
+		return decisionUnchanged
+	}
+
+	return decisionUpdated + ":" + strings.Join(changes, ",")
+}
+
+// setDecision records decision on the SveltosCluster. Value is truncated to maxDecisionLength.
+func setDecision(sveltosCluster *libsveltosv1alpha1.SveltosCluster, decision string) {
+	if len(decision) > maxDecisionLength {
+		decision = decision[:maxDecisionLength]
+	}
+
+	if sveltosCluster.Annotations == nil {
+		sveltosCluster.Annotations = make(map[string]string)
+	}
+	sveltosCluster.Annotations[sveltosClusterDecisionAnnotation] = decision
+}
+
+// withoutDecision returns annotations without the decision one
+func withoutDecision(annotations map[string]string) map[string]string {
+	result := make(map[string]string, len(annotations))
+	for k, v := range annotations {
+		if k == sveltosClusterDecisionAnnotation {
+			continue
+		}
+		result[k] = v
+	}
+
+	return result
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"gianlucam76/claudie-sveltos-integration/internal/controller"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+)
+
+var _ = Describe("Decision", func() {
+	It("createSveltosCluster records the last decision on SveltosCluster", func() {
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+		reconciler := getSecretReconciler(c)
+
+		sveltosClusterKey := types.NamespacedName{Namespace: secret.Namespace, Name: secret.Labels[controller.ClaudieCluster]}
+		getDecision := func() string {
+			currentSveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+			Expect(c.Get(context.TODO(), sveltosClusterKey, currentSveltosCluster)).To(Succeed())
+			return currentSveltosCluster.Annotations[controller.SveltosClusterDecisionAnnotation]
+		}
+
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+		Expect(getDecision()).To(Equal("created"))
+
+		// SveltosCluster already in the desired state is not written
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+		Expect(getDecision()).To(Equal("created"))
+
+		// Annotation drift
+		currentSveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+		Expect(c.Get(context.TODO(), sveltosClusterKey, currentSveltosCluster)).To(Succeed())
+		delete(currentSveltosCluster.Annotations, controller.SveltosClusterClaudieAnnotation)
+		Expect(c.Update(context.TODO(), currentSveltosCluster)).To(Succeed())
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+		Expect(getDecision()).To(Equal("updated:annotations"))
+
+		// Spec and annotation drift
+		Expect(c.Get(context.TODO(), sveltosClusterKey, currentSveltosCluster)).To(Succeed())
+		currentSveltosCluster.Spec.KubeconfigName = randomString()
+		delete(currentSveltosCluster.Annotations, controller.SveltosClusterClaudieAnnotation)
+		Expect(c.Update(context.TODO(), currentSveltosCluster)).To(Succeed())
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+		Expect(getDecision()).To(Equal("updated:spec,annotations"))
+
+		// Released
+		Expect(c.Get(context.TODO(), sveltosClusterKey, currentSveltosCluster)).To(Succeed())
+		currentSveltosCluster.Annotations[controller.UnmanageAnnotation] = "true"
+		Expect(c.Update(context.TODO(), currentSveltosCluster)).To(Succeed())
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+		Expect(getDecision()).To(Equal("released"))
+	})
+})
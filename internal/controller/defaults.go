@@ -0,0 +1,124 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+)
+
+const (
+	// claudieProviderLabel, if present on a Claudie Secret, contains the cloud provider
+	// the cluster was provisioned on
+	claudieProviderLabel = "claudie.io/provider"
+
+	// specDefaultsConfigMapKey is the key, in the ConfigMap referenced by --sveltoscluster-defaults-configmap,
+	// containing SveltosCluster Spec defaults
+	specDefaultsConfigMapKey = "defaults.yaml"
+)
+
+// SpecDefaults contains the SveltosCluster Spec used when creating a SveltosCluster
+// for a Claudie Secret.
+// Fields managed by this controller (KubeconfigName) are always overridden.
+type SpecDefaults struct {
+	// Default is used when no provider specific Spec is defined
+	// +optional
+	Default *libsveltosv1alpha1.SveltosClusterSpec `json:"default,omitempty"`
+
+	// Providers contains Spec defaults keyed by Claudie provider
+	// +optional
+	Providers map[string]libsveltosv1alpha1.SveltosClusterSpec `json:"providers,omitempty"`
+}
+
+// LoadSpecDefaults reads SveltosCluster Spec defaults from the YAML file at path
+func LoadSpecDefaults(path string) (*SpecDefaults, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read SveltosCluster spec defaults from %s", path)
+	}
+
+	return parseSpecDefaults(data, path)
+}
+
+// ParseSpecDefaultsConfigMap parses a ConfigMap reference in the form namespace/name
+func ParseSpecDefaultsConfigMap(ref string) (types.NamespacedName, error) {
+	namespace, name, found := strings.Cut(ref, "/")
+	if !found || namespace == "" || name == "" || strings.Contains(name, "/") {
+		return types.NamespacedName{}, fmt.Errorf("ConfigMap reference %q must be in the form namespace/name", ref)
+	}
+
+	return types.NamespacedName{Namespace: namespace, Name: name}, nil
+}
+
+// LoadSpecDefaultsFromConfigMap reads SveltosCluster Spec defaults from the defaults.yaml key
+// of the ConfigMap identified by key
+func LoadSpecDefaultsFromConfigMap(ctx context.Context, c client.Reader, key types.NamespacedName,
+) (*SpecDefaults, error) {
+
+	configMap := &corev1.ConfigMap{}
+	if err := c.Get(ctx, key, configMap); err != nil {
+		return nil, errors.Wrapf(err, "failed to get SveltosCluster spec defaults ConfigMap %s", key)
+	}
+
+	data, ok := configMap.Data[specDefaultsConfigMapKey]
+	if !ok {
+		return nil, fmt.Errorf("ConfigMap %s has no %s key", key, specDefaultsConfigMapKey)
+	}
+
+	return parseSpecDefaults([]byte(data), fmt.Sprintf("ConfigMap %s", key))
+}
+
+// parseSpecDefaults parses SveltosCluster Spec defaults read from source
+func parseSpecDefaults(data []byte, source string) (*SpecDefaults, error) {
+	defaults := &SpecDefaults{}
+	if err := yaml.UnmarshalStrict(data, defaults); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse SveltosCluster spec defaults from %s", source)
+	}
+
+	return defaults, nil
+}
+
+// getSpecDefaults returns the SveltosCluster Spec defaults for the Claudie Secret.
+// Provider specific defaults take precedence over the global default.
+// Returns nil if no default is defined.
+func (r *SecretReconciler) getSpecDefaults(secret *corev1.Secret) *libsveltosv1alpha1.SveltosClusterSpec {
+	if r.SpecDefaults == nil {
+		return nil
+	}
+
+	if provider, ok := secret.Labels[claudieProviderLabel]; ok {
+		if spec, ok := r.SpecDefaults.Providers[provider]; ok {
+			return spec.DeepCopy()
+		}
+	}
+
+	if r.SpecDefaults.Default != nil {
+		return r.SpecDefaults.Default.DeepCopy()
+	}
+
+	return nil
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"gianlucam76/claudie-sveltos-integration/internal/controller"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+)
+
+var _ = Describe("SveltosCluster Spec defaults", func() {
+	It("LoadSpecDefaults parses provider specific and global defaults", func() {
+		defaultsFile := filepath.Join(GinkgoT().TempDir(), "defaults.yaml")
+		Expect(os.WriteFile(defaultsFile, []byte(`default:
+  paused: false
+providers:
+  hetzner:
+    paused: true
+`), 0o600)).To(Succeed())
+
+		defaults, err := controller.LoadSpecDefaults(defaultsFile)
+		Expect(err).To(BeNil())
+		Expect(defaults.Default).ToNot(BeNil())
+		Expect(defaults.Default.Paused).To(BeFalse())
+		Expect(defaults.Providers).To(HaveKey("hetzner"))
+		Expect(defaults.Providers["hetzner"].Paused).To(BeTrue())
+	})
+
+	It("LoadSpecDefaults fails on unknown fields", func() {
+		defaultsFile := filepath.Join(GinkgoT().TempDir(), "defaults.yaml")
+		Expect(os.WriteFile(defaultsFile, []byte(`default:
+  notAField: true
+`), 0o600)).To(Succeed())
+
+		_, err := controller.LoadSpecDefaults(defaultsFile)
+		Expect(err).ToNot(BeNil())
+	})
+
+	It("LoadSpecDefaultsFromConfigMap parses defaults from the ConfigMap defaults.yaml key", func() {
+		configMap := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: randomString(),
+				Name:      randomString(),
+			},
+			Data: map[string]string{
+				"defaults.yaml": `default:
+  consecutiveFailureThreshold: 5
+  activeWindow:
+    from: "0 20 * * 5"
+    to: "0 7 * * 1"
+`,
+			},
+		}
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(configMap).Build()
+
+		configMapKey, err := controller.ParseSpecDefaultsConfigMap(configMap.Namespace + "/" + configMap.Name)
+		Expect(err).To(BeNil())
+
+		defaults, err := controller.LoadSpecDefaultsFromConfigMap(context.TODO(), c, configMapKey)
+		Expect(err).To(BeNil())
+		Expect(defaults.Default).ToNot(BeNil())
+		Expect(defaults.Default.ConsecutiveFailureThreshold).To(Equal(5))
+		Expect(defaults.Default.ActiveWindow).ToNot(BeNil())
+		Expect(defaults.Default.ActiveWindow.From).To(Equal("0 20 * * 5"))
+
+		// Missing key
+		configMap.Data = map[string]string{randomString(): "default: {}"}
+		Expect(c.Update(context.TODO(), configMap)).To(Succeed())
+		_, err = controller.LoadSpecDefaultsFromConfigMap(context.TODO(), c, configMapKey)
+		Expect(err).ToNot(BeNil())
+
+		// Missing ConfigMap
+		_, err = controller.LoadSpecDefaultsFromConfigMap(context.TODO(), c,
+			types.NamespacedName{Namespace: configMap.Namespace, Name: randomString()})
+		Expect(err).ToNot(BeNil())
+	})
+
+	It("ParseSpecDefaultsConfigMap requires namespace/name", func() {
+		for _, ref := range []string{"", "name", "/name", "namespace/", "a/b/c"} {
+			_, err := controller.ParseSpecDefaultsConfigMap(ref)
+			Expect(err).ToNot(BeNil(), ref)
+		}
+	})
+
+	It("getSpecDefaults returns provider specific defaults and falls back to global default", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := getSecretReconciler(c)
+
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: randomString(),
+				Name:      randomString(),
+				Labels: map[string]string{
+					controller.ClaudieProviderLabel: "hetzner",
+				},
+			},
+		}
+
+		// No defaults configured
+		Expect(controller.GetSpecDefaults(reconciler, secret)).To(BeNil())
+
+		reconciler.SpecDefaults = &controller.SpecDefaults{
+			Providers: map[string]libsveltosv1alpha1.SveltosClusterSpec{
+				"hetzner": {Paused: true},
+			},
+		}
+
+		spec := controller.GetSpecDefaults(reconciler, secret)
+		Expect(spec).ToNot(BeNil())
+		Expect(spec.Paused).To(BeTrue())
+
+		// Provider with no specific defaults and no global default
+		secret.Labels[controller.ClaudieProviderLabel] = "aws"
+		Expect(controller.GetSpecDefaults(reconciler, secret)).To(BeNil())
+
+		reconciler.SpecDefaults.Default = &libsveltosv1alpha1.SveltosClusterSpec{Paused: false}
+		spec = controller.GetSpecDefaults(reconciler, secret)
+		Expect(spec).ToNot(BeNil())
+		Expect(spec.Paused).To(BeFalse())
+	})
+
+	It("createSveltosCluster applies provider defaults and always sets controller managed fields", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := getSecretReconciler(c)
+		reconciler.SpecDefaults = &controller.SpecDefaults{
+			Providers: map[string]libsveltosv1alpha1.SveltosClusterSpec{
+				"hetzner": {Paused: true, KubeconfigName: randomString()},
+			},
+		}
+
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: randomString(),
+				Name:      randomString(),
+				Labels: map[string]string{
+					controller.ClaudieLabel:         "claudie",
+					controller.ClaudieKubeconfig:    "kubeconfig",
+					controller.ClaudieCluster:       randomString(),
+					controller.ClaudieProviderLabel: "hetzner",
+				},
+			},
+		}
+
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+
+		currentSveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+		Expect(c.Get(context.TODO(),
+			types.NamespacedName{Namespace: secret.Namespace, Name: secret.Labels[controller.ClaudieCluster]},
+			currentSveltosCluster)).To(Succeed())
+		Expect(currentSveltosCluster.Spec.Paused).To(BeTrue())
+		Expect(currentSveltosCluster.Spec.KubeconfigName).To(Equal(secret.Name))
+	})
+})
@@ -0,0 +1,74 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// deleteBackoff is used to retry SveltosCluster deletions failing with transient errors.
+// It is kept short since retries delay the reconciliation of the Secret.
+var deleteBackoff = wait.Backoff{
+	Duration: 50 * time.Millisecond,
+	Factor:   2,
+	Jitter:   0.1,
+	Steps:    4,
+}
+
+// deleteWithRetry deletes object retrying, with exponential backoff, on transient errors.
+// An object already gone is not an error. Once retries are exhausted the last error is returned
+// and the failure is counted in claudie_sveltoscluster_delete_failures_total.
+func deleteWithRetry(ctx context.Context, c client.Client, object client.Object) error {
+	var lastErr error
+	err := wait.ExponentialBackoffWithContext(ctx, deleteBackoff, func(ctx context.Context) (bool, error) {
+		lastErr = client.IgnoreNotFound(c.Delete(ctx, object))
+		if lastErr == nil {
+			return true, nil
+		}
+		if !isTransientError(lastErr) {
+			return false, lastErr
+		}
+		return false, nil
+	})
+	if err == nil {
+		return nil
+	}
+
+	if wait.Interrupted(err) && lastErr != nil {
+		err = lastErr
+	}
+	sveltosClusterDeleteFailures.Inc()
+	return err
+}
+
+// isTransientError returns true if err might go away by simply retrying
+func isTransientError(err error) bool {
+	var status apierrors.APIStatus
+	if !errors.As(err, &status) {
+		// Not reported by the API server (e.g. connection reset)
+		return true
+	}
+
+	return apierrors.IsServerTimeout(err) || apierrors.IsTooManyRequests(err) ||
+		apierrors.IsTimeout(err) || apierrors.IsServiceUnavailable(err)
+}
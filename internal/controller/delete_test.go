@@ -0,0 +1,55 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+
+	"gianlucam76/claudie-sveltos-integration/internal/controller"
+)
+
+var _ = Describe("Delete", func() {
+	resource := schema.GroupResource{Group: libsveltosv1alpha1.GroupVersion.Group, Resource: "sveltosclusters"}
+
+	DescribeTable("isTransientError",
+		func(err error, transient bool) {
+			Expect(controller.IsTransientError(err)).To(Equal(transient))
+		},
+		Entry("connection error", errors.New("connection reset by peer"), true),
+		Entry("server timeout", apierrors.NewServerTimeout(resource, "delete", 1), true),
+		Entry("timeout", apierrors.NewTimeoutError("timeout", 1), true),
+		Entry("too many requests", apierrors.NewTooManyRequests("slow down", 1), true),
+		Entry("service unavailable", apierrors.NewServiceUnavailable("restarting"), true),
+		Entry("wrapped service unavailable",
+			fmt.Errorf("failed to delete: %w", apierrors.NewServiceUnavailable("restarting")), true),
+		Entry("wrapped too many requests",
+			errors.Wrap(apierrors.NewTooManyRequests("slow down", 1), "failed to delete"), true),
+		Entry("conflict", apierrors.NewConflict(resource, randomString(), errors.New("modified")), false),
+		Entry("forbidden", apierrors.NewForbidden(resource, randomString(), errors.New("denied")), false),
+		Entry("wrapped forbidden",
+			fmt.Errorf("failed to delete: %w", apierrors.NewForbidden(resource, randomString(), errors.New("denied"))), false),
+	)
+})
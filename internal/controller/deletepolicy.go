@@ -0,0 +1,139 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+	logs "github.com/projectsveltos/libsveltos/lib/logsettings"
+)
+
+const (
+	// deletePolicyAnnotation, set on a Claudie Secret, controls what happens to its SveltosCluster
+	// when the Secret is deleted. It is copied to the SveltosCluster, so it is known even once the
+	// Secret is gone.
+	deletePolicyAnnotation = "projectsveltos.io/claudie-delete-policy"
+
+	// deletePolicyDelete deletes the SveltosCluster with its Claudie Secret. This is the default.
+	deletePolicyDelete = "Delete"
+
+	// deletePolicyOrphan releases the SveltosCluster from Claudie management instead of deleting it
+	deletePolicyOrphan = "Orphan"
+)
+
+// addDeletePolicyAnnotation reports on sveltosCluster the delete policy requested on secret.
+// Only Orphan is recorded, as Delete is the default. Unknown policies are ignored.
+func addDeletePolicyAnnotation(sveltosCluster *libsveltosv1alpha1.SveltosCluster, secret *corev1.Secret,
+	logger logr.Logger) {
+
+	switch policy := secret.Annotations[deletePolicyAnnotation]; policy {
+	case deletePolicyOrphan:
+		if sveltosCluster.Annotations == nil {
+			sveltosCluster.Annotations = make(map[string]string)
+		}
+		sveltosCluster.Annotations[deletePolicyAnnotation] = deletePolicyOrphan
+		return
+	case "", deletePolicyDelete:
+	default:
+		logger.V(logs.LogInfo).Info(fmt.Sprintf("ignoring unknown delete policy %q. Using %s", policy, deletePolicyDelete))
+	}
+
+	delete(sveltosCluster.Annotations, deletePolicyAnnotation)
+}
+
+// isOrphanPolicy returns true if sveltosCluster must be released, instead of deleted, when its
+// Claudie Secret is deleted
+func isOrphanPolicy(sveltosCluster *libsveltosv1alpha1.SveltosCluster) bool {
+	return sveltosCluster.Annotations[deletePolicyAnnotation] == deletePolicyOrphan
+}
+
+// claudieSveltosClusterAnnotations are the annotations this controller sets on SveltosClusters,
+// on top of the configured ExtraAnnotations
+var claudieSveltosClusterAnnotations = []string{sveltosClusterClaudieAnnotation, sveltosClusterSecretAnnotation,
+	sveltosClusterSecretNamespaceAnnotation, sveltosClusterExpiresAtAnnotation, kubeconfigHashAnnotation,
+	kubeconfigRotatedAtAnnotation, sveltosClusterKubeconfigKeyAnnotation, sveltosClusterEndpointAnnotation,
+	deletePolicyAnnotation, sveltosClusterSecretMissingAnnotation, reachableAnnotation, probedAtAnnotation,
+	sveltosClusterRegionAnnotation, sveltosClusterZoneAnnotation, retainAnnotation}
+
+// claudieMetadata lists the annotation and label keys this controller sets on SveltosClusters
+type claudieMetadata struct {
+	annotations []string
+	labels      []string
+}
+
+// getClaudieMetadata returns the annotation and label keys this controller sets on SveltosClusters,
+// including the configured ExtraAnnotations and owned labels
+func (r *SecretReconciler) getClaudieMetadata() *claudieMetadata {
+	annotations := append([]string{}, claudieSveltosClusterAnnotations...)
+	for key := range r.ExtraAnnotations {
+		annotations = append(annotations, key)
+	}
+
+	return &claudieMetadata{annotations: annotations, labels: r.getOwnedLabels()}
+}
+
+// removeClaudieMetadata removes from sveltosCluster the annotations and labels in metadata and
+// Secret OwnerReferences (so SveltosCluster is not garbage collected when Secret is deleted)
+func removeClaudieMetadata(sveltosCluster *libsveltosv1alpha1.SveltosCluster, metadata *claudieMetadata) {
+	for _, annotation := range metadata.annotations {
+		delete(sveltosCluster.Annotations, annotation)
+	}
+	for _, label := range metadata.labels {
+		delete(sveltosCluster.Labels, label)
+	}
+	removeSecretOwnerReferences(sveltosCluster)
+	setDecision(sveltosCluster, decisionReleased)
+}
+
+// orphanSveltosCluster releases from Claudie management sveltosCluster whose Claudie Secret is being
+// deleted, leaving it in place. Secret stops being tracked, without being marked as deleting.
+func (r *SecretReconciler) orphanSveltosCluster(ctx context.Context, secretKey, sveltosClusterInfo types.NamespacedName,
+	sveltosCluster *libsveltosv1alpha1.SveltosCluster, logger logr.Logger) error {
+
+	logger.V(logs.LogInfo).Info("delete policy is Orphan. SveltosCluster is released instead of deleted")
+
+	removeClaudieMetadata(sveltosCluster, r.getClaudieMetadata())
+	if err := r.writeSveltosCluster(ctx, sveltosCluster, false); err != nil {
+		return err
+	}
+
+	r.recordEvent(sveltosCluster, corev1.EventTypeNormal, reasonSveltosClusterReleased,
+		fmt.Sprintf("SveltosCluster %s/%s released from Claudie management on Secret %s deletion",
+			sveltosCluster.Namespace, sveltosCluster.Name, secretKey))
+	r.forgetSveltosCluster(secretKey, sveltosClusterInfo, false)
+	return nil
+}
+
+// orphanStaleSveltosCluster releases from Claudie management sveltosCluster whose Claudie Secret
+// does not exist anymore. metadata lists the annotations and labels to remove.
+func orphanStaleSveltosCluster(ctx context.Context, c client.Client, sveltosCluster *libsveltosv1alpha1.SveltosCluster,
+	metadata *claudieMetadata, logger logr.Logger) {
+
+	logger.V(logs.LogInfo).Info("delete policy is Orphan. Releasing stale SveltosCluster")
+
+	removeClaudieMetadata(sveltosCluster, metadata)
+	if err := c.Update(ctx, sveltosCluster); err != nil {
+		logger.V(logs.LogInfo).Info(fmt.Sprintf("failed to release sveltosCluster: %v", err))
+	}
+}
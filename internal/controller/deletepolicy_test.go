@@ -0,0 +1,188 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"gianlucam76/claudie-sveltos-integration/internal/controller"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+)
+
+var _ = Describe("Delete policy", func() {
+	DescribeTable("Reconcile honors the Secret delete policy once Secret is deleted",
+		func(policy string, retained bool) {
+			secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+			if policy != "" {
+				secret.Annotations = map[string]string{controller.DeletePolicyAnnotation: policy}
+			}
+			Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+			reconciler := getSecretReconciler(c)
+
+			secretRef := reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name},
+			}
+			_, err := reconciler.Reconcile(context.TODO(), secretRef)
+			Expect(err).To(BeNil())
+
+			sveltosClusterKey := types.NamespacedName{Namespace: secret.Namespace, Name: secret.Labels[controller.ClaudieCluster]}
+			sveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+			Expect(c.Get(context.TODO(), sveltosClusterKey, sveltosCluster)).To(Succeed())
+			Expect(sveltosCluster.OwnerReferences).To(HaveLen(1))
+
+			Expect(c.Get(context.TODO(), secretRef.NamespacedName, secret)).To(Succeed())
+			Expect(c.Delete(context.TODO(), secret)).To(Succeed())
+			_, err = reconciler.Reconcile(context.TODO(), secretRef)
+			Expect(err).To(BeNil())
+			Expect(reconciler.SecretToCluster()).To(BeEmpty())
+
+			err = c.Get(context.TODO(), sveltosClusterKey, sveltosCluster)
+			if !retained {
+				Expect(apierrors.IsNotFound(err)).To(BeTrue())
+				return
+			}
+
+			Expect(err).To(BeNil())
+			Expect(sveltosCluster.OwnerReferences).To(BeEmpty())
+			Expect(controller.IsSveltosClusterForClaudie(sveltosCluster)).To(BeFalse())
+			Expect(sveltosCluster.Annotations).ToNot(HaveKey(controller.DeletePolicyAnnotation))
+		},
+		Entry("default policy", "", false),
+		Entry("Delete policy", "Delete", false),
+		Entry("unknown policy", "Keep", false),
+		Entry("Orphan policy", "Orphan", true),
+	)
+
+	It("removeStaleSveltosClusters releases SveltosClusters with Orphan delete policy", func() {
+		orphan := &libsveltosv1alpha1.SveltosCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: randomString(),
+				Name:      randomString(),
+				Annotations: map[string]string{
+					controller.SveltosClusterClaudieAnnotation: "ok",
+					controller.DeletePolicyAnnotation:          "Orphan",
+				},
+				OwnerReferences: []metav1.OwnerReference{
+					{Kind: "Secret", APIVersion: "v1", Name: randomString()},
+				},
+			},
+		}
+		deleted := &libsveltosv1alpha1.SveltosCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: orphan.Namespace,
+				Name:      randomString(),
+				Annotations: map[string]string{
+					controller.SveltosClusterClaudieAnnotation: "ok",
+				},
+				OwnerReferences: []metav1.OwnerReference{
+					{Kind: "Secret", APIVersion: "v1", Name: randomString()},
+				},
+			},
+		}
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(orphan, deleted).Build()
+
+		mapReady := make(chan struct{})
+		close(mapReady)
+		controller.RemoveStaleSveltosClusters(context.TODO(), c, c, controller.DefaultClaudieMetadata, 0, mapReady, logr.Logger{})
+
+		Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(orphan), orphan)).To(Succeed())
+		Expect(orphan.OwnerReferences).To(BeEmpty())
+		Expect(controller.IsSveltosClusterForClaudie(orphan)).To(BeFalse())
+
+		err := c.Get(context.TODO(), client.ObjectKeyFromObject(deleted), deleted)
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("releasing a SveltosCluster removes all annotations and labels set when creating it", func() {
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		secret.Labels[controller.RegionLabel] = "eu-west-1"
+		secret.Labels[controller.ZoneLabel] = "eu-west-1a"
+		secret.Labels["team"] = "platform"
+		secret.Annotations = map[string]string{"claudie.io/tier": "gold"}
+		Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+		reconciler := getSecretReconciler(c)
+		reconciler.ExtraAnnotations = map[string]string{"example.com/cost-center": "42"}
+		reconciler.AutoTargetLabelKey = "sveltos-managed"
+		reconciler.AutoTargetLabelValue = "true"
+		reconciler.PropagatedLabels = []string{"team"}
+		reconciler.LabelMapping = map[string]string{"claudie.io/tier": "tier"}
+		reconciler.RetainOnSecretDelete = true
+
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+
+		sveltosClusterKey := types.NamespacedName{Namespace: secret.Namespace, Name: secret.Labels[controller.ClaudieCluster]}
+		sveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+		Expect(c.Get(context.TODO(), sveltosClusterKey, sveltosCluster)).To(Succeed())
+		Expect(sveltosCluster.Annotations).To(HaveKey(controller.SveltosClusterRegionAnnotation))
+		Expect(sveltosCluster.Annotations).To(HaveKey(controller.SveltosClusterZoneAnnotation))
+		Expect(sveltosCluster.Annotations).To(HaveKey(controller.RetainAnnotation))
+		Expect(sveltosCluster.Annotations).To(HaveKey("example.com/cost-center"))
+		Expect(sveltosCluster.Labels).To(HaveKey("sveltos-managed"))
+		Expect(sveltosCluster.Labels).To(HaveKey("team"))
+		Expect(sveltosCluster.Labels).To(HaveKey("tier"))
+
+		createdAnnotations := make([]string, 0, len(sveltosCluster.Annotations))
+		for key := range sveltosCluster.Annotations {
+			createdAnnotations = append(createdAnnotations, key)
+		}
+		createdLabels := make([]string, 0, len(sveltosCluster.Labels))
+		for key := range sveltosCluster.Labels {
+			createdLabels = append(createdLabels, key)
+		}
+
+		// Metadata set by users is preserved
+		sveltosCluster.Annotations[controller.UnmanageAnnotation] = "true"
+		sveltosCluster.Annotations["example.com/owner"] = "ops"
+		sveltosCluster.Labels["example.com/owner"] = "ops"
+		Expect(c.Update(context.TODO(), sveltosCluster)).To(Succeed())
+
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+
+		Expect(c.Get(context.TODO(), sveltosClusterKey, sveltosCluster)).To(Succeed())
+		for _, key := range createdAnnotations {
+			if key == controller.SveltosClusterDecisionAnnotation {
+				continue
+			}
+			Expect(sveltosCluster.Annotations).ToNot(HaveKey(key))
+		}
+		for _, key := range createdLabels {
+			Expect(sveltosCluster.Labels).ToNot(HaveKey(key))
+		}
+		Expect(sveltosCluster.Annotations).To(HaveKeyWithValue(controller.SveltosClusterDecisionAnnotation, "released"))
+		Expect(sveltosCluster.Annotations).To(HaveKey(controller.UnmanageAnnotation))
+		Expect(sveltosCluster.Annotations).To(HaveKey("example.com/owner"))
+		Expect(sveltosCluster.Labels).To(HaveKey("example.com/owner"))
+		Expect(sveltosCluster.OwnerReferences).To(BeEmpty())
+	})
+})
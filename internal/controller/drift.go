@@ -0,0 +1,76 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+
+	logs "github.com/projectsveltos/libsveltos/lib/logsettings"
+)
+
+// correctDrift periodically re-asserts the state of all managed SveltosClusters, so drift
+// (for instance a removed annotation or a modified KubeconfigName) is corrected even when
+// no Secret event fires. It returns when ctx is canceled.
+func (r *SecretReconciler) correctDrift(ctx context.Context, mapReady <-chan struct{}, logger logr.Logger) {
+	select {
+	case <-ctx.Done():
+		return
+	case <-mapReady:
+	}
+
+	ticker := time.NewTicker(r.DriftReconcileInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.V(logs.LogInfo).Info("stopping drift correction")
+			return
+		case <-ticker.C:
+			r.reconcileManagedSveltosClusters(ctx, logger)
+		}
+	}
+}
+
+// reconcileManagedSveltosClusters reconciles the SveltosCluster of each Secret currently tracked
+func (r *SecretReconciler) reconcileManagedSveltosClusters(ctx context.Context, logger logr.Logger) {
+	secrets := r.secretToCluster.Secrets()
+
+	logger.V(logs.LogDebug).Info(fmt.Sprintf("correcting drift for %d SveltosClusters", len(secrets)))
+
+	for i := range secrets {
+		secret := &corev1.Secret{}
+		if err := r.Get(ctx, secrets[i], secret); err != nil {
+			// Removed Secrets are handled by the Secret watch and the stale loop
+			continue
+		}
+
+		if !secret.DeletionTimestamp.IsZero() || !r.shouldReconcileSecret(secret) {
+			continue
+		}
+
+		secretLogger := r.getSecretLogger(logger, secret)
+		if err := r.createSveltosCluster(ctx, secret, secretLogger); err != nil {
+			secretLogger.V(logs.LogInfo).Info(fmt.Sprintf("failed to correct drift: %v", err))
+		}
+	}
+}
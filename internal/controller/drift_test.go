@@ -0,0 +1,96 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"gianlucam76/claudie-sveltos-integration/internal/controller"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+)
+
+var _ = Describe("Drift", func() {
+	It("reconcileManagedSveltosClusters restores drifted fields", func() {
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+		reconciler := getSecretReconciler(c)
+
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+
+		sveltosClusterKey := types.NamespacedName{Namespace: secret.Namespace, Name: secret.Labels[controller.ClaudieCluster]}
+		currentSveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+		Expect(c.Get(context.TODO(), sveltosClusterKey, currentSveltosCluster)).To(Succeed())
+
+		// Drift: annotation and owner reference removed, KubeconfigName modified
+		currentSveltosCluster.Annotations = nil
+		currentSveltosCluster.OwnerReferences = nil
+		currentSveltosCluster.Spec.KubeconfigName = randomString()
+		Expect(c.Update(context.TODO(), currentSveltosCluster)).To(Succeed())
+
+		controller.ReconcileManagedSveltosClusters(reconciler, context.TODO(), logr.Logger{})
+
+		Expect(c.Get(context.TODO(), sveltosClusterKey, currentSveltosCluster)).To(Succeed())
+		Expect(currentSveltosCluster.Annotations).To(HaveKey(controller.SveltosClusterClaudieAnnotation))
+		Expect(currentSveltosCluster.Spec.KubeconfigName).To(Equal(secret.Name))
+		Expect(len(currentSveltosCluster.OwnerReferences)).To(Equal(1))
+		Expect(currentSveltosCluster.OwnerReferences[0].Name).To(Equal(secret.Name))
+	})
+
+	It("reconcileManagedSveltosClusters ignores Secrets not existing anymore", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := getSecretReconciler(c)
+
+		secretKey := types.NamespacedName{Namespace: randomString(), Name: randomString()}
+		reconciler.TrackSveltosCluster(secretKey, types.NamespacedName{Namespace: secretKey.Namespace, Name: randomString()})
+
+		controller.ReconcileManagedSveltosClusters(reconciler, context.TODO(), logr.Logger{})
+
+		currentSveltosClusters := &libsveltosv1alpha1.SveltosClusterList{}
+		Expect(c.List(context.TODO(), currentSveltosClusters)).To(Succeed())
+		Expect(currentSveltosClusters.Items).To(BeEmpty())
+	})
+
+	It("correctDrift stops when context is canceled", func() {
+		reconciler := getSecretReconciler(fake.NewClientBuilder().WithScheme(scheme).Build())
+		reconciler.DriftReconcileInterval = time.Millisecond
+
+		ctx, cancel := context.WithCancel(context.TODO())
+		mapReady := make(chan struct{})
+		close(mapReady)
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			controller.CorrectDrift(reconciler, ctx, mapReady, logr.Logger{})
+		}()
+
+		Consistently(done, 20*time.Millisecond).ShouldNot(BeClosed())
+		cancel()
+		Eventually(done, time.Second).Should(BeClosed())
+	})
+})
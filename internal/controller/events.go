@@ -0,0 +1,128 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// EventFilter defines which event types are recorded
+type EventFilter string
+
+const (
+	// EventFilterAll records both Normal and Warning events
+	EventFilterAll = EventFilter("All")
+
+	// EventFilterNormal records Normal events only
+	EventFilterNormal = EventFilter("Normal")
+
+	// EventFilterWarning records Warning events only
+	EventFilterWarning = EventFilter("Warning")
+)
+
+const (
+	// eventRecorderName is the component name used when recording events
+	eventRecorderName = "claudie-sveltos"
+)
+
+const (
+	// reasonSveltosClusterConflict is used when SveltosCluster is already owned by a different Secret
+	reasonSveltosClusterConflict = "SveltosClusterConflict"
+
+	// reasonSveltosClusterTakenOver is used when ownership of a SveltosCluster moved to a different Secret
+	reasonSveltosClusterTakenOver = "SveltosClusterTakenOver"
+
+	// reasonKubeconfigRotated is used when a SveltosCluster moved to a newer Secret for the same cluster
+	reasonKubeconfigRotated = "KubeconfigRotated"
+
+	// reasonSecretSuperseded is used when a Secret is ignored because a newer Secret for the same cluster exists
+	reasonSecretSuperseded = "SecretSuperseded"
+
+	// reasonDuplicateSveltosClusterRemoved is used when a SveltosCluster for the same Secret found at
+	// another API version is removed
+	reasonDuplicateSveltosClusterRemoved = "DuplicateSveltosClusterRemoved"
+
+	// reasonSveltosClusterDeleteFailed is used when the SveltosCluster for a Secret could not be deleted
+	reasonSveltosClusterDeleteFailed = "SveltosClusterDeleteFailed"
+
+	// reasonInvalidNamespace is used when the namespace computed for a SveltosCluster is not valid
+	reasonInvalidNamespace = "InvalidSveltosClusterNamespace"
+
+	// reasonSveltosClusterReleased is used when a SveltosCluster is released from Claudie management
+	reasonSveltosClusterReleased = "SveltosClusterReleased"
+
+	// reasonSveltosClusterAdopted is used when a SveltosCluster not created by this controller is adopted
+	reasonSveltosClusterAdopted = "SveltosClusterAdopted"
+
+	// reasonSveltosClusterAdoptionRefused is used when a SveltosCluster not created by this controller
+	// is not adopted and reconciliation is retried
+	reasonSveltosClusterAdoptionRefused = "SveltosClusterAdoptionRefused"
+
+	// reasonSveltosClusterAdoptionSkipped is used when a SveltosCluster not created by this controller
+	// is ignored
+	reasonSveltosClusterAdoptionSkipped = "SveltosClusterAdoptionSkipped"
+
+	// reasonSveltosClusterCreated is used when the SveltosCluster for a Secret is created
+	reasonSveltosClusterCreated = "SveltosClusterCreated"
+
+	// reasonSveltosClusterUpdated is used when the SveltosCluster for a Secret is modified
+	reasonSveltosClusterUpdated = "SveltosClusterUpdated"
+
+	// reasonSveltosClusterDeleted is used when the SveltosCluster for a Secret is deleted
+	reasonSveltosClusterDeleted = "SveltosClusterDeleted"
+
+	// reasonReconcileFailed is used when the SveltosCluster for a Secret could not be reconciled
+	reasonReconcileFailed = "ReconcileFailed"
+
+	// reasonInvalidClusterName is used when no SveltosCluster name can be derived from the Secret cluster name
+	reasonInvalidClusterName = "InvalidClusterName"
+
+	// reasonNamespaceNotWatched is used when a Secret asks for its SveltosCluster to be created in a
+	// namespace the controller does not watch
+	reasonNamespaceNotWatched = "NamespaceNotWatched"
+
+	// reasonMissingKubeconfig is used when a Claudie Secret does not contain any kubeconfig data
+	reasonMissingKubeconfig = "MissingKubeconfig"
+)
+
+// recordEvent records an event for obj. It is a no-op if no EventRecorder is set
+// or if eventType is filtered out by the configured EventFilter.
+func (r *SecretReconciler) recordEvent(obj runtime.Object, eventType, reason, message string) {
+	if r.EventRecorder == nil {
+		return
+	}
+
+	if !r.shouldRecordEvent(eventType) {
+		return
+	}
+
+	r.EventRecorder.Event(obj, eventType, reason, message)
+}
+
+// shouldRecordEvent returns true if events of type eventType must be recorded.
+// An empty EventFilter records all events.
+func (r *SecretReconciler) shouldRecordEvent(eventType string) bool {
+	switch r.EventFilter {
+	case EventFilterNormal:
+		return eventType == corev1.EventTypeNormal
+	case EventFilterWarning:
+		return eventType == corev1.EventTypeWarning
+	default:
+		return true
+	}
+}
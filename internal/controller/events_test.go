@@ -0,0 +1,140 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	"gianlucam76/claudie-sveltos-integration/internal/controller"
+)
+
+var _ = Describe("Events", func() {
+	var secret *corev1.Secret
+
+	BeforeEach(func() {
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: randomString(),
+				Name:      randomString(),
+			},
+		}
+	})
+
+	It("recordEvent records all event types by default", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := getSecretReconciler(c)
+		recorder := record.NewFakeRecorder(10)
+		reconciler.EventRecorder = recorder
+
+		controller.RecordEvent(reconciler, secret, corev1.EventTypeNormal, randomString(), randomString())
+		controller.RecordEvent(reconciler, secret, corev1.EventTypeWarning, randomString(), randomString())
+		Expect(len(recorder.Events)).To(Equal(2))
+	})
+
+	It("recordEvent records only Normal events when so configured", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := getSecretReconciler(c)
+		recorder := record.NewFakeRecorder(10)
+		reconciler.EventRecorder = recorder
+		reconciler.EventFilter = controller.EventFilterNormal
+
+		controller.RecordEvent(reconciler, secret, corev1.EventTypeNormal, randomString(), randomString())
+		controller.RecordEvent(reconciler, secret, corev1.EventTypeWarning, randomString(), randomString())
+		Expect(len(recorder.Events)).To(Equal(1))
+		Expect(recorder.Events).To(Receive(HavePrefix(corev1.EventTypeNormal)))
+	})
+
+	It("recordEvent records only Warning events when so configured", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := getSecretReconciler(c)
+		recorder := record.NewFakeRecorder(10)
+		reconciler.EventRecorder = recorder
+		reconciler.EventFilter = controller.EventFilterWarning
+
+		controller.RecordEvent(reconciler, secret, corev1.EventTypeNormal, randomString(), randomString())
+		controller.RecordEvent(reconciler, secret, corev1.EventTypeWarning, randomString(), randomString())
+		Expect(len(recorder.Events)).To(Equal(1))
+		Expect(recorder.Events).To(Receive(HavePrefix(corev1.EventTypeWarning)))
+	})
+
+	It("recordEvent is a no-op when no EventRecorder is set", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := getSecretReconciler(c)
+		reconciler.EventRecorder = nil
+
+		Expect(func() {
+			controller.RecordEvent(reconciler, secret, corev1.EventTypeWarning, randomString(), randomString())
+		}).ToNot(Panic())
+	})
+
+	It("records events on SveltosCluster creation, update and deletion", func() {
+		claudieSecret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		Expect(addTypeInformationToObject(scheme, claudieSecret)).To(Succeed())
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(claudieSecret).Build()
+		reconciler := getSecretReconciler(c)
+		recorder := record.NewFakeRecorder(10)
+		reconciler.EventRecorder = recorder
+
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), claudieSecret, logr.Logger{})).To(Succeed())
+		Expect(recorder.Events).To(Receive(ContainSubstring(controller.ReasonSveltosClusterCreated)))
+
+		// Nothing changed, no event
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), claudieSecret, logr.Logger{})).To(Succeed())
+		Expect(recorder.Events).To(BeEmpty())
+
+		claudieSecret.Labels[controller.RegionLabel] = randomString()
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), claudieSecret, logr.Logger{})).To(Succeed())
+		Expect(recorder.Events).To(Receive(ContainSubstring(controller.ReasonSveltosClusterUpdated)))
+
+		secretRef := ctrl.Request{
+			NamespacedName: types.NamespacedName{Namespace: claudieSecret.Namespace, Name: claudieSecret.Name},
+		}
+		Expect(controller.CleanSveltosCluster(reconciler, context.TODO(), secretRef, logr.Logger{})).To(Succeed())
+		Expect(recorder.Events).To(Receive(ContainSubstring(controller.ReasonSveltosClusterDeleted)))
+	})
+
+	It("Reconcile records a Warning event when SveltosCluster cannot be reconciled", func() {
+		claudieSecret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		claudieSecret.Namespace = "Invalid_Namespace"
+		Expect(addTypeInformationToObject(scheme, claudieSecret)).To(Succeed())
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(claudieSecret).Build()
+		reconciler := getSecretReconciler(c)
+		recorder := record.NewFakeRecorder(10)
+		reconciler.EventRecorder = recorder
+
+		_, err := reconciler.Reconcile(context.TODO(), ctrl.Request{
+			NamespacedName: types.NamespacedName{Namespace: claudieSecret.Namespace, Name: claudieSecret.Name},
+		})
+		Expect(err).ToNot(BeNil())
+		Expect(recorder.Events).To(Receive(ContainSubstring(controller.ReasonInvalidNamespace)))
+		Expect(recorder.Events).To(Receive(And(HavePrefix(corev1.EventTypeWarning),
+			ContainSubstring(controller.ReasonReconcileFailed))))
+	})
+})
@@ -16,27 +16,223 @@ limitations under the License.
 
 package controller
 
+import (
+	"k8s.io/apimachinery/pkg/types"
+)
+
 const (
 	SveltosClusterClaudieAnnotation = sveltosClusterClaudieAnnotation
+	SveltosClusterSecretAnnotation  = sveltosClusterSecretAnnotation
+
+	SveltosClusterSecretNamespaceAnnotation = sveltosClusterSecretNamespaceAnnotation
+	TargetNamespaceAnnotation               = targetNamespaceAnnotation
+	ClaudieCleanupFinalizer                 = claudieCleanupFinalizer
+	SveltosClusterFieldManager              = sveltosClusterFieldManager
+	DeletePolicyAnnotation                  = deletePolicyAnnotation
+	ReachableAnnotation                     = reachableAnnotation
+	KubeconfigSecretRefKey                  = kubeconfigSecretRefKey
+	JitterFactor                            = jitterFactor
+	ProbedAtAnnotation                      = probedAtAnnotation
+	CAPIClusterNameLabel                    = capiClusterNameLabel
+	CAPISecretType                          = capiSecretType
+	CAPIKubeconfigDataKey                   = capiKubeconfigDataKey
+	StaleSweepPageSize                      = staleSweepPageSize
 )
 
 var (
 	IsSveltosClusterForClaudie = isSveltosClusterForClaudie
 	GetClaudieSecret           = getClaudieSecret
+	GetClaudieSecrets          = getClaudieSecrets
 	IsClaudieSecretRemoved     = isClaudieSecretRemoved
+	RemoveStaleSveltosClusters = removeStaleSveltosClusters
+	CleanStaleSveltosCluster   = cleanStaleSveltosCluster
+	GetClaudieMetadata         = (*SecretReconciler).getClaudieMetadata
+	DefaultClaudieMetadata     = (&SecretReconciler{}).getClaudieMetadata()
+	NewTimeoutClient           = newTimeoutClient
+	NewTimeoutReader           = newTimeoutReader
+	NewFailureRateLimiter      = newFailureRateLimiter
+	GetConcurrentReconciles    = (*SecretReconciler).getConcurrentReconciles
+	EnqueueClaudieSecrets      = (*SecretReconciler).enqueueClaudieSecrets
+	AddStaleSweep              = (*SecretReconciler).addStaleSweep
+	Jitter                     = jitter
 )
 
 const (
 	ClaudieLabel      = claudieLabel
 	ClaudieKubeconfig = claudieKubeconfig
 	ClaudieCluster    = claudieCluster
+	ClaudieProject    = claudieProject
+
+	ClaudieVersionAnnotation = claudieVersionAnnotation
+
+	RegionLabel                    = regionLabel
+	ZoneLabel                      = zoneLabel
+	SveltosClusterRegionAnnotation = sveltosClusterRegionAnnotation
+	SveltosClusterZoneAnnotation   = sveltosClusterZoneAnnotation
 )
 
 var (
 	ShouldReconcileSecret      = (*SecretReconciler).shouldReconcileSecret
+	IsClaudieVersionSupported  = (*SecretReconciler).isClaudieVersionSupported
 	GetSveltosClusterNamespace = (*SecretReconciler).getSveltosClusterNamespace
+	GetSveltosClusterName      = (*SecretReconciler).getSveltosClusterName
+	GetSveltosClusterApply     = (*SecretReconciler).getSveltosClusterApplyObject
 	CleanSveltosCluster        = (*SecretReconciler).cleanSveltosCluster
 	AddOwnerReference          = (*SecretReconciler).addOwnerReference
 	AddAnnotation              = (*SecretReconciler).addAnnotation
+	AddTopologyAnnotations     = (*SecretReconciler).addTopologyAnnotations
 	CreateSveltosCluster       = (*SecretReconciler).createSveltosCluster
+	GetDeletionRetention       = (*SecretReconciler).getDeletionRetention
+	VerifyNamespaceActive      = (*SecretReconciler).verifyNamespaceActive
+	IsTargetNamespaceWatched   = (*SecretReconciler).isTargetNamespaceWatched
+	GetNamespaceThrottle       = (*SecretReconciler).getNamespaceThrottle
+	GetSecretPredicate         = (*SecretReconciler).getSecretPredicate
+	GetClaudieSecretPredicate  = (*SecretReconciler).getClaudieSecretPredicate
+
+	ReconcileManagedSveltosClusters = (*SecretReconciler).reconcileManagedSveltosClusters
+	CorrectDrift                    = (*SecretReconciler).correctDrift
+	ToSveltosClusterVersion         = (*SecretReconciler).toSveltosClusterVersion
+	GetSveltosClusterAPIVersion     = (*SecretReconciler).getSveltosClusterAPIVersion
+	RemoveDuplicateSveltosClusters  = (*SecretReconciler).removeDuplicateSveltosClusters
+	SanitizeClusterName             = sanitizeClusterName
+	RestrictSpecUpdate              = (*SecretReconciler).restrictSpecUpdate
+	ApplySpecMapping                = (*SecretReconciler).applySpecMapping
+	RebuildSecretToClusterMap       = (*SecretReconciler).rebuildSecretToClusterMap
+	RequeueClaudieSecret            = (*SecretReconciler).requeueClaudieSecret
+	IsSecretTracked                 = (*SecretReconciler).isSecretTracked
+	ForgetSveltosCluster            = (*SecretReconciler).forgetSveltosCluster
+	GetSveltosClusterPredicate      = getSveltosClusterPredicate
+)
+
+const (
+	ActionCreate    = actionCreate
+	ActionUpdate    = actionUpdate
+	ActionDelete    = actionDelete
+	ActionSkip      = actionSkip
+	ResultSuccess   = resultSuccess
+	ResultError     = resultError
+	UnknownProvider = unknownProvider
+)
+
+const (
+	SkipAnnotation = skipAnnotation
+
+	SecretSveltosClusterAnnotation = secretSveltosClusterAnnotation
+	UnmanageAnnotation             = unmanageAnnotation
+	PausedAnnotation               = pausedAnnotation
+
+	SveltosClusterDecisionAnnotation = sveltosClusterDecisionAnnotation
+
+	TTLAnnotation                         = ttlAnnotation
+	TTLDeleteSecretAnnotation             = ttlDeleteSecretAnnotation
+	SveltosClusterExpiresAtAnnotation     = sveltosClusterExpiresAtAnnotation
+	SveltosClusterSecretMissingAnnotation = sveltosClusterSecretMissingAnnotation
+	RetainAnnotation                      = retainAnnotation
+	SveltosClusterEndpointAnnotation      = sveltosClusterEndpointAnnotation
+)
+
+const (
+	KubeconfigDataKey           = kubeconfigDataKey
+	KubeconfigContextAnnotation = kubeconfigContextAnnotation
+	KubeconfigServerAnnotation  = kubeconfigServerAnnotation
+
+	SveltosClusterKubeconfigKeyAnnotation = sveltosClusterKubeconfigKeyAnnotation
+
+	KubeconfigHashAnnotation      = kubeconfigHashAnnotation
+	KubeconfigRotatedAtAnnotation = kubeconfigRotatedAtAnnotation
+)
+
+var (
+	GetMirroredKubeconfigName = getMirroredKubeconfigName
+	GetKubeconfigToMirror     = (*SecretReconciler).getKubeconfigToMirror
+	GetKubeconfigKey          = (*SecretReconciler).getKubeconfigKey
+	RemoveMirroredKubeconfig  = removeMirroredKubeconfig
+	AddFreshnessAnnotations   = (*SecretReconciler).addFreshnessAnnotations
+	ValidateKubeconfig        = (*SecretReconciler).validateKubeconfig
+	ResolveKubeconfig         = (*SecretReconciler).resolveKubeconfig
+)
+
+const (
+	ReasonSveltosClusterConflict     = reasonSveltosClusterConflict
+	ReasonSveltosClusterDeleteFailed = reasonSveltosClusterDeleteFailed
+	ReasonInvalidNamespace           = reasonInvalidNamespace
+	ReasonNamespaceNotWatched        = reasonNamespaceNotWatched
+	ReasonInvalidClusterName         = reasonInvalidClusterName
+	ReasonMissingKubeconfig          = reasonMissingKubeconfig
+	ReasonSveltosClusterReleased     = reasonSveltosClusterReleased
+
+	ReasonSveltosClusterAdopted         = reasonSveltosClusterAdopted
+	ReasonSveltosClusterAdoptionRefused = reasonSveltosClusterAdoptionRefused
+	ReasonSveltosClusterAdoptionSkipped = reasonSveltosClusterAdoptionSkipped
+
+	ReasonKubeconfigRotated     = reasonKubeconfigRotated
+	ReasonSecretSuperseded      = reasonSecretSuperseded
+	ReasonSveltosClusterCreated = reasonSveltosClusterCreated
+	ReasonSveltosClusterUpdated = reasonSveltosClusterUpdated
+	ReasonSveltosClusterDeleted = reasonSveltosClusterDeleted
+	ReasonReconcileFailed       = reasonReconcileFailed
 )
+
+var (
+	RecordEvent = (*SecretReconciler).recordEvent
+)
+
+var (
+	IsTransientError = isTransientError
+)
+
+var (
+	ReconcilePanics = reconcilePanics
+	ReconcileTotal  = reconcileTotal
+
+	SecretToClusterOperations = secretToClusterOperations
+	SecretToClusterSize       = secretToClusterSize
+
+	SveltosClusterDeleteFailures = sveltosClusterDeleteFailures
+
+	StaleDetected      = staleDetected
+	StaleDeleteErrors  = staleDeleteErrors
+	LastSweepTimestamp = lastSweepTimestamp
+)
+
+const (
+	OperationInsert = operationInsert
+	OperationDelete = operationDelete
+	OperationLookup = operationLookup
+)
+
+const (
+	ClaudieProviderLabel = claudieProviderLabel
+)
+
+var (
+	GetSpecDefaults = (*SecretReconciler).getSpecDefaults
+)
+
+var (
+	GetClusterProfileStubName = getClusterProfileStubName
+	RemoveClusterProfileStub  = (*SecretReconciler).removeClusterProfileStub
+)
+
+// SetMapReady sets the channel closed once SecretToCluster map is rebuilt and the cache is synced
+func (r *SecretReconciler) SetMapReady(mapReady chan struct{}) {
+	r.mapReady = mapReady
+}
+
+// SecretToCluster returns a copy of the Claudie Secret to SveltosCluster associations
+func (r *SecretReconciler) SecretToCluster() map[types.NamespacedName]types.NamespacedName {
+	secretToCluster := make(map[types.NamespacedName]types.NamespacedName)
+	for _, secretKey := range r.secretToCluster.Secrets() {
+		if sveltosClusterKey, ok := r.secretToCluster.Get(secretKey); ok {
+			secretToCluster[secretKey] = sveltosClusterKey
+		}
+	}
+	return secretToCluster
+}
+
+// TrackSveltosCluster records the SveltosCluster for Secret
+func (r *SecretReconciler) TrackSveltosCluster(secretKey, sveltosClusterKey types.NamespacedName) {
+	r.secretToCluster.Set(secretKey, sveltosClusterKey)
+}
+
+type ClusterTracker = clusterTracker
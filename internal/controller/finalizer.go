@@ -0,0 +1,95 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+)
+
+const (
+	// claudieCleanupFinalizer is added to reconciled Claudie Secrets, so that when a Secret is deleted
+	// its SveltosCluster is deleted synchronously, independently of in-memory state (which is lost on
+	// restarts) and of garbage collection (which does not work across namespaces). It is removed once
+	// the SveltosCluster is deleted.
+	claudieCleanupFinalizer = "projectsveltos.io/claudie-cleanup"
+)
+
+// addCleanupFinalizer adds, if not present yet, the cleanup finalizer to secret.
+// A Secret already gone needs no finalizer: its removal is handled by a new reconciliation.
+func (r *SecretReconciler) addCleanupFinalizer(ctx context.Context, secret *corev1.Secret) error {
+	if !controllerutil.AddFinalizer(secret, claudieCleanupFinalizer) {
+		return nil
+	}
+
+	return client.IgnoreNotFound(r.Update(ctx, secret))
+}
+
+// isBeingCleanedUp returns true if object is a Secret being deleted which still carries the cleanup finalizer
+func isBeingCleanedUp(object client.Object) bool {
+	return !object.GetDeletionTimestamp().IsZero() &&
+		controllerutil.ContainsFinalizer(object, claudieCleanupFinalizer)
+}
+
+// removeCleanupFinalizer removes, if present, the cleanup finalizer from secret
+func (r *SecretReconciler) removeCleanupFinalizer(ctx context.Context, secret *corev1.Secret) error {
+	if !controllerutil.RemoveFinalizer(secret, claudieCleanupFinalizer) {
+		return nil
+	}
+
+	return client.IgnoreNotFound(r.Update(ctx, secret))
+}
+
+// trackForCleanup makes sure the SveltosCluster for a Secret being deleted is tracked, so it is
+// removed even when SecretToCluster map was lost (for instance on a controller restart).
+// SveltosCluster is tracked only if owned by secret (and, when recorded, created for secret UID).
+func (r *SecretReconciler) trackForCleanup(ctx context.Context, secret *corev1.Secret) error {
+	secretKey := client.ObjectKeyFromObject(secret)
+
+	if _, tracked := r.secretToCluster.Get(secretKey); tracked {
+		return nil
+	}
+
+	sveltosClusterKey := types.NamespacedName{
+		Namespace: r.getSveltosClusterNamespace(secret),
+		Name:      r.getSveltosClusterName(secret),
+	}
+
+	sveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+	err := r.Get(ctx, sveltosClusterKey, sveltosCluster)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	owner := getClaudieSecret(sveltosCluster)
+	if owner == nil || *owner != secretKey || !isMarkedForSecret(sveltosCluster, secret) {
+		return nil
+	}
+
+	r.secretToCluster.Set(secretKey, sveltosClusterKey)
+	return nil
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+)
+
+const (
+	// kubeconfigHashAnnotation is set on SveltosCluster and contains the hash of the
+	// kubeconfig in the Claudie Secret
+	kubeconfigHashAnnotation = "projectsveltos.io/claudie-kubeconfig-hash"
+
+	// kubeconfigRotatedAtAnnotation is set on SveltosCluster and contains the time (RFC3339)
+	// the kubeconfig hash last changed, so stale credentials can be spotted
+	kubeconfigRotatedAtAnnotation = "projectsveltos.io/claudie-kubeconfig-rotated-at"
+)
+
+// getKubeconfigHash returns the hash of the kubeconfig contained in the Claudie Secret.
+// Returns an empty string if the Secret does not contain a kubeconfig.
+func (r *SecretReconciler) getKubeconfigHash(secret *corev1.Secret) string {
+	data, err := r.getKubeconfigData(secret)
+	if err != nil {
+		return ""
+	}
+
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+// addFreshnessAnnotations records kubeconfig hash and rotation time on SveltosCluster.
+// Rotation time is only updated when the hash changes.
+func (r *SecretReconciler) addFreshnessAnnotations(sveltosCluster *libsveltosv1alpha1.SveltosCluster, secret *corev1.Secret,
+	now time.Time) {
+
+	hash := r.getKubeconfigHash(secret)
+	if hash == "" || sveltosCluster.Annotations[kubeconfigHashAnnotation] == hash {
+		return
+	}
+
+	if sveltosCluster.Annotations == nil {
+		sveltosCluster.Annotations = make(map[string]string)
+	}
+	sveltosCluster.Annotations[kubeconfigHashAnnotation] = hash
+	sveltosCluster.Annotations[kubeconfigRotatedAtAnnotation] = now.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"gianlucam76/claudie-sveltos-integration/internal/controller"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+)
+
+var _ = Describe("Kubeconfig freshness", func() {
+	It("addFreshnessAnnotations updates rotation time only when kubeconfig changes", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := getSecretReconciler(c)
+
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		sveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+
+		created := time.Now().Add(-time.Hour)
+		controller.AddFreshnessAnnotations(reconciler, sveltosCluster, secret, created)
+		hash := sveltosCluster.Annotations[controller.KubeconfigHashAnnotation]
+		Expect(hash).ToNot(BeEmpty())
+		Expect(sveltosCluster.Annotations[controller.KubeconfigRotatedAtAnnotation]).To(
+			Equal(created.UTC().Format(time.RFC3339)))
+
+		// No-op reconcile
+		controller.AddFreshnessAnnotations(reconciler, sveltosCluster, secret, time.Now())
+		Expect(sveltosCluster.Annotations[controller.KubeconfigHashAnnotation]).To(Equal(hash))
+		Expect(sveltosCluster.Annotations[controller.KubeconfigRotatedAtAnnotation]).To(
+			Equal(created.UTC().Format(time.RFC3339)))
+
+		// Rotation
+		rotated := time.Now()
+		secret.Data[controller.KubeconfigDataKey] = getKubeconfig("cluster-a", "cluster-a")
+		controller.AddFreshnessAnnotations(reconciler, sveltosCluster, secret, rotated)
+		Expect(sveltosCluster.Annotations[controller.KubeconfigHashAnnotation]).ToNot(Equal(hash))
+		Expect(sveltosCluster.Annotations[controller.KubeconfigRotatedAtAnnotation]).To(
+			Equal(rotated.UTC().Format(time.RFC3339)))
+	})
+
+	It("createSveltosCluster stamps kubeconfig freshness on SveltosCluster", func() {
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+		reconciler := getSecretReconciler(c)
+
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+
+		sveltosClusterKey := types.NamespacedName{Namespace: secret.Namespace, Name: secret.Labels[controller.ClaudieCluster]}
+		currentSveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+		Expect(c.Get(context.TODO(), sveltosClusterKey, currentSveltosCluster)).To(Succeed())
+		hash := currentSveltosCluster.Annotations[controller.KubeconfigHashAnnotation]
+		Expect(hash).ToNot(BeEmpty())
+		Expect(currentSveltosCluster.Annotations[controller.KubeconfigRotatedAtAnnotation]).ToNot(BeEmpty())
+
+		// Rotation time is left untouched by no-op reconciles
+		rotatedAt := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+		currentSveltosCluster.Annotations[controller.KubeconfigRotatedAtAnnotation] = rotatedAt
+		Expect(c.Update(context.TODO(), currentSveltosCluster)).To(Succeed())
+
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+		Expect(c.Get(context.TODO(), sveltosClusterKey, currentSveltosCluster)).To(Succeed())
+		Expect(currentSveltosCluster.Annotations[controller.KubeconfigHashAnnotation]).To(Equal(hash))
+		Expect(currentSveltosCluster.Annotations[controller.KubeconfigRotatedAtAnnotation]).To(Equal(rotatedAt))
+
+		// Kubeconfig rotation updates both
+		secret.Data[controller.KubeconfigDataKey] = getKubeconfig("cluster-a", "cluster-a")
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+		Expect(c.Get(context.TODO(), sveltosClusterKey, currentSveltosCluster)).To(Succeed())
+		Expect(currentSveltosCluster.Annotations[controller.KubeconfigHashAnnotation]).ToNot(Equal(hash))
+		Expect(currentSveltosCluster.Annotations[controller.KubeconfigRotatedAtAnnotation]).ToNot(Equal(rotatedAt))
+	})
+})
@@ -0,0 +1,96 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+	logs "github.com/projectsveltos/libsveltos/lib/logsettings"
+)
+
+const (
+	// sveltosClusterSecretMissingAnnotation is set by the stale sweep on a SveltosCluster whose Claudie Secret
+	// was found missing, with the time (RFC3339) it was first found missing. SveltosCluster is deleted only
+	// once Secret is still missing after the stale grace period.
+	sveltosClusterSecretMissingAnnotation = "projectsveltos.io/claudie-secret-missing-since"
+)
+
+// getSecretMissingSince returns when the Claudie Secret of sveltosCluster was first found missing.
+// Zero time is returned if not recorded (or invalid).
+func getSecretMissingSince(sveltosCluster *libsveltosv1alpha1.SveltosCluster) time.Time {
+	value, ok := sveltosCluster.Annotations[sveltosClusterSecretMissingAnnotation]
+	if !ok {
+		return time.Time{}
+	}
+
+	missingSince, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}
+	}
+	return missingSince
+}
+
+// isStaleGracePeriodOver returns true if the Claudie Secret of sveltosCluster has been missing for
+// at least gracePeriod. The first time Secret is found missing, this is recorded on the SveltosCluster
+// and false is returned. A non positive gracePeriod is always over.
+func isStaleGracePeriodOver(ctx context.Context, c client.Client, sveltosCluster *libsveltosv1alpha1.SveltosCluster,
+	gracePeriod time.Duration, now time.Time, logger logr.Logger) bool {
+
+	if gracePeriod <= 0 {
+		return true
+	}
+
+	missingSince := getSecretMissingSince(sveltosCluster)
+	if !missingSince.IsZero() {
+		return !now.Before(missingSince.Add(gracePeriod))
+	}
+
+	logger.V(logs.LogInfo).Info(
+		fmt.Sprintf("Claudie Secret missing. SveltosCluster is removed if still missing in %s", gracePeriod))
+
+	if sveltosCluster.Annotations == nil {
+		sveltosCluster.Annotations = make(map[string]string)
+	}
+	sveltosCluster.Annotations[sveltosClusterSecretMissingAnnotation] = now.UTC().Format(time.RFC3339)
+	if err := c.Update(ctx, sveltosCluster); err != nil {
+		logger.V(logs.LogInfo).Info(fmt.Sprintf("failed to record Claudie Secret is missing: %v", err))
+	}
+
+	return false
+}
+
+// clearSecretMissing removes, if present, the record that the Claudie Secret of sveltosCluster
+// was found missing. Called once the Secret is found again.
+func clearSecretMissing(ctx context.Context, c client.Client, sveltosCluster *libsveltosv1alpha1.SveltosCluster,
+	logger logr.Logger) {
+
+	if _, ok := sveltosCluster.Annotations[sveltosClusterSecretMissingAnnotation]; !ok {
+		return
+	}
+
+	logger.V(logs.LogInfo).Info("Claudie Secret found again")
+	delete(sveltosCluster.Annotations, sveltosClusterSecretMissingAnnotation)
+	if err := c.Update(ctx, sveltosCluster); err != nil {
+		logger.V(logs.LogInfo).Info(fmt.Sprintf("failed to clear Claudie Secret missing record: %v", err))
+	}
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"gianlucam76/claudie-sveltos-integration/internal/controller"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+)
+
+var _ = Describe("Stale grace period", func() {
+	var secret *corev1.Secret
+	var sveltosCluster *libsveltosv1alpha1.SveltosCluster
+	var mapReady chan struct{}
+
+	BeforeEach(func() {
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: randomString(),
+				Name:      randomString(),
+			},
+		}
+
+		sveltosCluster = &libsveltosv1alpha1.SveltosCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: secret.Namespace,
+				Name:      randomString(),
+				Annotations: map[string]string{
+					controller.SveltosClusterClaudieAnnotation: "ok",
+				},
+				OwnerReferences: []metav1.OwnerReference{
+					{Kind: "Secret", APIVersion: "v1", Name: secret.Name},
+				},
+			},
+		}
+
+		mapReady = make(chan struct{})
+		close(mapReady)
+	})
+
+	It("removeStaleSveltosClusters keeps SveltosCluster when Secret returns within grace period", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(sveltosCluster).Build()
+		sveltosClusterKey := client.ObjectKeyFromObject(sveltosCluster)
+
+		// Secret vanishes: time is recorded and SveltosCluster is kept
+		controller.RemoveStaleSveltosClusters(context.TODO(), c, c, controller.DefaultClaudieMetadata, time.Hour, mapReady, logr.Logger{})
+		currentSveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+		Expect(c.Get(context.TODO(), sveltosClusterKey, currentSveltosCluster)).To(Succeed())
+		Expect(currentSveltosCluster.Annotations).To(HaveKey(controller.SveltosClusterSecretMissingAnnotation))
+
+		// Still within grace period
+		controller.RemoveStaleSveltosClusters(context.TODO(), c, c, controller.DefaultClaudieMetadata, time.Hour, mapReady, logr.Logger{})
+		Expect(c.Get(context.TODO(), sveltosClusterKey, currentSveltosCluster)).To(Succeed())
+
+		// Secret returns: record is cleared
+		Expect(c.Create(context.TODO(), secret)).To(Succeed())
+		controller.RemoveStaleSveltosClusters(context.TODO(), c, c, controller.DefaultClaudieMetadata, time.Hour, mapReady, logr.Logger{})
+		Expect(c.Get(context.TODO(), sveltosClusterKey, currentSveltosCluster)).To(Succeed())
+		Expect(currentSveltosCluster.Annotations).ToNot(HaveKey(controller.SveltosClusterSecretMissingAnnotation))
+	})
+
+	It("removeStaleSveltosClusters removes SveltosCluster once Secret is missing past grace period", func() {
+		sveltosCluster.Annotations[controller.SveltosClusterSecretMissingAnnotation] =
+			time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339)
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(sveltosCluster).Build()
+
+		controller.RemoveStaleSveltosClusters(context.TODO(), c, c, controller.DefaultClaudieMetadata, time.Hour, mapReady, logr.Logger{})
+
+		err := c.Get(context.TODO(), client.ObjectKeyFromObject(sveltosCluster), &libsveltosv1alpha1.SveltosCluster{})
+		Expect(err).ToNot(BeNil())
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+})
@@ -0,0 +1,39 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// jitterFactor is the maximum fraction periodic and requeue delays are randomly shortened or
+	// lengthened by, so controller replicas (and Secrets) do not synchronize and spike API server load
+	jitterFactor = 0.1
+)
+
+// jitter returns a random duration within [d*(1-factor), d*(1+factor)]. factor must be in [0, 1).
+func jitter(d time.Duration, factor float64) time.Duration {
+	if d <= 0 || factor <= 0 || factor >= 1 {
+		return d
+	}
+
+	// wait.Jitter only lengthens the duration, so it is applied to the lower bound
+	return wait.Jitter(time.Duration(float64(d)*(1-factor)), 2*factor/(1-factor))
+}
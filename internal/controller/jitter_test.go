@@ -0,0 +1,46 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"gianlucam76/claudie-sveltos-integration/internal/controller"
+)
+
+var _ = Describe("Jitter", func() {
+	It("jitter keeps delays within the jitter factor bounds", func() {
+		base := 10 * time.Second
+		lower := time.Duration(float64(base) * (1 - controller.JitterFactor))
+		upper := time.Duration(float64(base) * (1 + controller.JitterFactor))
+
+		for i := 0; i < 1000; i++ {
+			delay := controller.Jitter(base, controller.JitterFactor)
+			Expect(delay).To(BeNumerically(">=", lower))
+			Expect(delay).To(BeNumerically("<=", upper))
+		}
+	})
+
+	It("jitter returns delay as it is when jitter does not apply", func() {
+		Expect(controller.Jitter(time.Minute, 0)).To(Equal(time.Minute))
+		Expect(controller.Jitter(time.Minute, 1)).To(Equal(time.Minute))
+		Expect(controller.Jitter(0, controller.JitterFactor)).To(BeZero())
+	})
+})
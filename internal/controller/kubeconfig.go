@@ -0,0 +1,356 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+	logs "github.com/projectsveltos/libsveltos/lib/logsettings"
+)
+
+const (
+	// kubeconfigDataKey is the key, in the Claudie Secret, containing the cluster kubeconfig
+	kubeconfigDataKey = "kubeconfig"
+
+	// kubeconfigContextAnnotation can be set on a Claudie Secret to select which context,
+	// within the kubeconfig, Sveltos should use to reach the cluster.
+	kubeconfigContextAnnotation = "projectsveltos.io/claudie-context"
+
+	// kubeconfigServerAnnotation can be set on a Claudie Secret to override the API server URL
+	// in the kubeconfig, for clusters only reachable through a bastion or proxy.
+	kubeconfigServerAnnotation = "projectsveltos.io/claudie-server"
+
+	// sveltosClusterKubeconfigKeyAnnotation is set on SveltosCluster and contains the key, in the
+	// referenced Secret, holding the kubeconfig. It is informational only: SveltosCluster Spec has no
+	// field for the key and Sveltos never reads this annotation. Sveltos reads the kubeconfig from any
+	// key of the Secret data, which so is mirrored when it has more than one key.
+	sveltosClusterKubeconfigKeyAnnotation = "projectsveltos.io/claudie-kubeconfig-key"
+
+	// sveltosClusterEndpointAnnotation is set on SveltosCluster and contains the API server URL of the
+	// cluster, as found in the kubeconfig, so inventories do not need to read the kubeconfig Secret
+	sveltosClusterEndpointAnnotation = "projectsveltos.io/claudie-endpoint"
+
+	// mirroredKubeconfigSuffix is appended to the SveltosCluster name to get the name
+	// of the Secret containing the mirrored (and rewritten) kubeconfig
+	mirroredKubeconfigSuffix = "claudie-kubeconfig"
+)
+
+// getKubeconfigKey returns the key, in the Claudie Secret, containing the kubeconfig.
+// For Claudie Secrets, in order, the configured KubeconfigDataKey (kubeconfigDataKey by default) and
+// the value of the Claudie output label are used when present. Otherwise, if the Secret contains a
+// single key, that is considered the (renamed) kubeconfig key.
+// Returns an empty string if the key cannot be determined.
+func (r *SecretReconciler) getKubeconfigKey(secret *corev1.Secret) string {
+	return r.getSecretClassifier(secret).getKubeconfigKey(secret)
+}
+
+// getKubeconfigData returns the kubeconfig contained in the Claudie Secret
+func (r *SecretReconciler) getKubeconfigData(secret *corev1.Secret) ([]byte, error) {
+	key := r.getKubeconfigKey(secret)
+	if key == "" || len(secret.Data[key]) == 0 {
+		return nil, fmt.Errorf("secret %s/%s does not contain a kubeconfig",
+			secret.Namespace, secret.Name)
+	}
+
+	return secret.Data[key], nil
+}
+
+// hasKubeconfigData returns true if secret contains kubeconfig data. Claudie might label a Secret
+// before filling it, and a SveltosCluster for it would never connect, so such Secrets are skipped
+// till kubeconfig data appears (which triggers a new reconciliation).
+func (r *SecretReconciler) hasKubeconfigData(secret *corev1.Secret, logger logr.Logger) bool {
+	if _, err := r.getKubeconfigData(secret); err == nil {
+		return true
+	}
+
+	key := r.KubeconfigDataKey
+	if key == "" {
+		key = kubeconfigDataKey
+	}
+	msg := fmt.Sprintf("skipping Secret: kubeconfig data key %s is missing or empty", key)
+	logger.V(logs.LogInfo).Info(msg)
+	r.recordEvent(secret, corev1.EventTypeWarning, reasonMissingKubeconfig, msg)
+	return false
+}
+
+// validateKubeconfig verifies the Claudie Secret contains a kubeconfig which can be parsed
+// and which defines at least one cluster and one context
+func (r *SecretReconciler) validateKubeconfig(secret *corev1.Secret) error {
+	kubeconfig, err := r.getKubeconfigData(secret)
+	if err != nil {
+		return err
+	}
+
+	config, err := clientcmd.Load(kubeconfig)
+	if err != nil {
+		return errors.Wrapf(err, "secret %s/%s contains a malformed kubeconfig", secret.Namespace, secret.Name)
+	}
+
+	if len(config.Clusters) == 0 {
+		return fmt.Errorf("kubeconfig in secret %s/%s does not define any cluster", secret.Namespace, secret.Name)
+	}
+
+	if len(config.Contexts) == 0 {
+		return fmt.Errorf("kubeconfig in secret %s/%s does not define any context", secret.Namespace, secret.Name)
+	}
+
+	return nil
+}
+
+// setKubeconfigKeyAnnotation reports on SveltosCluster the key holding the kubeconfig
+func setKubeconfigKeyAnnotation(sveltosCluster *libsveltosv1alpha1.SveltosCluster, key string) {
+	if key == "" {
+		delete(sveltosCluster.Annotations, sveltosClusterKubeconfigKeyAnnotation)
+		return
+	}
+
+	if sveltosCluster.Annotations == nil {
+		sveltosCluster.Annotations = make(map[string]string)
+	}
+	sveltosCluster.Annotations[sveltosClusterKubeconfigKeyAnnotation] = key
+}
+
+// getKubeconfigEndpoint returns the API server URL Sveltos uses to reach the cluster: the server of the
+// cluster referenced by the selected (current by default) context, unless overridden on the Secret.
+func (r *SecretReconciler) getKubeconfigEndpoint(secret *corev1.Secret) (string, error) {
+	if server := secret.Annotations[kubeconfigServerAnnotation]; server != "" {
+		return server, nil
+	}
+
+	kubeconfig, err := r.getKubeconfigData(secret)
+	if err != nil {
+		return "", err
+	}
+
+	config, err := clientcmd.Load(kubeconfig)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse kubeconfig")
+	}
+
+	contextName := secret.Annotations[kubeconfigContextAnnotation]
+	if contextName == "" {
+		contextName = config.CurrentContext
+	}
+
+	kubeContext, ok := config.Contexts[contextName]
+	if !ok {
+		return "", fmt.Errorf("context %q not found in kubeconfig", contextName)
+	}
+	cluster, ok := config.Clusters[kubeContext.Cluster]
+	if !ok {
+		return "", fmt.Errorf("cluster %q not found in kubeconfig", kubeContext.Cluster)
+	}
+
+	return cluster.Server, nil
+}
+
+// addEndpointAnnotation reports on SveltosCluster the API server URL of the cluster. Failing to find
+// it never blocks SveltosCluster reconciliation: annotation is simply removed.
+func (r *SecretReconciler) addEndpointAnnotation(sveltosCluster *libsveltosv1alpha1.SveltosCluster,
+	secret *corev1.Secret, logger logr.Logger) {
+
+	endpoint, err := r.getKubeconfigEndpoint(secret)
+	if err != nil || endpoint == "" {
+		if err != nil {
+			logger.V(logs.LogDebug).Info(fmt.Sprintf("failed to get cluster API server URL: %v", err))
+		}
+		delete(sveltosCluster.Annotations, sveltosClusterEndpointAnnotation)
+		return
+	}
+
+	if sveltosCluster.Annotations == nil {
+		sveltosCluster.Annotations = make(map[string]string)
+	}
+	sveltosCluster.Annotations[sveltosClusterEndpointAnnotation] = endpoint
+}
+
+// getMirroredKubeconfigName returns the name of the Secret the rewritten kubeconfig
+// is mirrored to
+func getMirroredKubeconfigName(sveltosClusterName string) string {
+	return fmt.Sprintf("%s-%s", sveltosClusterName, mirroredKubeconfigSuffix)
+}
+
+// getKubeconfigToMirror returns the kubeconfig Sveltos must use when the one contained
+// in the Claudie Secret cannot be used as is (for instance a context different
+// from the current one was requested, the API server URL must be overridden,
+// SveltosCluster is in a different namespace than the Claudie Secret or the Claudie
+// Secret contains more than one key).
+// Returns nil if Sveltos can directly use the Claudie Secret.
+func (r *SecretReconciler) getKubeconfigToMirror(secret *corev1.Secret) ([]byte, error) {
+	// Sveltos reads the kubeconfig from the SveltosCluster namespace. SveltosCluster Spec has
+	// no field for the kubeconfig key either: Sveltos reads any key of the referenced Secret,
+	// so a Secret with more than one key must be mirrored to a Secret with the kubeconfig only.
+	mirrorAsIs := r.getSveltosClusterNamespace(secret) != secret.Namespace || len(secret.Data) > 1
+
+	contextName := secret.Annotations[kubeconfigContextAnnotation]
+	server := secret.Annotations[kubeconfigServerAnnotation]
+	if contextName == "" && server == "" && !mirrorAsIs {
+		return nil, nil
+	}
+
+	kubeconfig, err := r.getKubeconfigData(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	if contextName == "" && server == "" {
+		return kubeconfig, nil
+	}
+
+	config, err := clientcmd.Load(kubeconfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse kubeconfig")
+	}
+
+	modified := false
+	if contextName != "" {
+		if _, ok := config.Contexts[contextName]; !ok {
+			return nil, fmt.Errorf("context %s not found in kubeconfig", contextName)
+		}
+
+		if config.CurrentContext != contextName {
+			config.CurrentContext = contextName
+			modified = true
+		}
+	}
+
+	if server != "" {
+		if err := validateServerURL(server); err != nil {
+			return nil, err
+		}
+
+		kubeContext, ok := config.Contexts[config.CurrentContext]
+		if !ok {
+			return nil, fmt.Errorf("current context %s not found in kubeconfig", config.CurrentContext)
+		}
+		cluster, ok := config.Clusters[kubeContext.Cluster]
+		if !ok {
+			return nil, fmt.Errorf("cluster %s not found in kubeconfig", kubeContext.Cluster)
+		}
+
+		if cluster.Server != server {
+			cluster.Server = server
+			modified = true
+		}
+	}
+
+	if !modified {
+		if mirrorAsIs {
+			return kubeconfig, nil
+		}
+		return nil, nil
+	}
+
+	return clientcmd.Write(*config)
+}
+
+// validateServerURL verifies server is a valid API server URL
+func validateServerURL(server string) error {
+	u, err := url.Parse(server)
+	if err != nil {
+		return errors.Wrapf(err, "invalid server URL %q", server)
+	}
+
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return fmt.Errorf("invalid server URL %q: scheme must be https or http", server)
+	}
+
+	if u.Host == "" {
+		return fmt.Errorf("invalid server URL %q: host is missing", server)
+	}
+
+	return nil
+}
+
+// mirrorKubeconfig creates (or updates) the Secret, in the SveltosCluster namespace, containing
+// the kubeconfig Sveltos must use. Such Secret is owned by the SveltosCluster.
+func (r *SecretReconciler) mirrorKubeconfig(ctx context.Context, sveltosCluster *libsveltosv1alpha1.SveltosCluster,
+	kubeconfig []byte) error {
+
+	mirror := &corev1.Secret{}
+	err := r.Get(ctx,
+		types.NamespacedName{Namespace: sveltosCluster.Namespace, Name: getMirroredKubeconfigName(sveltosCluster.Name)},
+		mirror)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			mirror.Namespace = sveltosCluster.Namespace
+			mirror.Name = getMirroredKubeconfigName(sveltosCluster.Name)
+			mirror.Data = map[string][]byte{kubeconfigDataKey: kubeconfig}
+			mirror.OwnerReferences = []metav1.OwnerReference{r.getSveltosClusterOwnerReference(sveltosCluster)}
+			return r.Create(ctx, mirror)
+		}
+		return err
+	}
+
+	data := map[string][]byte{kubeconfigDataKey: kubeconfig}
+	if reflect.DeepEqual(mirror.Data, data) {
+		return nil
+	}
+
+	mirror.Data = data
+	return r.Update(ctx, mirror)
+}
+
+// removeMirroredKubeconfig deletes, if present, the Secret containing the mirrored kubeconfig
+// for the SveltosCluster. Mirrored Secret is owned by the SveltosCluster, so this is also
+// an explicit fallback to garbage collection when SveltosCluster is deleted.
+func removeMirroredKubeconfig(ctx context.Context, c client.Client, sveltosCluster types.NamespacedName) error {
+	mirror := &corev1.Secret{}
+	err := c.Get(ctx,
+		types.NamespacedName{Namespace: sveltosCluster.Namespace, Name: getMirroredKubeconfigName(sveltosCluster.Name)},
+		mirror)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	// Never remove a Secret which was not created by this controller
+	if !isOwnedBySveltosCluster(mirror, sveltosCluster.Name) {
+		return nil
+	}
+
+	return client.IgnoreNotFound(c.Delete(ctx, mirror))
+}
+
+// isOwnedBySveltosCluster returns true if object is owned by the SveltosCluster with given name
+func isOwnedBySveltosCluster(object client.Object, sveltosClusterName string) bool {
+	ownerReferences := object.GetOwnerReferences()
+	for i := range ownerReferences {
+		if ownerReferences[i].Kind == libsveltosv1alpha1.SveltosClusterKind &&
+			ownerReferences[i].Name == sveltosClusterName {
+
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,514 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/client-go/tools/record"
+
+	"gianlucam76/claudie-sveltos-integration/internal/controller"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+)
+
+var _ = Describe("Kubeconfig", func() {
+	It("getKubeconfigToMirror returns nil when no context is requested", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := getSecretReconciler(c)
+
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a", "cluster-b")
+
+		kubeconfig, err := controller.GetKubeconfigToMirror(reconciler, secret)
+		Expect(err).To(BeNil())
+		Expect(kubeconfig).To(BeNil())
+
+		// Requested context is already the current one
+		secret.Annotations = map[string]string{controller.KubeconfigContextAnnotation: "cluster-a"}
+		kubeconfig, err = controller.GetKubeconfigToMirror(reconciler, secret)
+		Expect(err).To(BeNil())
+		Expect(kubeconfig).To(BeNil())
+	})
+
+	It("createSveltosCluster mirrors kubeconfig with selected context", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := getSecretReconciler(c)
+
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a", "cluster-b")
+		secret.Annotations = map[string]string{controller.KubeconfigContextAnnotation: "cluster-b"}
+
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+
+		sveltosClusterName := secret.Labels[controller.ClaudieCluster]
+		currentSveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+		Expect(c.Get(context.TODO(),
+			types.NamespacedName{Namespace: secret.Namespace, Name: sveltosClusterName},
+			currentSveltosCluster)).To(Succeed())
+		Expect(currentSveltosCluster.Spec.KubeconfigName).To(Equal(controller.GetMirroredKubeconfigName(sveltosClusterName)))
+
+		mirror := &corev1.Secret{}
+		Expect(c.Get(context.TODO(),
+			types.NamespacedName{Namespace: secret.Namespace, Name: controller.GetMirroredKubeconfigName(sveltosClusterName)},
+			mirror)).To(Succeed())
+		config, err := clientcmd.Load(mirror.Data[controller.KubeconfigDataKey])
+		Expect(err).To(BeNil())
+		Expect(config.CurrentContext).To(Equal("cluster-b"))
+		Expect(len(mirror.OwnerReferences)).To(Equal(1))
+		Expect(mirror.OwnerReferences[0].Name).To(Equal(sveltosClusterName))
+
+		// Selecting back the current context removes the mirrored kubeconfig
+		secret.Annotations = map[string]string{controller.KubeconfigContextAnnotation: "cluster-a"}
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+		Expect(c.Get(context.TODO(),
+			types.NamespacedName{Namespace: secret.Namespace, Name: sveltosClusterName},
+			currentSveltosCluster)).To(Succeed())
+		Expect(currentSveltosCluster.Spec.KubeconfigName).To(Equal(secret.Name))
+
+		err = c.Get(context.TODO(),
+			types.NamespacedName{Namespace: secret.Namespace, Name: controller.GetMirroredKubeconfigName(sveltosClusterName)},
+			mirror)
+		Expect(err).ToNot(BeNil())
+	})
+
+	It("createSveltosCluster fails when selected context does not exist", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := getSecretReconciler(c)
+
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		secret.Annotations = map[string]string{controller.KubeconfigContextAnnotation: randomString()}
+
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).ToNot(Succeed())
+
+		currentSveltosClusters := &libsveltosv1alpha1.SveltosClusterList{}
+		Expect(c.List(context.TODO(), currentSveltosClusters)).To(Succeed())
+		Expect(len(currentSveltosClusters.Items)).To(Equal(0))
+	})
+
+	It("createSveltosCluster mirrors kubeconfig with overridden server URL", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := getSecretReconciler(c)
+
+		const server = "https://bastion.example.com:8443"
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a", "cluster-b")
+		secret.Annotations = map[string]string{
+			controller.KubeconfigContextAnnotation: "cluster-b",
+			controller.KubeconfigServerAnnotation:  server,
+		}
+
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+
+		sveltosClusterName := secret.Labels[controller.ClaudieCluster]
+		mirror := &corev1.Secret{}
+		Expect(c.Get(context.TODO(),
+			types.NamespacedName{Namespace: secret.Namespace, Name: controller.GetMirroredKubeconfigName(sveltosClusterName)},
+			mirror)).To(Succeed())
+		config, err := clientcmd.Load(mirror.Data[controller.KubeconfigDataKey])
+		Expect(err).To(BeNil())
+		Expect(config.CurrentContext).To(Equal("cluster-b"))
+		Expect(config.Clusters["cluster-b"].Server).To(Equal(server))
+		// Only the cluster referenced by the selected context is modified
+		Expect(config.Clusters["cluster-a"].Server).To(Equal("https://cluster-a.example.com:6443"))
+	})
+
+	It("getKubeconfigToMirror overrides server URL of current context and validates it", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := getSecretReconciler(c)
+
+		const server = "https://10.0.0.1:6443"
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		secret.Annotations = map[string]string{controller.KubeconfigServerAnnotation: server}
+
+		kubeconfig, err := controller.GetKubeconfigToMirror(reconciler, secret)
+		Expect(err).To(BeNil())
+		config, err := clientcmd.Load(kubeconfig)
+		Expect(err).To(BeNil())
+		Expect(config.Clusters["cluster-a"].Server).To(Equal(server))
+
+		// Same server as the kubeconfig one: no need to mirror
+		secret.Annotations[controller.KubeconfigServerAnnotation] = "https://cluster-a.example.com:6443"
+		kubeconfig, err = controller.GetKubeconfigToMirror(reconciler, secret)
+		Expect(err).To(BeNil())
+		Expect(kubeconfig).To(BeNil())
+
+		for _, invalid := range []string{"10.0.0.1:6443", "ftp://10.0.0.1", "https://", "://bad"} {
+			secret.Annotations[controller.KubeconfigServerAnnotation] = invalid
+			_, err = controller.GetKubeconfigToMirror(reconciler, secret)
+			Expect(err).ToNot(BeNil())
+		}
+	})
+
+	It("getKubeconfigKey returns the key containing the kubeconfig", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := getSecretReconciler(c)
+
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		Expect(controller.GetKubeconfigKey(reconciler, secret)).To(Equal(controller.KubeconfigDataKey))
+
+		// Renamed key
+		kubeconfig := secret.Data[controller.KubeconfigDataKey]
+		secret.Data = map[string][]byte{"value": kubeconfig}
+		Expect(controller.GetKubeconfigKey(reconciler, secret)).To(Equal("value"))
+
+		// Ambiguous
+		secret.Data[randomString()] = []byte(randomString())
+		Expect(controller.GetKubeconfigKey(reconciler, secret)).To(BeEmpty())
+
+		// Key referenced by the Claudie output label
+		secret.Labels[controller.ClaudieKubeconfig] = "value"
+		Expect(controller.GetKubeconfigKey(reconciler, secret)).To(Equal("value"))
+
+		// Configured key
+		reconciler.KubeconfigDataKey = "config"
+		secret.Data["config"] = kubeconfig
+		Expect(controller.GetKubeconfigKey(reconciler, secret)).To(Equal("config"))
+	})
+
+	It("validateKubeconfig rejects missing, malformed and incomplete kubeconfigs", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := getSecretReconciler(c)
+
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		Expect(controller.ValidateKubeconfig(reconciler, secret)).To(Succeed())
+
+		secret.Data[controller.KubeconfigDataKey] = nil
+		Expect(controller.ValidateKubeconfig(reconciler, secret)).ToNot(Succeed())
+
+		secret.Data[controller.KubeconfigDataKey] = []byte("{not a kubeconfig")
+		Expect(controller.ValidateKubeconfig(reconciler, secret)).ToNot(Succeed())
+
+		// No cluster nor context
+		secret.Data[controller.KubeconfigDataKey] = getKubeconfig("")
+		err := controller.ValidateKubeconfig(reconciler, secret)
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(ContainSubstring("cluster"))
+	})
+
+	It("Reconcile does not create SveltosCluster for a Secret with an invalid kubeconfig", func() {
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		secret.Data[controller.KubeconfigDataKey] = []byte(randomString())
+		Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+		reconciler := getSecretReconciler(c)
+
+		secretRef := reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name},
+		}
+		_, err := reconciler.Reconcile(context.TODO(), secretRef)
+		Expect(err).ToNot(BeNil())
+
+		currentSveltosClusters := &libsveltosv1alpha1.SveltosClusterList{}
+		Expect(c.List(context.TODO(), currentSveltosClusters)).To(Succeed())
+		Expect(currentSveltosClusters.Items).To(BeEmpty())
+	})
+
+	DescribeTable("Reconcile skips labeled Secrets without kubeconfig data till it appears",
+		func(data map[string][]byte) {
+			secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+			kubeconfig := secret.Data[controller.KubeconfigDataKey]
+			secret.Data = data
+			Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+			reconciler := getSecretReconciler(c)
+			recorder := record.NewFakeRecorder(10)
+			reconciler.EventRecorder = recorder
+
+			secretRef := reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name},
+			}
+			result, err := reconciler.Reconcile(context.TODO(), secretRef)
+			Expect(err).To(BeNil())
+			Expect(result).To(Equal(reconcile.Result{}))
+			Expect(recorder.Events).To(Receive(And(HavePrefix(corev1.EventTypeWarning),
+				ContainSubstring(controller.ReasonMissingKubeconfig))))
+
+			currentSveltosClusters := &libsveltosv1alpha1.SveltosClusterList{}
+			Expect(c.List(context.TODO(), currentSveltosClusters)).To(Succeed())
+			Expect(currentSveltosClusters.Items).To(BeEmpty())
+
+			// Once kubeconfig data appears, SveltosCluster is created
+			Expect(c.Get(context.TODO(), secretRef.NamespacedName, secret)).To(Succeed())
+			if secret.Data == nil {
+				secret.Data = map[string][]byte{}
+			}
+			secret.Data[controller.KubeconfigDataKey] = kubeconfig
+			Expect(c.Update(context.TODO(), secret)).To(Succeed())
+
+			_, err = reconciler.Reconcile(context.TODO(), secretRef)
+			Expect(err).To(BeNil())
+			Expect(c.List(context.TODO(), currentSveltosClusters)).To(Succeed())
+			Expect(currentSveltosClusters.Items).To(HaveLen(1))
+		},
+		Entry("no data", nil),
+		Entry("empty kubeconfig", map[string][]byte{controller.KubeconfigDataKey: {}}),
+		Entry("kubeconfig key missing", map[string][]byte{"ca.crt": []byte(randomString()), "token": []byte(randomString())}),
+	)
+
+	It("createSveltosCluster updates kubeconfig key in place when kubeconfig key is renamed", func() {
+		creates := 0
+		deletes := 0
+		c := fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(interceptor.Funcs{
+			Create: func(ctx context.Context, wc client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				creates++
+				return wc.Create(ctx, obj, opts...)
+			},
+			Delete: func(ctx context.Context, wc client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+				deletes++
+				return wc.Delete(ctx, obj, opts...)
+			},
+		}).Build()
+		reconciler := getSecretReconciler(c)
+
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+
+		sveltosClusterKey := types.NamespacedName{Namespace: secret.Namespace, Name: secret.Labels[controller.ClaudieCluster]}
+		currentSveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+		Expect(c.Get(context.TODO(), sveltosClusterKey, currentSveltosCluster)).To(Succeed())
+		Expect(currentSveltosCluster.Annotations[controller.SveltosClusterKubeconfigKeyAnnotation]).To(
+			Equal(controller.KubeconfigDataKey))
+
+		secret.Data = map[string][]byte{"value": secret.Data[controller.KubeconfigDataKey]}
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+
+		Expect(c.Get(context.TODO(), sveltosClusterKey, currentSveltosCluster)).To(Succeed())
+		Expect(currentSveltosCluster.Annotations[controller.SveltosClusterKubeconfigKeyAnnotation]).To(Equal("value"))
+		Expect(currentSveltosCluster.Spec.KubeconfigName).To(Equal(secret.Name))
+		Expect(creates).To(Equal(1))
+		Expect(deletes).To(Equal(0))
+	})
+
+	It("createSveltosCluster points Sveltos to the kubeconfig for default and custom data keys", func() {
+		// Default key: Sveltos reads Claudie Secret directly
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+		reconciler := getSecretReconciler(c)
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+
+		sveltosClusterKey := types.NamespacedName{Namespace: secret.Namespace, Name: secret.Labels[controller.ClaudieCluster]}
+		currentSveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+		Expect(c.Get(context.TODO(), sveltosClusterKey, currentSveltosCluster)).To(Succeed())
+		Expect(currentSveltosCluster.Spec.KubeconfigName).To(Equal(secret.Name))
+		Expect(currentSveltosCluster.Annotations[controller.SveltosClusterKubeconfigKeyAnnotation]).To(
+			Equal(controller.KubeconfigDataKey))
+
+		// Custom key, referenced by Claudie output label, next to other keys: kubeconfig is mirrored
+		// to a Secret containing the kubeconfig only
+		secret = getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		kubeconfig := secret.Data[controller.KubeconfigDataKey]
+		secret.Labels[controller.ClaudieKubeconfig] = "output"
+		secret.Data = map[string][]byte{"output": kubeconfig, "metadata": []byte(randomString())}
+		c = fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+		reconciler = getSecretReconciler(c)
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+
+		sveltosClusterKey = types.NamespacedName{Namespace: secret.Namespace, Name: secret.Labels[controller.ClaudieCluster]}
+		Expect(c.Get(context.TODO(), sveltosClusterKey, currentSveltosCluster)).To(Succeed())
+		Expect(currentSveltosCluster.Spec.KubeconfigName).To(Equal(
+			controller.GetMirroredKubeconfigName(currentSveltosCluster.Name)))
+		Expect(currentSveltosCluster.Annotations[controller.SveltosClusterKubeconfigKeyAnnotation]).To(
+			Equal(controller.KubeconfigDataKey))
+
+		mirror := &corev1.Secret{}
+		Expect(c.Get(context.TODO(), types.NamespacedName{Namespace: secret.Namespace,
+			Name: currentSveltosCluster.Spec.KubeconfigName}, mirror)).To(Succeed())
+		Expect(mirror.Data).To(HaveLen(1))
+		Expect(mirror.Data[controller.KubeconfigDataKey]).To(Equal(kubeconfig))
+	})
+
+	It("createSveltosCluster reports cluster API server URL of the selected context", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := getSecretReconciler(c)
+
+		secret := getClaudieSecretWithKubeconfig("cluster-b", "cluster-a", "cluster-b")
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+
+		sveltosClusterKey := types.NamespacedName{Namespace: secret.Namespace, Name: secret.Labels[controller.ClaudieCluster]}
+		currentSveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+		Expect(c.Get(context.TODO(), sveltosClusterKey, currentSveltosCluster)).To(Succeed())
+		Expect(currentSveltosCluster.Annotations).To(HaveKeyWithValue(controller.SveltosClusterEndpointAnnotation,
+			"https://cluster-b.example.com:6443"))
+
+		// Selected context wins over current one
+		secret.Annotations = map[string]string{controller.KubeconfigContextAnnotation: "cluster-a"}
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+		Expect(c.Get(context.TODO(), sveltosClusterKey, currentSveltosCluster)).To(Succeed())
+		Expect(currentSveltosCluster.Annotations).To(HaveKeyWithValue(controller.SveltosClusterEndpointAnnotation,
+			"https://cluster-a.example.com:6443"))
+
+		// Overridden server URL wins over kubeconfig
+		secret.Annotations[controller.KubeconfigServerAnnotation] = "https://proxy.example.com:443"
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+		Expect(c.Get(context.TODO(), sveltosClusterKey, currentSveltosCluster)).To(Succeed())
+		Expect(currentSveltosCluster.Annotations).To(HaveKeyWithValue(controller.SveltosClusterEndpointAnnotation,
+			"https://proxy.example.com:443"))
+	})
+
+	It("createSveltosCluster creates SveltosCluster when API server URL cannot be found", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := getSecretReconciler(c)
+
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		secret.Data[controller.KubeconfigDataKey] = []byte("not a kubeconfig")
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+
+		sveltosClusterKey := types.NamespacedName{Namespace: secret.Namespace, Name: secret.Labels[controller.ClaudieCluster]}
+		currentSveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+		Expect(c.Get(context.TODO(), sveltosClusterKey, currentSveltosCluster)).To(Succeed())
+		Expect(currentSveltosCluster.Annotations).ToNot(HaveKey(controller.SveltosClusterEndpointAnnotation))
+	})
+
+	It("cleanSveltosCluster removes mirrored kubeconfig", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := getSecretReconciler(c)
+
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a", "cluster-b")
+		secret.Annotations = map[string]string{controller.KubeconfigContextAnnotation: "cluster-b"}
+
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+
+		mirrorKey := types.NamespacedName{
+			Namespace: secret.Namespace,
+			Name:      controller.GetMirroredKubeconfigName(secret.Labels[controller.ClaudieCluster]),
+		}
+		mirror := &corev1.Secret{}
+		Expect(c.Get(context.TODO(), mirrorKey, mirror)).To(Succeed())
+
+		secretRef := reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name},
+		}
+		Expect(controller.CleanSveltosCluster(reconciler, context.TODO(), secretRef, logr.Logger{})).To(Succeed())
+
+		err := c.Get(context.TODO(), mirrorKey, mirror)
+		Expect(err).ToNot(BeNil())
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("removeStaleSveltosClusters removes mirrored kubeconfig", func() {
+		sveltosCluster := &libsveltosv1alpha1.SveltosCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: randomString(),
+				Name:      randomString(),
+				Annotations: map[string]string{
+					controller.SveltosClusterClaudieAnnotation: "ok",
+				},
+				OwnerReferences: []metav1.OwnerReference{
+					{
+						Kind:       "Secret",
+						APIVersion: "v1",
+						Name:       randomString(),
+					},
+				},
+			},
+		}
+
+		mirror := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: sveltosCluster.Namespace,
+				Name:      controller.GetMirroredKubeconfigName(sveltosCluster.Name),
+				OwnerReferences: []metav1.OwnerReference{
+					{
+						Kind:       libsveltosv1alpha1.SveltosClusterKind,
+						APIVersion: libsveltosv1alpha1.GroupVersion.String(),
+						Name:       sveltosCluster.Name,
+					},
+				},
+			},
+		}
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(sveltosCluster, mirror).Build()
+
+		mapReady := make(chan struct{})
+		close(mapReady)
+		controller.RemoveStaleSveltosClusters(context.TODO(), c, c, controller.DefaultClaudieMetadata, 0, mapReady, logr.Logger{})
+
+		err := c.Get(context.TODO(), types.NamespacedName{Namespace: mirror.Namespace, Name: mirror.Name}, mirror)
+		Expect(err).ToNot(BeNil())
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("removeMirroredKubeconfig does not remove Secrets not owned by the SveltosCluster", func() {
+		sveltosClusterName := randomString()
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: randomString(),
+				Name:      controller.GetMirroredKubeconfigName(sveltosClusterName),
+			},
+		}
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+		Expect(controller.RemoveMirroredKubeconfig(context.TODO(), c,
+			types.NamespacedName{Namespace: secret.Namespace, Name: sveltosClusterName})).To(Succeed())
+
+		Expect(c.Get(context.TODO(), types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name}, secret)).To(Succeed())
+	})
+})
+
+// getClaudieSecretWithKubeconfig returns a Claudie Secret containing a kubeconfig with
+// one cluster/context for each of the contexts passed.
+func getClaudieSecretWithKubeconfig(currentContext string, contexts ...string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: randomString(),
+			Name:      randomString(),
+			Labels: map[string]string{
+				controller.ClaudieLabel:      "claudie",
+				controller.ClaudieKubeconfig: "kubeconfig",
+				controller.ClaudieCluster:    randomString(),
+			},
+		},
+		Data: map[string][]byte{
+			controller.KubeconfigDataKey: getKubeconfig(currentContext, contexts...),
+		},
+	}
+}
+
+func getKubeconfig(currentContext string, contexts ...string) []byte {
+	config := clientcmdapi.NewConfig()
+	for i := range contexts {
+		config.Clusters[contexts[i]] = &clientcmdapi.Cluster{
+			Server: "https://" + contexts[i] + ".example.com:6443",
+		}
+		config.AuthInfos[contexts[i]] = &clientcmdapi.AuthInfo{
+			Token: randomString(),
+		}
+		config.Contexts[contexts[i]] = &clientcmdapi.Context{
+			Cluster:  contexts[i],
+			AuthInfo: contexts[i],
+		}
+	}
+	config.CurrentContext = currentContext
+
+	data, err := clientcmd.Write(*config)
+	Expect(err).To(BeNil())
+	return data
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// kubeconfigSecretRefKey is the key, in a Claudie Secret, containing the name of the Secret, in the
+	// same namespace, holding the cluster kubeconfig. Such a Claudie Secret is only a pointer.
+	kubeconfigSecretRefKey = "kubeconfigSecretRef"
+)
+
+var (
+	// errKubeconfigSecretNotFound is returned when the Secret referenced by a Claudie Secret does not exist
+	errKubeconfigSecretNotFound = errors.New("referenced kubeconfig Secret not found")
+)
+
+// resolveKubeconfig returns the Secret view kubeconfig is read from, and the name of the Secret Sveltos
+// must use. Unless secret references a different Secret with the kubeconfigSecretRef key, those are secret
+// itself and its name. Otherwise the view is a copy of secret carrying the data of the referenced Secret,
+// so all other metadata (e.g. context and server annotations) still comes from the Claudie Secret.
+func (r *SecretReconciler) resolveKubeconfig(ctx context.Context, secret *corev1.Secret,
+) (*corev1.Secret, string, error) {
+
+	ref, ok := secret.Data[kubeconfigSecretRefKey]
+	if !ok {
+		return secret, secret.Name, nil
+	}
+
+	name := strings.TrimSpace(string(ref))
+	if name == "" || name == secret.Name {
+		return nil, "", fmt.Errorf("secret %s/%s contains an invalid %s %q",
+			secret.Namespace, secret.Name, kubeconfigSecretRefKey, name)
+	}
+
+	referenced := &corev1.Secret{}
+	err := r.Get(ctx, types.NamespacedName{Namespace: secret.Namespace, Name: name}, referenced)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, "", errors.Wrapf(errKubeconfigSecretNotFound, "secret %s/%s", secret.Namespace, name)
+		}
+		return nil, "", err
+	}
+
+	kubeconfigSecret := secret.DeepCopy()
+	kubeconfigSecret.Data = referenced.Data
+	return kubeconfigSecret, referenced.Name, nil
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"gianlucam76/claudie-sveltos-integration/internal/controller"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+)
+
+var _ = Describe("Kubeconfig Secret reference", func() {
+	var pointer *corev1.Secret
+	var kubeconfigSecret *corev1.Secret
+
+	BeforeEach(func() {
+		kubeconfigSecret = getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		kubeconfigSecret.Labels = nil
+		Expect(addTypeInformationToObject(scheme, kubeconfigSecret)).To(Succeed())
+
+		pointer = getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		pointer.Namespace = kubeconfigSecret.Namespace
+		pointer.Data = map[string][]byte{
+			controller.KubeconfigSecretRefKey: []byte(kubeconfigSecret.Name),
+		}
+		Expect(addTypeInformationToObject(scheme, pointer)).To(Succeed())
+	})
+
+	It("Reconcile uses the kubeconfig of the referenced Secret", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(pointer, kubeconfigSecret).Build()
+		reconciler := getSecretReconciler(c)
+
+		_, err := reconciler.Reconcile(context.TODO(), reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: pointer.Namespace, Name: pointer.Name},
+		})
+		Expect(err).To(BeNil())
+
+		sveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+		Expect(c.Get(context.TODO(),
+			types.NamespacedName{Namespace: pointer.Namespace, Name: pointer.Labels[controller.ClaudieCluster]},
+			sveltosCluster)).To(Succeed())
+		Expect(sveltosCluster.Spec.KubeconfigName).To(Equal(kubeconfigSecret.Name))
+		Expect(sveltosCluster.Annotations[controller.SveltosClusterEndpointAnnotation]).To(
+			Equal("https://cluster-a.example.com:6443"))
+
+		// SveltosCluster is still owned by the Claudie Secret
+		Expect(sveltosCluster.OwnerReferences).To(HaveLen(1))
+		Expect(sveltosCluster.OwnerReferences[0].Name).To(Equal(pointer.Name))
+	})
+
+	It("Reconcile requeues till the referenced Secret exists", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(pointer).Build()
+		reconciler := getSecretReconciler(c)
+
+		secretRef := reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: pointer.Namespace, Name: pointer.Name},
+		}
+		result, err := reconciler.Reconcile(context.TODO(), secretRef)
+		Expect(err).To(BeNil())
+		Expect(result.RequeueAfter).ToNot(BeZero())
+
+		currentSveltosClusters := &libsveltosv1alpha1.SveltosClusterList{}
+		Expect(c.List(context.TODO(), currentSveltosClusters)).To(Succeed())
+		Expect(currentSveltosClusters.Items).To(BeEmpty())
+
+		Expect(c.Create(context.TODO(), kubeconfigSecret)).To(Succeed())
+
+		_, err = reconciler.Reconcile(context.TODO(), secretRef)
+		Expect(err).To(BeNil())
+		Expect(c.List(context.TODO(), currentSveltosClusters)).To(Succeed())
+		Expect(currentSveltosClusters.Items).To(HaveLen(1))
+		Expect(currentSveltosClusters.Items[0].Spec.KubeconfigName).To(Equal(kubeconfigSecret.Name))
+	})
+
+	It("resolveKubeconfig never reads Secrets in other namespaces", func() {
+		otherNamespace := kubeconfigSecret.DeepCopy()
+		otherNamespace.Namespace = randomString()
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(pointer, otherNamespace).Build()
+		reconciler := getSecretReconciler(c)
+
+		_, _, err := controller.ResolveKubeconfig(reconciler, context.TODO(), pointer)
+		Expect(err).ToNot(BeNil())
+
+		// Claudie Secrets without the reference are used as they are
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		view, name, err := controller.ResolveKubeconfig(reconciler, context.TODO(), secret)
+		Expect(err).To(BeNil())
+		Expect(view).To(Equal(secret))
+		Expect(name).To(Equal(secret.Name))
+	})
+})
@@ -0,0 +1,72 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+	logs "github.com/projectsveltos/libsveltos/lib/logsettings"
+)
+
+// ParseLabelMapping parses entries in the form annotationKey=labelKey (e.g.
+// claudie.io/provider=topology.kubernetes.io/region) into a map annotation key -> SveltosCluster label key
+func ParseLabelMapping(entries []string) (map[string]string, error) {
+	mapping := make(map[string]string, len(entries))
+	for i := range entries {
+		annotation, label, found := strings.Cut(entries[i], "=")
+		if !found || annotation == "" || label == "" {
+			return nil, fmt.Errorf("invalid mapping %q: expected annotationKey=labelKey", entries[i])
+		}
+		if errs := validation.IsQualifiedName(label); len(errs) != 0 {
+			return nil, fmt.Errorf("invalid label key %q: %s", label, strings.Join(errs, ", "))
+		}
+		mapping[annotation] = label
+	}
+
+	return mapping, nil
+}
+
+// applyLabelMapping sets, for each annotation in LabelMapping present on the Secret, the corresponding
+// SveltosCluster label. Sveltos matches add-ons on labels, so only mapped labels are set and labels are
+// never removed. Annotation values which are not valid label values are skipped.
+func (r *SecretReconciler) applyLabelMapping(sveltosCluster *libsveltosv1alpha1.SveltosCluster, secret *corev1.Secret,
+	logger logr.Logger) {
+
+	for annotation, label := range r.LabelMapping {
+		value, ok := secret.Annotations[annotation]
+		if !ok {
+			continue
+		}
+
+		if errs := validation.IsValidLabelValue(value); len(errs) != 0 {
+			logger.V(logs.LogInfo).Info(fmt.Sprintf("skipping mapping of annotation %s to label %s: %s",
+				annotation, label, strings.Join(errs, ", ")))
+			continue
+		}
+
+		if sveltosCluster.Labels == nil {
+			sveltosCluster.Labels = make(map[string]string)
+		}
+		sveltosCluster.Labels[label] = value
+	}
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"gianlucam76/claudie-sveltos-integration/internal/controller"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+)
+
+const (
+	providerAnnotation = "claudie.io/provider"
+	regionLabel        = "topology.kubernetes.io/region"
+)
+
+var _ = Describe("Label mapping", func() {
+	It("ParseLabelMapping parses annotationKey=labelKey entries", func() {
+		mapping, err := controller.ParseLabelMapping([]string{providerAnnotation + "=" + regionLabel})
+		Expect(err).To(BeNil())
+		Expect(mapping).To(Equal(map[string]string{providerAnnotation: regionLabel}))
+
+		_, err = controller.ParseLabelMapping([]string{providerAnnotation})
+		Expect(err).ToNot(BeNil())
+
+		_, err = controller.ParseLabelMapping([]string{"=" + regionLabel})
+		Expect(err).ToNot(BeNil())
+
+		_, err = controller.ParseLabelMapping([]string{providerAnnotation + "=not a label"})
+		Expect(err).ToNot(BeNil())
+	})
+
+	It("mapped labels are applied on create and preserved on update", func() {
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		secret.Annotations = map[string]string{
+			providerAnnotation:       "hetzner",
+			"claudie.io/description": "not a valid label value",
+		}
+		Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+		reconciler := getSecretReconciler(c)
+		reconciler.LabelMapping = map[string]string{
+			providerAnnotation:       regionLabel,
+			"claudie.io/description": "description",
+		}
+
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+
+		sveltosClusterKey := types.NamespacedName{Namespace: secret.Namespace, Name: secret.Labels[controller.ClaudieCluster]}
+		sveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+		Expect(c.Get(context.TODO(), sveltosClusterKey, sveltosCluster)).To(Succeed())
+		Expect(sveltosCluster.Labels).To(Equal(map[string]string{regionLabel: "hetzner"}))
+
+		// Labels set by users are preserved, mapped ones follow the annotation
+		sveltosCluster.Labels["env"] = "production"
+		Expect(c.Update(context.TODO(), sveltosCluster)).To(Succeed())
+		secret.Annotations[providerAnnotation] = "aws"
+
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+		Expect(c.Get(context.TODO(), sveltosClusterKey, sveltosCluster)).To(Succeed())
+		Expect(sveltosCluster.Labels).To(Equal(map[string]string{regionLabel: "aws", "env": "production"}))
+
+		// Removing the annotation never removes the label
+		delete(secret.Annotations, providerAnnotation)
+
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+		Expect(c.Get(context.TODO(), sveltosClusterKey, sveltosCluster)).To(Succeed())
+		Expect(sveltosCluster.Labels).To(HaveKeyWithValue(regionLabel, "aws"))
+	})
+})
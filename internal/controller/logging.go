@@ -0,0 +1,56 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Keys of the structured fields identifying, in every log line, what is being reconciled
+const (
+	logKeySecret         = "secret"
+	logKeySveltosCluster = "sveltosCluster"
+	logKeyClaudieCluster = "claudieCluster"
+)
+
+// getSecretLogger returns logger enriched with the identity of the Claudie Secret, of its
+// SveltosCluster and of the Claudie cluster
+func (r *SecretReconciler) getSecretLogger(logger logr.Logger, secret *corev1.Secret) logr.Logger {
+	sveltosClusterKey := types.NamespacedName{
+		Namespace: r.getSveltosClusterNamespace(secret),
+		Name:      r.getSveltosClusterName(secret),
+	}
+
+	return logger.WithValues(
+		logKeySecret, types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name}.String(),
+		logKeySveltosCluster, sveltosClusterKey.String(),
+		logKeyClaudieCluster, r.getClusterName(secret))
+}
+
+// getRemovedSecretLogger returns logger enriched with the identity of a Claudie Secret which does not
+// exist anymore and, if tracked, of its SveltosCluster
+func (r *SecretReconciler) getRemovedSecretLogger(logger logr.Logger, secretKey types.NamespacedName) logr.Logger {
+	sveltosClusterKey, tracked := r.secretToCluster.Get(secretKey)
+
+	logger = logger.WithValues(logKeySecret, secretKey.String())
+	if tracked {
+		logger = logger.WithValues(logKeySveltosCluster, sveltosClusterKey.String())
+	}
+	return logger
+}
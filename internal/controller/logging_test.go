@@ -0,0 +1,68 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr/funcr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"gianlucam76/claudie-sveltos-integration/internal/controller"
+)
+
+var _ = Describe("Logging", func() {
+	It("Reconcile attaches Secret, SveltosCluster and Claudie cluster identity to every log line", func() {
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+		reconciler := getSecretReconciler(c)
+
+		var lines []string
+		logger := funcr.New(func(prefix, args string) {
+			lines = append(lines, args)
+		}, funcr.Options{Verbosity: 10})
+		ctx := ctrl.LoggerInto(context.TODO(), logger)
+
+		secretKey := types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name}
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: secretKey})
+		Expect(err).To(BeNil())
+
+		secretField := fmt.Sprintf("%q=%q", "secret", secretKey.String())
+		sveltosClusterField := fmt.Sprintf("%q=%q", "sveltosCluster",
+			types.NamespacedName{Namespace: secret.Namespace, Name: secret.Labels[controller.ClaudieCluster]}.String())
+		claudieClusterField := fmt.Sprintf("%q=%q", "claudieCluster", secret.Labels[controller.ClaudieCluster])
+
+		Expect(lines).ToNot(BeEmpty())
+		for i := range lines {
+			Expect(lines[i]).To(ContainSubstring(secretField))
+			// Once Secret is fetched, SveltosCluster and Claudie cluster are known
+			if !strings.Contains(lines[i], `"msg"="Reconciling"`) {
+				Expect(lines[i]).To(ContainSubstring(sveltosClusterField))
+				Expect(lines[i]).To(ContainSubstring(claudieClusterField))
+			}
+		}
+	})
+})
@@ -0,0 +1,65 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+)
+
+const (
+	// claudieMarkerLegacyValue is the value of the Claudie annotation set by previous versions of
+	// this controller (and still used when the Secret UID is not known). It carries no provenance.
+	claudieMarkerLegacyValue = "ok"
+
+	// claudieMarkerV2Prefix prefixes the UID of the Claudie Secret a SveltosCluster was created for
+	// in the Claudie annotation value (e.g. v2:<Secret UID>)
+	claudieMarkerV2Prefix = "v2:"
+)
+
+// getClaudieMarker returns the value of the Claudie annotation for a SveltosCluster created for secret
+func getClaudieMarker(secret *corev1.Secret) string {
+	if secret == nil || secret.UID == "" {
+		return claudieMarkerLegacyValue
+	}
+
+	return claudieMarkerV2Prefix + string(secret.UID)
+}
+
+// getMarkedSecretUID returns the UID of the Claudie Secret stored in the Claudie annotation of
+// sveltosCluster. Returns an empty UID for legacy annotation values, which are still considered
+// managed but cannot be used to verify provenance.
+func getMarkedSecretUID(sveltosCluster *libsveltosv1alpha1.SveltosCluster) types.UID {
+	marker := sveltosCluster.Annotations[sveltosClusterClaudieAnnotation]
+	if !strings.HasPrefix(marker, claudieMarkerV2Prefix) {
+		return ""
+	}
+
+	return types.UID(strings.TrimPrefix(marker, claudieMarkerV2Prefix))
+}
+
+// isMarkedForSecret returns false only if sveltosCluster records, in its Claudie annotation, it was
+// created for a Secret with a UID different from secret one. When provenance is not recorded (or
+// secret UID is not known), true is returned.
+func isMarkedForSecret(sveltosCluster *libsveltosv1alpha1.SveltosCluster, secret *corev1.Secret) bool {
+	uid := getMarkedSecretUID(sveltosCluster)
+	return uid == "" || secret.UID == "" || uid == secret.UID
+}
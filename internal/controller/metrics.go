@@ -0,0 +1,130 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	// reconcilePanics counts panics recovered while reconciling Claudie Secrets
+	reconcilePanics = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "claudie_reconcile_panics_total",
+			Help: "Number of panics recovered while reconciling Claudie Secrets",
+		},
+	)
+
+	// reconcileTotal counts SveltosCluster reconciliations by action, Claudie provider and result
+	reconcileTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "claudie_reconcile_total",
+			Help: "Number of SveltosCluster reconciliations by action, Claudie provider and result",
+		},
+		[]string{"action", "provider", "result"},
+	)
+
+	// secretToClusterOperations counts SecretToCluster map inserts, deletes and lookups
+	secretToClusterOperations = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "claudie_secret_to_cluster_operations_total",
+			Help: "Number of SecretToCluster map operations by type (insert, delete, lookup)",
+		},
+		[]string{"operation"},
+	)
+
+	// sveltosClusterDeleteFailures counts SveltosCluster deletions which still failed once retries were exhausted
+	sveltosClusterDeleteFailures = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "claudie_sveltoscluster_delete_failures_total",
+			Help: "Number of SveltosCluster deletions which failed after all retries",
+		},
+	)
+
+	// staleDetected counts stale SveltosClusters (whose Claudie Secret is gone) found by the stale sweep
+	staleDetected = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "claudie_sveltos_stale_detected_total",
+			Help: "Number of stale SveltosClusters found by the stale sweep",
+		},
+	)
+
+	// staleDeleteErrors counts stale SveltosClusters the stale sweep failed to delete
+	staleDeleteErrors = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "claudie_sveltos_stale_delete_errors_total",
+			Help: "Number of stale SveltosClusters the stale sweep failed to delete",
+		},
+	)
+
+	// lastSweepTimestamp reports when the stale sweep last completed
+	lastSweepTimestamp = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "claudie_sveltos_last_sweep_timestamp",
+			Help: "Unix time the stale SveltosCluster sweep last completed",
+		},
+	)
+
+	// secretToClusterSize reports the number of entries in SecretToCluster map
+	secretToClusterSize = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "claudie_secret_to_cluster_size",
+			Help: "Number of Secrets tracked in SecretToCluster map",
+		},
+	)
+)
+
+const (
+	actionCreate = "create"
+	actionUpdate = "update"
+	actionDelete = "delete"
+	// actionSkip is used when SveltosCluster is left untouched (for instance it is owned by a different Secret)
+	actionSkip = "skip"
+
+	resultSuccess = "success"
+	resultError   = "error"
+
+	// unknownProvider is used when the Claudie provider cannot be determined
+	unknownProvider = "unknown"
+)
+
+const (
+	operationInsert = "insert"
+	operationDelete = "delete"
+	operationLookup = "lookup"
+)
+
+func init() {
+	// Register custom metrics with the global controller-runtime registry
+	metrics.Registry.MustRegister(reconcilePanics, reconcileTotal, secretToClusterOperations, secretToClusterSize,
+		sveltosClusterDeleteFailures, staleDetected, staleDeleteErrors, lastSweepTimestamp)
+}
+
+// recordReconcileOutcome increments claudie_reconcile_total for action, provider and outcome
+func recordReconcileOutcome(action, provider string, err error) {
+	if provider == "" {
+		provider = unknownProvider
+	}
+
+	result := resultSuccess
+	if err != nil {
+		result = resultError
+	}
+
+	reconcileTotal.WithLabelValues(action, provider, result).Inc()
+}
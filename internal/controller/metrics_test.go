@@ -0,0 +1,189 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"gianlucam76/claudie-sveltos-integration/internal/controller"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+)
+
+var _ = Describe("Metrics", func() {
+	It("Reconcile recovers from panics and increments claudie_reconcile_panics_total", func() {
+		// Simulate a panic in the reconcile path (e.g. parsing a malformed Secret)
+		c := fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, wc client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				panic("malformed Secret")
+			},
+		}).Build()
+		reconciler := getSecretReconciler(c)
+
+		before := testutil.ToFloat64(controller.ReconcilePanics)
+
+		secretRef := reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: randomString(), Name: randomString()},
+		}
+
+		var callbackErr error
+		reconciler.ReconcileCallback = func(req reconcile.Request, result reconcile.Result, err error) {
+			callbackErr = err
+		}
+
+		var result reconcile.Result
+		var err error
+		Expect(func() {
+			result, err = reconciler.Reconcile(context.TODO(), secretRef)
+		}).ToNot(Panic())
+		Expect(err).To(MatchError(ContainSubstring("malformed Secret")))
+		Expect(result).To(Equal(reconcile.Result{}))
+
+		Expect(testutil.ToFloat64(controller.ReconcilePanics)).To(Equal(before + 1))
+
+		// Callback observes the outcome set after recovering
+		Expect(callbackErr).To(Equal(err))
+	})
+
+	It("createSveltosCluster and cleanSveltosCluster increment claudie_reconcile_total", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := getSecretReconciler(c)
+
+		provider := randomString()
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		secret.Labels[controller.ClaudieProviderLabel] = provider
+
+		counter := func(action, provider, result string) float64 {
+			return testutil.ToFloat64(controller.ReconcileTotal.WithLabelValues(action, provider, result))
+		}
+
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+		Expect(counter(controller.ActionCreate, provider, controller.ResultSuccess)).To(Equal(float64(1)))
+
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+		Expect(counter(controller.ActionUpdate, provider, controller.ResultSuccess)).To(Equal(float64(1)))
+
+		// Requested context does not exist
+		secret.Annotations = map[string]string{controller.KubeconfigContextAnnotation: randomString()}
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).ToNot(Succeed())
+		Expect(counter(controller.ActionCreate, provider, controller.ResultError)).To(Equal(float64(1)))
+
+		// Secret with no provider
+		otherSecret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		before := counter(controller.ActionCreate, controller.UnknownProvider, controller.ResultSuccess)
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), otherSecret, logr.Logger{})).To(Succeed())
+		Expect(counter(controller.ActionCreate, controller.UnknownProvider, controller.ResultSuccess)).To(Equal(before + 1))
+
+		before = counter(controller.ActionDelete, controller.UnknownProvider, controller.ResultSuccess)
+		secretRef := reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name},
+		}
+		Expect(controller.CleanSveltosCluster(reconciler, context.TODO(), secretRef, logr.Logger{})).To(Succeed())
+		Expect(counter(controller.ActionDelete, controller.UnknownProvider, controller.ResultSuccess)).To(Equal(before + 1))
+	})
+
+	It("SecretToCluster map operations are counted", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := getSecretReconciler(c)
+
+		counter := func(operation string) float64 {
+			return testutil.ToFloat64(controller.SecretToClusterOperations.WithLabelValues(operation))
+		}
+
+		inserts := counter(controller.OperationInsert)
+		deletes := counter(controller.OperationDelete)
+		lookups := counter(controller.OperationLookup)
+
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+		Expect(counter(controller.OperationInsert)).To(Equal(inserts + 1))
+		Expect(testutil.ToFloat64(controller.SecretToClusterSize)).To(Equal(float64(len(reconciler.SecretToCluster()))))
+
+		// Updating an already tracked Secret is not an insert
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+		Expect(counter(controller.OperationInsert)).To(Equal(inserts + 1))
+
+		secretRef := reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name},
+		}
+		Expect(controller.CleanSveltosCluster(reconciler, context.TODO(), secretRef, logr.Logger{})).To(Succeed())
+		Expect(counter(controller.OperationDelete)).To(Equal(deletes + 1))
+		Expect(counter(controller.OperationLookup)).To(BeNumerically(">", lookups))
+		Expect(testutil.ToFloat64(controller.SecretToClusterSize)).To(BeZero())
+	})
+
+	It("stale sweep updates stale detected, delete errors and last sweep metrics", func() {
+		getStaleSveltosCluster := func() *libsveltosv1alpha1.SveltosCluster {
+			return &libsveltosv1alpha1.SveltosCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: randomString(),
+					Name:      randomString(),
+					Annotations: map[string]string{
+						controller.SveltosClusterClaudieAnnotation: "ok",
+					},
+					OwnerReferences: []metav1.OwnerReference{
+						{Kind: "Secret", APIVersion: "v1", Name: randomString()},
+					},
+				},
+			}
+		}
+		deleted := getStaleSveltosCluster()
+		undeletable := getStaleSveltosCluster()
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(deleted, undeletable).
+			WithInterceptorFuncs(interceptor.Funcs{
+				Delete: func(ctx context.Context, wc client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+					if obj.GetName() == undeletable.Name {
+						return apierrors.NewForbidden(schema.GroupResource{}, obj.GetName(), nil)
+					}
+					return wc.Delete(ctx, obj, opts...)
+				},
+			}).Build()
+
+		detectedBefore := testutil.ToFloat64(controller.StaleDetected)
+		deleteErrorsBefore := testutil.ToFloat64(controller.StaleDeleteErrors)
+		controller.LastSweepTimestamp.Set(0)
+
+		// Deferred sweeps are not reported as completed
+		controller.RemoveStaleSveltosClusters(context.TODO(), c, c, controller.DefaultClaudieMetadata, 0, make(chan struct{}), logr.Logger{})
+		Expect(testutil.ToFloat64(controller.LastSweepTimestamp)).To(BeZero())
+
+		mapReady := make(chan struct{})
+		close(mapReady)
+		start := time.Now().Unix()
+		controller.RemoveStaleSveltosClusters(context.TODO(), c, c, controller.DefaultClaudieMetadata, 0, mapReady, logr.Logger{})
+
+		Expect(testutil.ToFloat64(controller.StaleDetected)).To(Equal(detectedBefore + 2))
+		Expect(testutil.ToFloat64(controller.StaleDeleteErrors)).To(Equal(deleteErrorsBefore + 1))
+		Expect(testutil.ToFloat64(controller.LastSweepTimestamp)).To(BeNumerically(">=", start))
+	})
+})
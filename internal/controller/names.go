@@ -0,0 +1,123 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	logs "github.com/projectsveltos/libsveltos/lib/logsettings"
+)
+
+const (
+	// nameSuffixLength is the length of the hash suffix appended to SveltosCluster names
+	nameSuffixLength = 8
+
+	// maxClusterNameLength is the maximum length of a SveltosCluster name. Sveltos uses the
+	// SveltosCluster name as label value, so the label value limit applies.
+	maxClusterNameLength = validation.LabelValueMaxLength
+)
+
+// sanitizeClusterName converts raw (a label value) into a valid SveltosCluster name.
+// Uppercase letters are lowercased and characters not allowed in resource names are
+// replaced with '-'. Names longer than maxClusterNameLength are truncated and a hash of
+// raw is appended, so distinct long inputs do not collide.
+func sanitizeClusterName(raw string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '.':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + ('a' - 'A')
+		default:
+			return '-'
+		}
+	}, raw)
+
+	isAlphanumeric := func(r rune) bool {
+		return (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9')
+	}
+	name = strings.TrimFunc(name, func(r rune) bool { return !isAlphanumeric(r) })
+
+	// Dots are only allowed between alphanumeric segments
+	if len(validation.IsDNS1123Subdomain(name)) != 0 {
+		name = strings.ReplaceAll(name, ".", "-")
+	}
+
+	if len(name) > maxClusterNameLength {
+		name = appendNameSuffix(name, getShortHash(raw))
+	}
+
+	return name
+}
+
+// appendNameSuffix appends suffix to name, truncating name so the result is not longer
+// than maxClusterNameLength
+func appendNameSuffix(name, suffix string) string {
+	maxPrefixLength := maxClusterNameLength - len(suffix) - 1
+	if len(name) > maxPrefixLength {
+		name = strings.TrimRight(name[:maxPrefixLength], "-.")
+	}
+
+	return fmt.Sprintf("%s-%s", name, suffix)
+}
+
+// getShortHash returns the first nameSuffixLength characters of the hex encoded hash of value
+func getShortHash(value string) string {
+	hash := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(hash[:])[:nameSuffixLength]
+}
+
+// getNameSuffix returns a short hash, derived from Secret namespace and name, used to make
+// SveltosCluster names unique. It is stable across reconciliations.
+func getNameSuffix(secret *corev1.Secret) string {
+	return getShortHash(fmt.Sprintf("%s/%s", secret.Namespace, secret.Name))
+}
+
+func (r *SecretReconciler) getSveltosClusterName(secret *corev1.Secret) string {
+	raw := r.getClusterName(secret)
+	name := sanitizeClusterName(raw)
+
+	// Different cluster labels can sanitize to the same name. When the label had to be modified,
+	// a suffix unique to the Secret guarantees SveltosCluster names do not collide.
+	if r.UniqueNameSuffix && name != raw {
+		name = appendNameSuffix(name, getNameSuffix(secret))
+	}
+
+	return name
+}
+
+// hasClusterName returns true if a SveltosCluster name can be derived from the secret cluster name.
+// A blank cluster name (or one made only of invalid characters) would make SveltosCluster creation
+// fail opaquely, so such Secrets are skipped till the cluster name is fixed.
+func (r *SecretReconciler) hasClusterName(secret *corev1.Secret, logger logr.Logger) bool {
+	raw := r.getClusterName(secret)
+	if sanitizeClusterName(raw) != "" {
+		return true
+	}
+
+	msg := fmt.Sprintf("skipping Secret: cluster name %q is not a valid SveltosCluster name", raw)
+	logger.V(logs.LogInfo).Info(msg)
+	r.recordEvent(secret, corev1.EventTypeWarning, reasonInvalidClusterName, msg)
+	return false
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"gianlucam76/claudie-sveltos-integration/internal/controller"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+)
+
+var _ = Describe("Names", func() {
+	DescribeTable("sanitizeClusterName returns a valid resource name",
+		func(raw, expected string) {
+			name := controller.SanitizeClusterName(raw)
+			Expect(name).To(Equal(expected))
+			Expect(validation.IsDNS1123Subdomain(name)).To(BeEmpty())
+		},
+		Entry("already valid", "cluster-a", "cluster-a"),
+		Entry("uppercase", "Cluster-A", "cluster-a"),
+		Entry("underscore", "cluster_a", "cluster-a"),
+		Entry("leading and trailing invalid characters", "_cluster_", "cluster"),
+		Entry("dots", "cluster.a", "cluster.a"),
+		Entry("dot next to dash", "cluster.-a", "cluster--a"),
+	)
+
+	It("sanitizeClusterName truncates over-length names with a stable hash suffix", func() {
+		raw := strings.Repeat("Cluster_", 20)
+		name := controller.SanitizeClusterName(raw)
+		Expect(len(name)).To(BeNumerically("<=", validation.LabelValueMaxLength))
+		Expect(validation.IsDNS1123Subdomain(name)).To(BeEmpty())
+		Expect(validation.IsDNS1123Label(name)).To(BeEmpty())
+		Expect(name).To(HavePrefix("cluster-cluster-"))
+		Expect(controller.SanitizeClusterName(raw)).To(Equal(name))
+
+		// Inputs sharing the truncated prefix do not collide
+		Expect(controller.SanitizeClusterName(raw + "b")).ToNot(Equal(name))
+	})
+
+	It("getSveltosClusterName produces distinct and stable names for colliding labels", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := getSecretReconciler(c)
+		reconciler.UniqueNameSuffix = true
+
+		namespace := randomString()
+		getSecret := func(name, cluster string) *corev1.Secret {
+			return &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: namespace,
+					Name:      name,
+					Labels:    map[string]string{controller.ClaudieCluster: cluster},
+				},
+			}
+		}
+
+		// Both labels sanitize to "cluster-a"
+		secret1 := getSecret(randomString(), "Cluster_A")
+		secret2 := getSecret(randomString(), "cluster_a")
+
+		name1 := controller.GetSveltosClusterName(reconciler, secret1)
+		name2 := controller.GetSveltosClusterName(reconciler, secret2)
+		Expect(name1).ToNot(Equal(name2))
+		Expect(name1).To(HavePrefix("cluster-a-"))
+		Expect(name2).To(HavePrefix("cluster-a-"))
+		Expect(validation.IsDNS1123Subdomain(name1)).To(BeEmpty())
+
+		// Stable across reconciliations
+		Expect(controller.GetSveltosClusterName(reconciler, secret1)).To(Equal(name1))
+		Expect(controller.GetSveltosClusterName(reconciler, getSecret(secret1.Name, secret1.Labels[controller.ClaudieCluster]))).
+			To(Equal(name1))
+
+		// Suffix never makes names exceed the length limit
+		longName := controller.GetSveltosClusterName(reconciler, getSecret(randomString(), strings.Repeat("A", 63)))
+		Expect(len(longName)).To(BeNumerically("<=", validation.LabelValueMaxLength))
+		Expect(validation.IsDNS1123Subdomain(longName)).To(BeEmpty())
+
+		// Valid labels are used as they are
+		secret3 := getSecret(randomString(), "cluster-a")
+		Expect(controller.GetSveltosClusterName(reconciler, secret3)).To(Equal("cluster-a"))
+
+		// Without the option, sanitized name is used as it is
+		reconciler.UniqueNameSuffix = false
+		Expect(controller.GetSveltosClusterName(reconciler, secret1)).To(Equal("cluster-a"))
+	})
+
+	DescribeTable("Reconcile skips Secrets whose cluster label does not yield a SveltosCluster name",
+		func(clusterLabel string) {
+			secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+			secret.Labels[controller.ClaudieCluster] = clusterLabel
+			Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+			reconciler := getSecretReconciler(c)
+			reconciler.UniqueNameSuffix = true
+			recorder := record.NewFakeRecorder(10)
+			reconciler.EventRecorder = recorder
+
+			result, err := reconciler.Reconcile(context.TODO(), reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name},
+			})
+			Expect(err).To(BeNil())
+			Expect(result).To(Equal(reconcile.Result{}))
+			Expect(recorder.Events).To(Receive(And(HavePrefix(corev1.EventTypeWarning),
+				ContainSubstring(controller.ReasonInvalidClusterName))))
+
+			currentSveltosClusters := &libsveltosv1alpha1.SveltosClusterList{}
+			Expect(c.List(context.TODO(), currentSveltosClusters)).To(Succeed())
+			Expect(currentSveltosClusters.Items).To(BeEmpty())
+		},
+		Entry("empty value", ""),
+		Entry("only invalid characters", "_-_"),
+	)
+})
@@ -0,0 +1,111 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	logs "github.com/projectsveltos/libsveltos/lib/logsettings"
+)
+
+const (
+	// targetNamespaceAnnotation can be set on a Claudie Secret to create its SveltosCluster in a
+	// namespace different from the Secret one
+	targetNamespaceAnnotation = "projectsveltos.io/claudie-namespace"
+)
+
+// ParseNamespaceMap parses entries in the form secretNamespace=sveltosClusterNamespace (e.g.
+// claudie=production) into a map Secret namespace -> SveltosCluster namespace
+func ParseNamespaceMap(entries []string) (map[string]string, error) {
+	mapping := make(map[string]string, len(entries))
+	for i := range entries {
+		secretNamespace, sveltosClusterNamespace, found := strings.Cut(entries[i], "=")
+		if !found || secretNamespace == "" || sveltosClusterNamespace == "" {
+			return nil, fmt.Errorf("invalid mapping %q: expected secretNamespace=sveltosClusterNamespace", entries[i])
+		}
+		if err := validateNamespaceName(sveltosClusterNamespace); err != nil {
+			return nil, err
+		}
+		mapping[secretNamespace] = sveltosClusterNamespace
+	}
+
+	return mapping, nil
+}
+
+// verifyNamespaceActive returns an error if namespace does not exist or it is not Active yet
+// (or anymore). SveltosCluster must not be created in such a namespace.
+func (r *SecretReconciler) verifyNamespaceActive(ctx context.Context, namespace string) error {
+	ns := &corev1.Namespace{}
+	err := r.Get(ctx, types.NamespacedName{Name: namespace}, ns)
+	if err != nil {
+		return err
+	}
+
+	if ns.Status.Phase != corev1.NamespaceActive {
+		return fmt.Errorf("namespace %s is not active (phase: %q)", namespace, ns.Status.Phase)
+	}
+
+	return nil
+}
+
+// isTargetNamespaceWatched returns true if the SveltosCluster for secret can be created in the namespace
+// set by the targetNamespaceAnnotation. When the cache is restricted to WatchNamespaces (plus the
+// NamespaceMap targets), SveltosClusters in any other namespace could never be read back, so such
+// Secrets are skipped till the annotation is fixed.
+func (r *SecretReconciler) isTargetNamespaceWatched(secret *corev1.Secret, logger logr.Logger) bool {
+	namespace := secret.Annotations[targetNamespaceAnnotation]
+	if namespace == "" || len(r.WatchNamespaces) == 0 {
+		return true
+	}
+
+	if slices.Contains(r.WatchNamespaces, namespace) {
+		return true
+	}
+	for _, mapped := range r.NamespaceMap {
+		if mapped == namespace {
+			return true
+		}
+	}
+
+	msg := fmt.Sprintf("skipping Secret: %s namespace %q is not watched", targetNamespaceAnnotation, namespace)
+	logger.V(logs.LogInfo).Info(msg)
+	r.recordEvent(secret, corev1.EventTypeWarning, reasonNamespaceNotWatched, msg)
+	return false
+}
+
+// validateNamespaceName verifies namespace is a valid RFC 1123 label
+func validateNamespaceName(namespace string) error {
+	// Cannot happen for Secrets fetched from the API server, but crafted objects might
+	// have no namespace. Creating a SveltosCluster would then fail with an obscure error.
+	if namespace == "" {
+		return fmt.Errorf("SveltosCluster namespace is empty")
+	}
+
+	if errs := validation.IsDNS1123Label(namespace); len(errs) != 0 {
+		return fmt.Errorf("invalid SveltosCluster namespace %q: %s", namespace, strings.Join(errs, ", "))
+	}
+
+	return nil
+}
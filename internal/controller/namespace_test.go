@@ -0,0 +1,249 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"gianlucam76/claudie-sveltos-integration/internal/controller"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+)
+
+var _ = Describe("Namespace", func() {
+	It("verifyNamespaceActive succeeds only for Active namespaces", func() {
+		active := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: randomString(),
+			},
+			Status: corev1.NamespaceStatus{
+				Phase: corev1.NamespaceActive,
+			},
+		}
+
+		terminating := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: randomString(),
+			},
+			Status: corev1.NamespaceStatus{
+				Phase: corev1.NamespaceTerminating,
+			},
+		}
+
+		notReady := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: randomString(),
+			},
+		}
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(active, terminating, notReady).Build()
+		reconciler := getSecretReconciler(c)
+
+		Expect(controller.VerifyNamespaceActive(reconciler, context.TODO(), active.Name)).To(Succeed())
+		Expect(controller.VerifyNamespaceActive(reconciler, context.TODO(), terminating.Name)).ToNot(Succeed())
+		Expect(controller.VerifyNamespaceActive(reconciler, context.TODO(), notReady.Name)).ToNot(Succeed())
+		Expect(controller.VerifyNamespaceActive(reconciler, context.TODO(), randomString())).ToNot(Succeed())
+	})
+
+	DescribeTable("createSveltosCluster validates SveltosCluster namespace",
+		func(namespace string, valid bool) {
+			c := fake.NewClientBuilder().WithScheme(scheme).Build()
+			reconciler := getSecretReconciler(c)
+			recorder := record.NewFakeRecorder(10)
+			reconciler.EventRecorder = recorder
+
+			secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+			secret.Namespace = namespace
+
+			err := controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})
+			if valid {
+				Expect(err).To(BeNil())
+				Expect(recorder.Events).To(Receive(And(
+					HavePrefix(corev1.EventTypeNormal),
+					ContainSubstring(controller.ReasonSveltosClusterCreated))))
+				return
+			}
+
+			Expect(err).ToNot(BeNil())
+			Expect(recorder.Events).To(Receive(And(
+				HavePrefix(corev1.EventTypeWarning),
+				ContainSubstring(controller.ReasonInvalidNamespace))))
+
+			currentSveltosClusters := &libsveltosv1alpha1.SveltosClusterList{}
+			Expect(c.List(context.TODO(), currentSveltosClusters)).To(Succeed())
+			Expect(currentSveltosClusters.Items).To(BeEmpty())
+		},
+		Entry("valid namespace", "claudie-clusters", true),
+		Entry("uppercase namespace", "Claudie", false),
+		Entry("namespace with underscore", "claudie_clusters", false),
+		Entry("namespace too long", strings.Repeat("a", 64), false),
+		Entry("namespace starting with dash", "-claudie", false),
+		Entry("empty namespace", "", false),
+	)
+
+	It("createSveltosCluster rejects empty namespace without contacting the API server", func() {
+		apiCalls := 0
+		c := fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, wc client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				apiCalls++
+				return wc.Get(ctx, key, obj, opts...)
+			},
+			Create: func(ctx context.Context, wc client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				apiCalls++
+				return wc.Create(ctx, obj, opts...)
+			},
+		}).Build()
+		reconciler := getSecretReconciler(c)
+
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		secret.Namespace = ""
+
+		err := controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(ContainSubstring("namespace is empty"))
+		Expect(apiCalls).To(BeZero())
+	})
+
+	It("ParseNamespaceMap parses secretNamespace=sveltosClusterNamespace entries", func() {
+		mapping, err := controller.ParseNamespaceMap([]string{"claudie=production", "staging=staging-clusters"})
+		Expect(err).To(BeNil())
+		Expect(mapping).To(Equal(map[string]string{"claudie": "production", "staging": "staging-clusters"}))
+
+		_, err = controller.ParseNamespaceMap([]string{"claudie"})
+		Expect(err).ToNot(BeNil())
+		_, err = controller.ParseNamespaceMap([]string{"claudie=Invalid_Namespace"})
+		Expect(err).ToNot(BeNil())
+	})
+
+	It("getSveltosClusterNamespace prefers annotation over namespace map", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := getSecretReconciler(c)
+
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		Expect(controller.GetSveltosClusterNamespace(reconciler, secret)).To(Equal(secret.Namespace))
+
+		reconciler.NamespaceMap = map[string]string{secret.Namespace: "production"}
+		Expect(controller.GetSveltosClusterNamespace(reconciler, secret)).To(Equal("production"))
+
+		secret.Annotations = map[string]string{controller.TargetNamespaceAnnotation: "staging"}
+		Expect(controller.GetSveltosClusterNamespace(reconciler, secret)).To(Equal("staging"))
+	})
+
+	It("Reconcile skips Secrets whose target namespace is not watched", func() {
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		secret.Annotations = map[string]string{controller.TargetNamespaceAnnotation: "staging"}
+		Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+		reconciler := getSecretReconciler(c)
+		recorder := record.NewFakeRecorder(10)
+		reconciler.EventRecorder = recorder
+		reconciler.WatchNamespaces = []string{secret.Namespace}
+		reconciler.NamespaceMap = map[string]string{randomString(): "production"}
+
+		secretRef := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name}}
+		_, err := reconciler.Reconcile(context.TODO(), secretRef)
+		Expect(err).To(BeNil())
+		Expect(recorder.Events).To(Receive(And(
+			HavePrefix(corev1.EventTypeWarning),
+			ContainSubstring(controller.ReasonNamespaceNotWatched))))
+
+		currentSveltosClusters := &libsveltosv1alpha1.SveltosClusterList{}
+		Expect(c.List(context.TODO(), currentSveltosClusters)).To(Succeed())
+		Expect(currentSveltosClusters.Items).To(BeEmpty())
+		currentSecret := &corev1.Secret{}
+		Expect(c.Get(context.TODO(), secretRef.NamespacedName, currentSecret)).To(Succeed())
+		Expect(currentSecret.Finalizers).ToNot(ContainElement(controller.ClaudieCleanupFinalizer))
+
+		// Namespace-map targets are in the cache as well
+		reconciler.NamespaceMap = map[string]string{randomString(): "staging"}
+		Expect(controller.IsTargetNamespaceWatched(reconciler, secret, logr.Logger{})).To(BeTrue())
+
+		// Cache is not restricted
+		reconciler.WatchNamespaces = nil
+		reconciler.NamespaceMap = nil
+		Expect(controller.IsTargetNamespaceWatched(reconciler, secret, logr.Logger{})).To(BeTrue())
+	})
+
+	It("createSveltosCluster in a different namespace tracks Secret via annotations", func() {
+		target := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: randomString()},
+			Status:     corev1.NamespaceStatus{Phase: corev1.NamespaceActive},
+		}
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(target, secret).Build()
+		reconciler := getSecretReconciler(c)
+		reconciler.NamespaceMap = map[string]string{secret.Namespace: target.Name}
+
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+
+		sveltosClusterKey := types.NamespacedName{Namespace: target.Name, Name: secret.Labels[controller.ClaudieCluster]}
+		sveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+		Expect(c.Get(context.TODO(), sveltosClusterKey, sveltosCluster)).To(Succeed())
+		Expect(sveltosCluster.OwnerReferences).To(BeEmpty())
+		Expect(sveltosCluster.Annotations).To(HaveKeyWithValue(controller.SveltosClusterSecretAnnotation, secret.Name))
+		Expect(sveltosCluster.Annotations).To(HaveKeyWithValue(controller.SveltosClusterSecretNamespaceAnnotation,
+			secret.Namespace))
+		Expect(controller.GetClaudieSecret(sveltosCluster)).To(Equal(
+			&types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name}))
+
+		// Sveltos reads kubeconfig from SveltosCluster namespace
+		Expect(sveltosCluster.Spec.KubeconfigName).To(Equal(controller.GetMirroredKubeconfigName(sveltosCluster.Name)))
+		mirror := &corev1.Secret{}
+		Expect(c.Get(context.TODO(),
+			types.NamespacedName{Namespace: target.Name, Name: sveltosCluster.Spec.KubeconfigName}, mirror)).To(Succeed())
+		Expect(mirror.Data[controller.KubeconfigDataKey]).To(Equal(secret.Data[controller.KubeconfigDataKey]))
+
+		currentSecret := &corev1.Secret{}
+		Expect(c.Get(context.TODO(), types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name},
+			currentSecret)).To(Succeed())
+		Expect(currentSecret.Finalizers).To(ContainElement(controller.ClaudieCleanupFinalizer))
+
+		// Controller restarted, SecretToCluster map is empty. Deletion still removes SveltosCluster.
+		Expect(c.Delete(context.TODO(), currentSecret)).To(Succeed())
+		reconciler = getSecretReconciler(c)
+		reconciler.NamespaceMap = map[string]string{secret.Namespace: target.Name}
+		_, err := reconciler.Reconcile(context.TODO(), ctrl.Request{
+			NamespacedName: types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name},
+		})
+		Expect(err).To(BeNil())
+
+		err = c.Get(context.TODO(), sveltosClusterKey, sveltosCluster)
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+		// Finalizer removed, Secret is gone
+		err = c.Get(context.TODO(), types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name}, currentSecret)
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+})
@@ -0,0 +1,54 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+	logs "github.com/projectsveltos/libsveltos/lib/logsettings"
+)
+
+const (
+	// pausedAnnotation can be set on a Claudie Secret to "true" to create (or turn) the SveltosCluster
+	// paused, so that Sveltos does not deploy add-ons till the cluster is verified. Setting it to "false"
+	// resumes it. When the annotation is not set, Paused is left untouched.
+	pausedAnnotation = "projectsveltos.io/claudie-paused"
+)
+
+// applyPausedAnnotation sets SveltosCluster Spec.Paused according to the paused annotation on secret.
+// Invalid values are ignored.
+func applyPausedAnnotation(sveltosCluster *libsveltosv1alpha1.SveltosCluster, secret *corev1.Secret,
+	logger logr.Logger) {
+
+	value, ok := secret.Annotations[pausedAnnotation]
+	if !ok {
+		return
+	}
+
+	paused, err := strconv.ParseBool(value)
+	if err != nil {
+		logger.V(logs.LogInfo).Info(fmt.Sprintf("ignoring invalid paused annotation value %q", value))
+		return
+	}
+
+	sveltosCluster.Spec.Paused = paused
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+)
+
+const (
+	// permissionsCheckInterval is the minimum interval between two permission checks run
+	// by the health check, so probes do not flood the API server with access reviews
+	permissionsCheckInterval = time.Minute
+)
+
+// requiredPermission lists verbs this controller needs on a resource
+type requiredPermission struct {
+	group    string
+	resource string
+	verbs    []string
+}
+
+// requiredPermissions are the permissions this controller cannot work without.
+// Keep them in sync with the kubebuilder rbac markers on Reconcile.
+var requiredPermissions = []requiredPermission{
+	{
+		group:    "",
+		resource: "secrets",
+		verbs:    []string{"get", "list", "watch", "create", "update", "delete"},
+	},
+	{
+		group:    libsveltosv1alpha1.GroupVersion.Group,
+		resource: "sveltosclusters",
+		verbs:    []string{"get", "list", "watch", "update", "patch", "create", "delete"},
+	},
+}
+
+// CheckPermissions verifies, via SelfSubjectAccessReviews, that this controller is allowed all verbs
+// it needs on Secrets and SveltosClusters in each of namespaces (an empty namespace means cluster-wide).
+// Returned error lists all missing permissions, so a misconfigured RBAC is reported at once.
+func CheckPermissions(ctx context.Context, c client.Client, namespaces []string) error {
+	missing, err := getMissingPermissions(ctx, c, namespaces)
+	if err != nil {
+		return err
+	}
+
+	return missingPermissionsError(missing)
+}
+
+// getMissingPermissions returns the description of every required permission a SelfSubjectAccessReview
+// explicitly denies. An error is returned if any review cannot be run.
+func getMissingPermissions(ctx context.Context, c client.Client, namespaces []string) ([]string, error) {
+	if len(namespaces) == 0 {
+		namespaces = []string{""}
+	}
+
+	var missing []string
+	for _, namespace := range namespaces {
+		for i := range requiredPermissions {
+			permission := &requiredPermissions[i]
+			for _, verb := range permission.verbs {
+				review := &authorizationv1.SelfSubjectAccessReview{
+					Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+						ResourceAttributes: &authorizationv1.ResourceAttributes{
+							Namespace: namespace,
+							Verb:      verb,
+							Group:     permission.group,
+							Resource:  permission.resource,
+						},
+					},
+				}
+				if err := c.Create(ctx, review); err != nil {
+					return nil, fmt.Errorf("failed to review access to %s: %w", permission.resource, err)
+				}
+				if !review.Status.Allowed {
+					missing = append(missing, describePermission(namespace, verb, permission))
+				}
+			}
+		}
+	}
+
+	return missing, nil
+}
+
+// missingPermissionsError returns an error listing missing permissions, nil if none is missing
+func missingPermissionsError(missing []string) error {
+	if len(missing) != 0 {
+		return fmt.Errorf("missing RBAC permissions: %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// describePermission returns a human readable description of verb on permission resource
+func describePermission(namespace, verb string, permission *requiredPermission) string {
+	resource := permission.resource
+	if permission.group != "" {
+		resource = fmt.Sprintf("%s.%s", permission.resource, permission.group)
+	}
+	if namespace == "" {
+		return fmt.Sprintf("%s %s (cluster-wide)", verb, resource)
+	}
+	return fmt.Sprintf("%s %s (namespace %s)", verb, resource, namespace)
+}
+
+// NewPermissionsCheck returns a check failing when this controller lacks any of the RBAC
+// permissions it needs. Only an access review explicitly denying a permission fails the check:
+// a review that cannot be run (for instance because the API server is briefly unreachable) says
+// nothing about RBAC, so it is treated as passing. Permissions are verified at most once per minute,
+// last result is reported in between.
+func NewPermissionsCheck(c client.Client, namespaces []string) healthz.Checker {
+	var (
+		mux       sync.Mutex
+		lastCheck time.Time
+		lastErr   error
+	)
+
+	return func(req *http.Request) error {
+		mux.Lock()
+		defer mux.Unlock()
+
+		if !lastCheck.IsZero() && time.Since(lastCheck) < permissionsCheckInterval {
+			return lastErr
+		}
+
+		ctx := context.Background()
+		if req != nil {
+			ctx = req.Context()
+		}
+
+		missing, err := getMissingPermissions(ctx, c, namespaces)
+		lastCheck = time.Now()
+		if err != nil {
+			lastErr = nil
+			return nil
+		}
+
+		lastErr = missingPermissionsError(missing)
+		return lastErr
+	}
+}
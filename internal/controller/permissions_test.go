@@ -0,0 +1,114 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"gianlucam76/claudie-sveltos-integration/internal/controller"
+)
+
+var _ = Describe("Permissions", func() {
+	It("CheckPermissions succeeds when all verbs are allowed", func() {
+		var reviews []authorizationv1.ResourceAttributes
+		c := getClientWithAccessReviews(func(attributes *authorizationv1.ResourceAttributes) bool {
+			reviews = append(reviews, *attributes)
+			return true
+		})
+
+		Expect(controller.CheckPermissions(context.TODO(), c, nil)).To(Succeed())
+		Expect(reviews).ToNot(BeEmpty())
+		for i := range reviews {
+			Expect(reviews[i].Namespace).To(BeEmpty())
+		}
+	})
+
+	It("CheckPermissions reports all missing permissions", func() {
+		c := getClientWithAccessReviews(func(attributes *authorizationv1.ResourceAttributes) bool {
+			return attributes.Resource != "sveltosclusters" ||
+				(attributes.Verb != "create" && attributes.Verb != "delete")
+		})
+
+		namespace := randomString()
+		err := controller.CheckPermissions(context.TODO(), c, []string{namespace})
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(ContainSubstring("create sveltosclusters.lib.projectsveltos.io (namespace " + namespace + ")"))
+		Expect(err.Error()).To(ContainSubstring("delete sveltosclusters.lib.projectsveltos.io (namespace " + namespace + ")"))
+		Expect(err.Error()).ToNot(ContainSubstring("secrets"))
+	})
+
+	It("NewPermissionsCheck does not review access on every probe", func() {
+		var mux sync.Mutex
+		reviews := 0
+		c := getClientWithAccessReviews(func(attributes *authorizationv1.ResourceAttributes) bool {
+			mux.Lock()
+			defer mux.Unlock()
+			reviews++
+			return attributes.Resource != "secrets"
+		})
+
+		check := controller.NewPermissionsCheck(c, nil)
+		Expect(check(nil)).ToNot(Succeed())
+		firstReviews := reviews
+		Expect(firstReviews).To(BeNumerically(">", 0))
+
+		// Last result is reported without reviewing access again
+		Expect(check(nil)).ToNot(Succeed())
+		Expect(reviews).To(Equal(firstReviews))
+	})
+
+	It("NewPermissionsCheck passes when access cannot be reviewed", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(interceptor.Funcs{
+			Create: func(ctx context.Context, wc client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				if _, ok := obj.(*authorizationv1.SelfSubjectAccessReview); ok {
+					return apierrors.NewServiceUnavailable("api server unavailable")
+				}
+				return wc.Create(ctx, obj, opts...)
+			},
+		}).Build()
+
+		// Startup verification still reports the failure
+		Expect(controller.CheckPermissions(context.TODO(), c, nil)).ToNot(Succeed())
+
+		check := controller.NewPermissionsCheck(c, nil)
+		Expect(check(nil)).To(Succeed())
+	})
+})
+
+// getClientWithAccessReviews returns a fake client answering SelfSubjectAccessReviews with allowed
+func getClientWithAccessReviews(allowed func(attributes *authorizationv1.ResourceAttributes) bool) client.Client {
+	return fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(interceptor.Funcs{
+		Create: func(ctx context.Context, wc client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			review, ok := obj.(*authorizationv1.SelfSubjectAccessReview)
+			if !ok {
+				return wc.Create(ctx, obj, opts...)
+			}
+			review.Status.Allowed = allowed(review.Spec.ResourceAttributes)
+			return nil
+		},
+	}).Build()
+}
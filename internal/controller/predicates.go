@@ -0,0 +1,160 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	// skipAnnotation, when set to "true" on a Secret, instructs this controller to ignore it
+	skipAnnotation = "projectsveltos.io/claudie-skip"
+)
+
+// SecretFilters contains the filters Secrets must pass to be enqueued for reconciliation
+type SecretFilters struct {
+	// Selector, when set, must match Secret labels
+	Selector labels.Selector
+
+	// AllowedNamespaces, when not empty, are the only namespaces Secrets are considered from
+	AllowedNamespaces []string
+
+	// DeniedNamespaces are the namespaces Secrets are never considered from
+	DeniedNamespaces []string
+}
+
+// getSecretPredicate returns the predicate combining label selector, namespace allow/deny list and
+// skip annotation checks. It is used to filter Secrets at the informer level before they are enqueued.
+// Secrets being deleted which carry the cleanup finalizer, and Secrets already tracked, are always let
+// through, so SveltosClusters are offboarded when such Secrets gain the skip annotation or move out of
+// the allowed namespaces.
+func (r *SecretReconciler) getSecretPredicate() predicate.Predicate {
+	filters := r.SecretFilters
+
+	isRelevant := func(object client.Object) bool {
+		if filters.matches(object) {
+			return true
+		}
+		secret, ok := object.(*corev1.Secret)
+		return ok && r.isSecretTracked(secret)
+	}
+
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return isRelevant(e.Object)
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			// Secrets carrying the cleanup finalizer must be processed to release them
+			return isRelevant(e.ObjectNew) || isBeingCleanedUp(e.ObjectNew)
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return isRelevant(e.Object)
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return isRelevant(e.Object)
+		},
+	}
+}
+
+// getClaudieSecretPredicate returns the predicate letting through only events for Secrets carrying
+// all Claudie labels, so unrelated Secrets never reach the reconcile queue.
+// Secrets already tracked, or being deleted with the cleanup finalizer, are let through as well, so that
+// SveltosClusters are cleaned up when such Secrets are deleted or lose the Claudie labels.
+func (r *SecretReconciler) getClaudieSecretPredicate() predicate.Predicate {
+	isRelevant := func(object client.Object) bool {
+		secret, ok := object.(*corev1.Secret)
+		if !ok {
+			return false
+		}
+
+		return r.shouldReconcileSecret(secret) || r.isSecretTracked(secret) || isBeingCleanedUp(secret)
+	}
+
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return isRelevant(e.Object)
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			// Secret losing Claudie labels must be processed to offboard it
+			return isRelevant(e.ObjectNew) || isRelevant(e.ObjectOld)
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return isRelevant(e.Object)
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return isRelevant(e.Object)
+		},
+	}
+}
+
+// isSecretTracked returns true if a SveltosCluster is tracked for secret
+func (r *SecretReconciler) isSecretTracked(secret *corev1.Secret) bool {
+	_, ok := r.secretToCluster.Get(types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name})
+	return ok
+}
+
+// matches returns true if object passes all filters
+func (f *SecretFilters) matches(object client.Object) bool {
+	if object == nil {
+		return false
+	}
+
+	if _, ok := object.(*corev1.Secret); !ok {
+		return false
+	}
+
+	if object.GetAnnotations()[skipAnnotation] == "true" {
+		return false
+	}
+
+	if !f.isNamespaceAllowed(object.GetNamespace()) {
+		return false
+	}
+
+	return f.matchesSelector(object)
+}
+
+// matchesSelector returns true if no Selector is set or object labels match it
+func (f *SecretFilters) matchesSelector(object client.Object) bool {
+	return f.Selector == nil || f.Selector.Matches(labels.Set(object.GetLabels()))
+}
+
+// isNamespaceAllowed returns true if Secrets in namespace must be considered
+func (f *SecretFilters) isNamespaceAllowed(namespace string) bool {
+	for i := range f.DeniedNamespaces {
+		if f.DeniedNamespaces[i] == namespace {
+			return false
+		}
+	}
+
+	if len(f.AllowedNamespaces) == 0 {
+		return true
+	}
+
+	for i := range f.AllowedNamespaces {
+		if f.AllowedNamespaces[i] == namespace {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+
+	"gianlucam76/claudie-sveltos-integration/internal/controller"
+)
+
+var _ = Describe("Predicates", func() {
+	const (
+		allowedNamespace = "claudie"
+		deniedNamespace  = "kube-system"
+	)
+
+	DescribeTable("secret predicate combines label selector, namespace and skip annotation filters",
+		func(filters controller.SecretFilters, namespace string, secretLabels, secretAnnotations map[string]string,
+			expected bool) {
+
+			c := fake.NewClientBuilder().WithScheme(scheme).Build()
+			reconciler := getSecretReconciler(c)
+			reconciler.SecretFilters = filters
+
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:   namespace,
+					Name:        randomString(),
+					Labels:      secretLabels,
+					Annotations: secretAnnotations,
+				},
+			}
+
+			p := controller.GetSecretPredicate(reconciler)
+			Expect(p.Create(event.CreateEvent{Object: secret})).To(Equal(expected))
+			Expect(p.Update(event.UpdateEvent{ObjectOld: secret, ObjectNew: secret})).To(Equal(expected))
+			Expect(p.Delete(event.DeleteEvent{Object: secret})).To(Equal(expected))
+			Expect(p.Generic(event.GenericEvent{Object: secret})).To(Equal(expected))
+		},
+		Entry("no filters",
+			controller.SecretFilters{}, randomString(), nil, nil, true),
+		Entry("skip annotation",
+			controller.SecretFilters{}, randomString(), nil,
+			map[string]string{controller.SkipAnnotation: "true"}, false),
+		Entry("skip annotation not true",
+			controller.SecretFilters{}, randomString(), nil,
+			map[string]string{controller.SkipAnnotation: "false"}, true),
+		Entry("namespace allowed",
+			controller.SecretFilters{AllowedNamespaces: []string{allowedNamespace}}, allowedNamespace, nil, nil, true),
+		Entry("namespace not in allow list",
+			controller.SecretFilters{AllowedNamespaces: []string{allowedNamespace}}, randomString(), nil, nil, false),
+		Entry("namespace denied",
+			controller.SecretFilters{DeniedNamespaces: []string{deniedNamespace}}, deniedNamespace, nil, nil, false),
+		Entry("deny list takes precedence over allow list",
+			controller.SecretFilters{AllowedNamespaces: []string{deniedNamespace}, DeniedNamespaces: []string{deniedNamespace}},
+			deniedNamespace, nil, nil, false),
+		Entry("selector matches",
+			controller.SecretFilters{Selector: labels.SelectorFromSet(labels.Set{"team": "a"})}, randomString(),
+			map[string]string{"team": "a"}, nil, true),
+		Entry("selector does not match",
+			controller.SecretFilters{Selector: labels.SelectorFromSet(labels.Set{"team": "a"})}, randomString(),
+			map[string]string{"team": "b"}, nil, false),
+		Entry("selector matches, namespace allowed, skip annotation",
+			controller.SecretFilters{
+				Selector:          labels.SelectorFromSet(labels.Set{"team": "a"}),
+				AllowedNamespaces: []string{allowedNamespace},
+			},
+			allowedNamespace, map[string]string{"team": "a"},
+			map[string]string{controller.SkipAnnotation: "true"}, false),
+		Entry("selector matches, namespace not allowed",
+			controller.SecretFilters{
+				Selector:          labels.SelectorFromSet(labels.Set{"team": "a"}),
+				AllowedNamespaces: []string{allowedNamespace},
+			},
+			randomString(), map[string]string{"team": "a"}, nil, false),
+	)
+
+	It("secret predicate lets through tracked Secrets no longer matching the filters", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := getSecretReconciler(c)
+		reconciler.SecretFilters = controller.SecretFilters{DeniedNamespaces: []string{deniedNamespace}}
+		p := controller.GetSecretPredicate(reconciler)
+
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		skipped := secret.DeepCopy()
+		skipped.Annotations = map[string]string{controller.SkipAnnotation: "true"}
+		denied := secret.DeepCopy()
+		denied.Namespace = deniedNamespace
+
+		Expect(p.Update(event.UpdateEvent{ObjectOld: secret, ObjectNew: skipped})).To(BeFalse())
+		Expect(p.Generic(event.GenericEvent{Object: denied})).To(BeFalse())
+
+		reconciler.TrackSveltosCluster(types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name},
+			types.NamespacedName{Namespace: secret.Namespace, Name: randomString()})
+		reconciler.TrackSveltosCluster(types.NamespacedName{Namespace: denied.Namespace, Name: denied.Name},
+			types.NamespacedName{Namespace: denied.Namespace, Name: randomString()})
+
+		// Skip annotation added to a tracked Secret
+		Expect(p.Update(event.UpdateEvent{ObjectOld: secret, ObjectNew: skipped})).To(BeTrue())
+		Expect(p.Delete(event.DeleteEvent{Object: skipped})).To(BeTrue())
+		// Tracked Secret in a denied namespace
+		Expect(p.Generic(event.GenericEvent{Object: denied})).To(BeTrue())
+	})
+
+	It("Claudie Secret predicate lets through Claudie Secrets and tracked Secrets only", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := getSecretReconciler(c)
+		p := controller.GetClaudieSecretPredicate(reconciler)
+
+		unrelated := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: randomString(),
+				Name:      randomString(),
+				Labels:    map[string]string{controller.ClaudieLabel: "claudie"},
+			},
+		}
+		Expect(p.Create(event.CreateEvent{Object: unrelated})).To(BeFalse())
+		Expect(p.Update(event.UpdateEvent{ObjectOld: unrelated, ObjectNew: unrelated})).To(BeFalse())
+		Expect(p.Delete(event.DeleteEvent{Object: unrelated})).To(BeFalse())
+		Expect(p.Generic(event.GenericEvent{Object: unrelated})).To(BeFalse())
+
+		claudieSecret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		Expect(p.Create(event.CreateEvent{Object: claudieSecret})).To(BeTrue())
+		Expect(p.Update(event.UpdateEvent{ObjectOld: claudieSecret, ObjectNew: claudieSecret})).To(BeTrue())
+		Expect(p.Delete(event.DeleteEvent{Object: claudieSecret})).To(BeTrue())
+		Expect(p.Generic(event.GenericEvent{Object: claudieSecret})).To(BeTrue())
+
+		// Secret losing Claudie labels
+		withoutLabels := claudieSecret.DeepCopy()
+		withoutLabels.Labels = nil
+		Expect(p.Update(event.UpdateEvent{ObjectOld: claudieSecret, ObjectNew: withoutLabels})).To(BeTrue())
+		Expect(p.Update(event.UpdateEvent{ObjectOld: withoutLabels, ObjectNew: withoutLabels})).To(BeFalse())
+
+		// Deleting a tracked Secret, whose labels were removed, lets cleanup proceed
+		Expect(p.Delete(event.DeleteEvent{Object: withoutLabels})).To(BeFalse())
+		reconciler.TrackSveltosCluster(types.NamespacedName{Namespace: withoutLabels.Namespace, Name: withoutLabels.Name},
+			types.NamespacedName{Namespace: withoutLabels.Namespace, Name: randomString()})
+		Expect(p.Delete(event.DeleteEvent{Object: withoutLabels})).To(BeTrue())
+		Expect(p.Update(event.UpdateEvent{ObjectOld: withoutLabels, ObjectNew: withoutLabels})).To(BeTrue())
+
+		// Deleting an untracked Secret, which still carries the cleanup finalizer, lets cleanup proceed
+		now := metav1.Now()
+		deleting := unrelated.DeepCopy()
+		deleting.DeletionTimestamp = &now
+		deleting.Finalizers = []string{controller.ClaudieCleanupFinalizer}
+		Expect(p.Update(event.UpdateEvent{ObjectOld: unrelated, ObjectNew: deleting})).To(BeTrue())
+		Expect(p.Update(event.UpdateEvent{ObjectOld: deleting, ObjectNew: deleting})).To(BeTrue())
+	})
+})
@@ -0,0 +1,156 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+	logs "github.com/projectsveltos/libsveltos/lib/logsettings"
+)
+
+const (
+	// reachableAnnotation reports on a SveltosCluster whether its API server answered the connectivity
+	// probe ("true") or not ("false")
+	reachableAnnotation = "projectsveltos.io/claudie-reachable"
+
+	// probedAtAnnotation reports on a SveltosCluster when the connectivity probe last ran
+	probedAtAnnotation = "projectsveltos.io/claudie-probed-at"
+
+	// defaultConnectivityProbeTimeout is the default time the connectivity probe waits for the API server
+	defaultConnectivityProbeTimeout = 10 * time.Second
+)
+
+// getConnectivityProbeTimeout returns how long the connectivity probe waits for the API server
+func (r *SecretReconciler) getConnectivityProbeTimeout() time.Duration {
+	if r.ConnectivityProbeTimeout <= 0 {
+		return defaultConnectivityProbeTimeout
+	}
+	return r.ConnectivityProbeTimeout
+}
+
+// probeConnectivity issues a /version request to the API server kubeconfig points to. Server
+// certificate is verified against the kubeconfig CA, as Sveltos does.
+func probeConnectivity(ctx context.Context, kubeconfig []byte, timeout time.Duration) error {
+	restConfig, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return err
+	}
+	restConfig.Timeout = timeout
+
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+
+	return discoveryClient.RESTClient().Get().AbsPath("/version").Do(ctx).Error()
+}
+
+// getKubeconfigToProbe returns the kubeconfig Sveltos uses to reach the cluster: the mirrored one,
+// when secret kubeconfig is mirrored, otherwise the one in secret
+func (r *SecretReconciler) getKubeconfigToProbe(secret *corev1.Secret, mirroredKubeconfig []byte) []byte {
+	if mirroredKubeconfig != nil {
+		return mirroredKubeconfig
+	}
+
+	kubeconfig, err := r.getKubeconfigData(secret)
+	if err != nil {
+		return nil
+	}
+	return kubeconfig
+}
+
+// startConnectivityProbe, when ConnectivityProbe is set, checks in the background whether the cluster
+// is reachable with kubeconfig and reports the outcome on the SveltosCluster. The probe is best-effort
+// and time-bounded: it never blocks nor fails reconciliation.
+func (r *SecretReconciler) startConnectivityProbe(sveltosClusterKey types.NamespacedName, kubeconfig []byte,
+	logger logr.Logger) {
+
+	if !r.ConnectivityProbe || len(kubeconfig) == 0 {
+		return
+	}
+
+	r.probingMux.Lock()
+	defer r.probingMux.Unlock()
+	if r.probing == nil {
+		r.probing = make(map[types.NamespacedName]bool)
+	}
+	if r.probing[sveltosClusterKey] {
+		return
+	}
+	r.probing[sveltosClusterKey] = true
+
+	go func() {
+		defer func() {
+			r.probingMux.Lock()
+			defer r.probingMux.Unlock()
+			delete(r.probing, sveltosClusterKey)
+		}()
+
+		timeout := r.getConnectivityProbeTimeout()
+		ctx, cancel := context.WithTimeout(context.Background(), 2*timeout)
+		defer cancel()
+
+		reachable := true
+		if err := probeConnectivity(ctx, kubeconfig, timeout); err != nil {
+			logger.V(logs.LogInfo).Info(fmt.Sprintf("cluster is not reachable: %v", err))
+			reachable = false
+		}
+
+		if err := r.setReachable(ctx, sveltosClusterKey, reachable, time.Now()); err != nil {
+			logger.V(logs.LogInfo).Info(fmt.Sprintf("failed to report connectivity probe outcome: %v", err))
+		}
+	}()
+}
+
+// setReachable reports on the SveltosCluster the outcome of the connectivity probe. Only the probe
+// annotations are patched, so concurrent changes to the SveltosCluster are preserved.
+func (r *SecretReconciler) setReachable(ctx context.Context, sveltosClusterKey types.NamespacedName,
+	reachable bool, now time.Time) error {
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				reachableAnnotation: strconv.FormatBool(reachable),
+				probedAtAnnotation:  now.UTC().Format(time.RFC3339),
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	sveltosCluster := &unstructured.Unstructured{}
+	sveltosCluster.SetAPIVersion(r.getSveltosClusterAPIVersion())
+	sveltosCluster.SetKind(libsveltosv1alpha1.SveltosClusterKind)
+	sveltosCluster.SetNamespace(sveltosClusterKey.Namespace)
+	sveltosCluster.SetName(sveltosClusterKey.Name)
+
+	return client.IgnoreNotFound(r.Patch(ctx, sveltosCluster, client.RawPatch(types.MergePatchType, patch)))
+}
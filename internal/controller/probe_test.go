@@ -0,0 +1,131 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"gianlucam76/claudie-sveltos-integration/internal/controller"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+)
+
+var _ = Describe("Connectivity probe", func() {
+	var server *httptest.Server
+
+	BeforeEach(func() {
+		server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/version" {
+				http.NotFound(w, r)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"major":"1","minor":"31","gitVersion":"v1.31.1"}`))
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("reports a cluster reachable with a kubeconfig trusting its CA", func() {
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		secret.Data[controller.KubeconfigDataKey] = getProbeKubeconfig(server, true)
+
+		sveltosCluster := probeSveltosCluster(secret, true)
+		Expect(sveltosCluster.Annotations[controller.ReachableAnnotation]).To(Equal("true"))
+		_, err := time.Parse(time.RFC3339, sveltosCluster.Annotations[controller.ProbedAtAnnotation])
+		Expect(err).To(BeNil())
+	})
+
+	It("reports a cluster unreachable when its certificate cannot be verified", func() {
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		secret.Data[controller.KubeconfigDataKey] = getProbeKubeconfig(server, false)
+
+		sveltosCluster := probeSveltosCluster(secret, true)
+		Expect(sveltosCluster.Annotations[controller.ReachableAnnotation]).To(Equal("false"))
+		Expect(sveltosCluster.Annotations).To(HaveKey(controller.ProbedAtAnnotation))
+	})
+
+	It("does not probe when disabled", func() {
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		secret.Data[controller.KubeconfigDataKey] = getProbeKubeconfig(server, true)
+
+		sveltosCluster := probeSveltosCluster(secret, false)
+		Expect(sveltosCluster.Annotations).ToNot(HaveKey(controller.ReachableAnnotation))
+		Expect(sveltosCluster.Annotations).ToNot(HaveKey(controller.ProbedAtAnnotation))
+	})
+})
+
+// probeSveltosCluster creates the SveltosCluster for secret, with the connectivity probe enabled or
+// not, and returns it once the probe had time to report its outcome
+func probeSveltosCluster(secret *corev1.Secret, enabled bool) *libsveltosv1alpha1.SveltosCluster {
+	Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+	reconciler := getSecretReconciler(c)
+	reconciler.ConnectivityProbe = enabled
+	reconciler.ConnectivityProbeTimeout = 5 * time.Second
+
+	Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+
+	sveltosClusterKey := types.NamespacedName{Namespace: secret.Namespace, Name: secret.Labels[controller.ClaudieCluster]}
+	sveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+	probed := func() map[string]string {
+		Expect(c.Get(context.TODO(), sveltosClusterKey, sveltosCluster)).To(Succeed())
+		return sveltosCluster.Annotations
+	}
+
+	if enabled {
+		Eventually(probed, 10*time.Second, 100*time.Millisecond).Should(HaveKey(controller.ReachableAnnotation))
+	} else {
+		Consistently(probed, time.Second, 100*time.Millisecond).ShouldNot(HaveKey(controller.ReachableAnnotation))
+	}
+
+	return sveltosCluster
+}
+
+// getProbeKubeconfig returns a kubeconfig pointing to server. When trustCA is true, kubeconfig
+// carries server CA.
+func getProbeKubeconfig(server *httptest.Server, trustCA bool) []byte {
+	config := clientcmdapi.NewConfig()
+	config.Clusters["cluster-a"] = &clientcmdapi.Cluster{Server: server.URL}
+	if trustCA {
+		config.Clusters["cluster-a"].CertificateAuthorityData = pem.EncodeToMemory(
+			&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	}
+	config.AuthInfos["cluster-a"] = &clientcmdapi.AuthInfo{Token: randomString()}
+	config.Contexts["cluster-a"] = &clientcmdapi.Context{Cluster: "cluster-a", AuthInfo: "cluster-a"}
+	config.CurrentContext = "cluster-a"
+
+	data, err := clientcmd.Write(*config)
+	Expect(err).To(BeNil())
+	return data
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// getNamespaceThrottle consumes a reconcile token for namespace. It returns zero if the reconcile
+// can proceed, otherwise how long to wait before reconciling again, so that a namespace
+// flooding the controller does not starve the others.
+func (r *SecretReconciler) getNamespaceThrottle(namespace string) time.Duration {
+	if r.NamespaceReconcileRate <= 0 {
+		return 0
+	}
+
+	r.limitersMux.Lock()
+	defer r.limitersMux.Unlock()
+
+	if r.namespaceLimiters == nil {
+		r.namespaceLimiters = make(map[string]*rate.Limiter)
+	}
+
+	limiter, ok := r.namespaceLimiters[namespace]
+	if !ok {
+		burst := r.NamespaceReconcileBurst
+		if burst <= 0 {
+			burst = 1
+		}
+		limiter = rate.NewLimiter(rate.Limit(r.NamespaceReconcileRate), burst)
+		r.namespaceLimiters[namespace] = limiter
+	}
+
+	reservation := limiter.Reserve()
+	delay := reservation.Delay()
+	if delay > 0 {
+		// Do not consume the token. Reconcile will be retried after delay.
+		reservation.Cancel()
+	}
+
+	return delay
+}
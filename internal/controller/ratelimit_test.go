@@ -0,0 +1,60 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"gianlucam76/claudie-sveltos-integration/internal/controller"
+)
+
+var _ = Describe("Namespace rate limit", func() {
+	It("getNamespaceThrottle throttles a flooding namespace while others proceed", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := getSecretReconciler(c)
+		reconciler.NamespaceReconcileRate = 0.01
+		reconciler.NamespaceReconcileBurst = 2
+
+		flooding := randomString()
+		other := randomString()
+
+		// Burst is consumed
+		Expect(controller.GetNamespaceThrottle(reconciler, flooding)).To(BeZero())
+		Expect(controller.GetNamespaceThrottle(reconciler, flooding)).To(BeZero())
+
+		// Flooding namespace is now throttled
+		for i := 0; i < 5; i++ {
+			Expect(controller.GetNamespaceThrottle(reconciler, flooding)).To(BeNumerically(">", 0))
+		}
+
+		// Other namespaces are not affected
+		Expect(controller.GetNamespaceThrottle(reconciler, other)).To(BeZero())
+		Expect(controller.GetNamespaceThrottle(reconciler, other)).To(BeZero())
+	})
+
+	It("getNamespaceThrottle never throttles when rate is not set", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := getSecretReconciler(c)
+
+		namespace := randomString()
+		for i := 0; i < 10; i++ {
+			Expect(controller.GetNamespaceThrottle(reconciler, namespace)).To(BeZero())
+		}
+	})
+})
@@ -0,0 +1,81 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/go-logr/logr"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+	logs "github.com/projectsveltos/libsveltos/lib/logsettings"
+)
+
+// rebuildSecretToClusterMap repopulates SecretToCluster from existing SveltosClusters created for
+// Claudie Secrets. It runs before any event is processed, so that after a restart Secrets deleted
+// before their first reconciliation still have their SveltosCluster removed.
+// SveltosClusters released from Claudie management are not tracked.
+func (r *SecretReconciler) rebuildSecretToClusterMap(ctx context.Context, c client.Reader, logger logr.Logger) error {
+	sveltosClusters := &libsveltosv1alpha1.SveltosClusterList{}
+	if err := c.List(ctx, sveltosClusters); err != nil {
+		return err
+	}
+
+	for i := range sveltosClusters.Items {
+		sveltosCluster := &sveltosClusters.Items[i]
+		if !isSveltosClusterForClaudie(sveltosCluster) || isUnmanaged(sveltosCluster) {
+			continue
+		}
+
+		claudieSecret := getClaudieSecret(sveltosCluster)
+		if claudieSecret == nil {
+			continue
+		}
+
+		if owners := getClaudieSecrets(sveltosCluster); len(owners) > 1 {
+			logger.V(logs.LogInfo).Info(fmt.Sprintf(
+				"SveltosCluster %s/%s is owned by %d Secrets. Tracking it for %s",
+				sveltosCluster.Namespace, sveltosCluster.Name, len(owners), claudieSecret))
+		}
+
+		r.secretToCluster.Set(*claudieSecret,
+			types.NamespacedName{Namespace: sveltosCluster.Namespace, Name: sveltosCluster.Name})
+	}
+
+	logger.V(logs.LogInfo).Info(fmt.Sprintf("tracking %d SveltosClusters", r.secretToCluster.Len()))
+	return nil
+}
+
+// ReadyzCheck is a readiness check reporting ready only once SecretToCluster map has been rebuilt
+// and the cache has synced. Till then, cleanup of removed Secrets might be missed.
+func (r *SecretReconciler) ReadyzCheck(_ *http.Request) error {
+	if r.mapReady == nil {
+		return errors.New("controller not set up yet")
+	}
+
+	select {
+	case <-r.mapReady:
+		return nil
+	default:
+		return errors.New("SecretToCluster map not rebuilt yet")
+	}
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"gianlucam76/claudie-sveltos-integration/internal/controller"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+)
+
+var _ = Describe("SecretToCluster rebuild", func() {
+	It("rebuildSecretToClusterMap tracks SveltosClusters created for Claudie Secrets only", func() {
+		namespace := randomString()
+		secretName := randomString()
+
+		owned := &libsveltosv1alpha1.SveltosCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   namespace,
+				Name:        randomString(),
+				Annotations: map[string]string{controller.SveltosClusterClaudieAnnotation: "ok"},
+				OwnerReferences: []metav1.OwnerReference{
+					{APIVersion: "v1", Kind: "Secret", Name: secretName, UID: types.UID(randomString())},
+				},
+			},
+		}
+
+		crossNamespaceSecret := types.NamespacedName{Namespace: randomString(), Name: randomString()}
+		crossNamespace := &libsveltosv1alpha1.SveltosCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: randomString(),
+				Name:      randomString(),
+				Annotations: map[string]string{
+					controller.SveltosClusterClaudieAnnotation:         "ok",
+					controller.SveltosClusterSecretAnnotation:          crossNamespaceSecret.Name,
+					controller.SveltosClusterSecretNamespaceAnnotation: crossNamespaceSecret.Namespace,
+				},
+			},
+		}
+
+		unmanaged := &libsveltosv1alpha1.SveltosCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: namespace,
+				Name:      randomString(),
+				Annotations: map[string]string{
+					controller.SveltosClusterClaudieAnnotation: "ok",
+					controller.SveltosClusterSecretAnnotation:  randomString(),
+					controller.UnmanageAnnotation:              "true",
+				},
+			},
+		}
+
+		notForClaudie := &libsveltosv1alpha1.SveltosCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: namespace,
+				Name:      randomString(),
+				OwnerReferences: []metav1.OwnerReference{
+					{APIVersion: "v1", Kind: "Secret", Name: randomString(), UID: types.UID(randomString())},
+				},
+			},
+		}
+
+		c := fake.NewClientBuilder().WithScheme(scheme).
+			WithObjects(owned, crossNamespace, unmanaged, notForClaudie).Build()
+		reconciler := getSecretReconciler(c)
+
+		Expect(controller.RebuildSecretToClusterMap(reconciler, context.TODO(), c, logr.Logger{})).To(Succeed())
+		secretKey := types.NamespacedName{Namespace: namespace, Name: secretName}
+		Expect(reconciler.SecretToCluster()).To(HaveLen(2))
+		Expect(reconciler.SecretToCluster()).To(HaveKeyWithValue(secretKey, client.ObjectKeyFromObject(owned)))
+		Expect(reconciler.SecretToCluster()).To(HaveKeyWithValue(crossNamespaceSecret,
+			client.ObjectKeyFromObject(crossNamespace)))
+
+		// Secret was deleted while controller was down: SveltosCluster is removed right away
+		Expect(controller.CleanSveltosCluster(reconciler, context.TODO(), ctrl.Request{NamespacedName: secretKey},
+			logr.Logger{})).To(Succeed())
+		err := c.Get(context.TODO(), client.ObjectKeyFromObject(owned), &libsveltosv1alpha1.SveltosCluster{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("ReadyzCheck reports ready only once SecretToCluster map is rebuilt", func() {
+		reconciler := getSecretReconciler(fake.NewClientBuilder().WithScheme(scheme).Build())
+		Expect(reconciler.ReadyzCheck(nil)).ToNot(Succeed())
+
+		mapReady := make(chan struct{})
+		reconciler.SetMapReady(mapReady)
+		Expect(reconciler.ReadyzCheck(nil)).ToNot(Succeed())
+
+		close(mapReady)
+		Expect(reconciler.ReadyzCheck(nil)).To(Succeed())
+	})
+})
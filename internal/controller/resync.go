@@ -0,0 +1,113 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	logs "github.com/projectsveltos/libsveltos/lib/logsettings"
+)
+
+// resyncClaudieSecrets, every ResyncPeriod, enqueues all Claudie Secrets so SveltosClusters which
+// drifted are corrected even when events were missed. Secrets are sent to the controller through
+// events and reconciled by its workers, so resyncs honor concurrency and rate limiting, and are
+// deduplicated with event triggered reconciliations. It returns when ctx is canceled.
+func (r *SecretReconciler) resyncClaudieSecrets(ctx context.Context, mapReady <-chan struct{},
+	events chan<- event.GenericEvent, logger logr.Logger) {
+
+	select {
+	case <-ctx.Done():
+		return
+	case <-mapReady:
+	}
+
+	ticker := time.NewTicker(r.ResyncPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.V(logs.LogInfo).Info("stopping periodic resync")
+			return
+		case <-ticker.C:
+			r.enqueueClaudieSecrets(ctx, events, logger)
+		}
+	}
+}
+
+// enqueueClaudieSecrets lists all Claudie Secrets and sends an event for each one passing the
+// Secret filters
+func (r *SecretReconciler) enqueueClaudieSecrets(ctx context.Context, events chan<- event.GenericEvent,
+	logger logr.Logger) {
+
+	secrets, err := r.listManagedSecrets(ctx)
+	if err != nil {
+		logger.V(logs.LogInfo).Info(fmt.Sprintf("failed to list Claudie Secrets: %v", err))
+		return
+	}
+
+	logger.V(logs.LogDebug).Info(fmt.Sprintf("resyncing %d Claudie Secrets", len(secrets)))
+
+	for i := range secrets {
+		secret := secrets[i]
+		if !r.SecretFilters.matches(secret) || !r.shouldReconcileSecret(secret) {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case events <- event.GenericEvent{Object: secret}:
+		}
+	}
+}
+
+// listManagedSecrets lists the Secrets carrying the labels of any Secret convention this controller
+// manages. Each Secret is returned once, even when it carries the labels of more than one convention.
+func (r *SecretReconciler) listManagedSecrets(ctx context.Context) ([]*corev1.Secret, error) {
+	result := make([]*corev1.Secret, 0)
+	seen := make(map[types.NamespacedName]bool)
+
+	classifiers := r.getSecretClassifiers()
+	for i := range classifiers {
+		secrets := &corev1.SecretList{}
+		err := r.List(ctx, secrets, client.HasLabels(classifiers[i].getRequiredLabels()))
+		if err != nil {
+			return nil, err
+		}
+
+		for j := range secrets.Items {
+			secret := &secrets.Items[j]
+			key := client.ObjectKeyFromObject(secret)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			result = append(result, secret)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	"gianlucam76/claudie-sveltos-integration/internal/controller"
+)
+
+var _ = Describe("Resync", func() {
+	It("enqueueClaudieSecrets enqueues only Claudie Secrets passing the filters", func() {
+		claudieSecret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+
+		skippedSecret := getClaudieSecretWithKubeconfig("cluster-b", "cluster-b")
+		skippedSecret.Annotations = map[string]string{controller.SkipAnnotation: "true"}
+
+		unlabeledSecret := getClaudieSecretWithKubeconfig("cluster-c", "cluster-c")
+		delete(unlabeledSecret.Labels, controller.ClaudieCluster)
+
+		c := fake.NewClientBuilder().WithScheme(scheme).
+			WithObjects(claudieSecret, skippedSecret, unlabeledSecret).Build()
+		reconciler := getSecretReconciler(c)
+
+		events := make(chan event.GenericEvent, 10)
+		controller.EnqueueClaudieSecrets(reconciler, context.TODO(), events, logr.Logger{})
+		close(events)
+
+		enqueued := make([]client.ObjectKey, 0)
+		for e := range events {
+			enqueued = append(enqueued, client.ObjectKeyFromObject(e.Object))
+		}
+		Expect(enqueued).To(ConsistOf(client.ObjectKeyFromObject(claudieSecret)))
+	})
+})
@@ -0,0 +1,82 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/types"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+	logs "github.com/projectsveltos/libsveltos/lib/logsettings"
+)
+
+const (
+	// retainAnnotation, when set to "true" on a SveltosCluster, keeps it when its Claudie Secret
+	// is deleted. Set on every SveltosCluster when RetainOnSecretDelete is true, and can be set
+	// by users on single SveltosClusters.
+	retainAnnotation = "projectsveltos.io/claudie-retain-on-secret-delete"
+)
+
+// isRetained returns true if sveltosCluster must be kept when its Claudie Secret is deleted
+func isRetained(sveltosCluster *libsveltosv1alpha1.SveltosCluster) bool {
+	return sveltosCluster.Annotations[retainAnnotation] == "true"
+}
+
+// addRetainAnnotation marks sveltosCluster to be retained on Secret deletion, when RetainOnSecretDelete
+// is set. Annotation is otherwise left untouched, as users might have set it.
+func (r *SecretReconciler) addRetainAnnotation(sveltosCluster *libsveltosv1alpha1.SveltosCluster) {
+	if !r.RetainOnSecretDelete {
+		return
+	}
+
+	if sveltosCluster.Annotations == nil {
+		sveltosCluster.Annotations = make(map[string]string)
+	}
+	sveltosCluster.Annotations[retainAnnotation] = "true"
+}
+
+// retainSveltosCluster keeps sveltosCluster whose Claudie Secret is being deleted. Secret OwnerReferences
+// are removed, so the SveltosCluster is not garbage collected once the Secret is gone. Secret stops
+// being tracked, without being marked as deleting, so SveltosCluster is updated right away if Secret
+// is recreated.
+func (r *SecretReconciler) retainSveltosCluster(ctx context.Context, secretKey, sveltosClusterInfo types.NamespacedName,
+	sveltosCluster *libsveltosv1alpha1.SveltosCluster, logger logr.Logger) error {
+
+	logger.V(logs.LogInfo).Info("retention active. SveltosCluster is kept after Secret deletion")
+
+	if hasSecretOwnerReferences(sveltosCluster) {
+		removeSecretOwnerReferences(sveltosCluster)
+		if err := r.writeSveltosCluster(ctx, sveltosCluster, false); err != nil {
+			return err
+		}
+	}
+
+	r.forgetSveltosCluster(secretKey, sveltosClusterInfo, false)
+	return nil
+}
+
+// hasSecretOwnerReferences returns true if any Secret owns sveltosCluster
+func hasSecretOwnerReferences(sveltosCluster *libsveltosv1alpha1.SveltosCluster) bool {
+	for i := range sveltosCluster.OwnerReferences {
+		if sveltosCluster.OwnerReferences[i].Kind == "Secret" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"gianlucam76/claudie-sveltos-integration/internal/controller"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+)
+
+var _ = Describe("Retain on Secret delete", func() {
+	It("Reconcile keeps SveltosCluster once Secret is deleted when RetainOnSecretDelete is set", func() {
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+		reconciler := getSecretReconciler(c)
+		reconciler.RetainOnSecretDelete = true
+
+		secretRef := reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name},
+		}
+		_, err := reconciler.Reconcile(context.TODO(), secretRef)
+		Expect(err).To(BeNil())
+
+		sveltosClusterKey := types.NamespacedName{Namespace: secret.Namespace, Name: secret.Labels[controller.ClaudieCluster]}
+		sveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+		Expect(c.Get(context.TODO(), sveltosClusterKey, sveltosCluster)).To(Succeed())
+		Expect(sveltosCluster.Annotations).To(HaveKeyWithValue(controller.RetainAnnotation, "true"))
+		// Ownership is tracked via annotation so SveltosCluster is not garbage collected
+		Expect(sveltosCluster.OwnerReferences).To(BeEmpty())
+		Expect(controller.GetClaudieSecret(sveltosCluster)).To(Equal(&secretRef.NamespacedName))
+
+		// Secret carries the cleanup finalizer, so it is removed only once reconciled
+		Expect(c.Get(context.TODO(), secretRef.NamespacedName, secret)).To(Succeed())
+		Expect(secret.Finalizers).To(ContainElement(controller.ClaudieCleanupFinalizer))
+		Expect(c.Delete(context.TODO(), secret)).To(Succeed())
+		_, err = reconciler.Reconcile(context.TODO(), secretRef)
+		Expect(err).To(BeNil())
+
+		err = c.Get(context.TODO(), secretRef.NamespacedName, secret)
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		Expect(c.Get(context.TODO(), sveltosClusterKey, sveltosCluster)).To(Succeed())
+		Expect(reconciler.SecretToCluster()).To(BeEmpty())
+	})
+
+	It("cleanSveltosCluster keeps retained SveltosClusters and drops Secret OwnerReferences", func() {
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		sveltosCluster := &libsveltosv1alpha1.SveltosCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: secret.Namespace,
+				Name:      randomString(),
+				Annotations: map[string]string{
+					controller.SveltosClusterClaudieAnnotation: "ok",
+					controller.RetainAnnotation:                "true",
+				},
+				OwnerReferences: []metav1.OwnerReference{
+					{Kind: "Secret", APIVersion: "v1", Name: secret.Name},
+				},
+			},
+		}
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(sveltosCluster).Build()
+		reconciler := getSecretReconciler(c)
+		secretKey := types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name}
+		reconciler.TrackSveltosCluster(secretKey, client.ObjectKeyFromObject(sveltosCluster))
+
+		Expect(controller.CleanSveltosCluster(reconciler, context.TODO(),
+			reconcile.Request{NamespacedName: secretKey}, logr.Logger{})).To(Succeed())
+
+		Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(sveltosCluster), sveltosCluster)).To(Succeed())
+		Expect(sveltosCluster.OwnerReferences).To(BeEmpty())
+		Expect(reconciler.SecretToCluster()).To(BeEmpty())
+	})
+
+	It("removeStaleSveltosClusters never removes retained SveltosClusters", func() {
+		sveltosCluster := &libsveltosv1alpha1.SveltosCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: randomString(),
+				Name:      randomString(),
+				Annotations: map[string]string{
+					controller.SveltosClusterClaudieAnnotation: "ok",
+					controller.SveltosClusterSecretAnnotation:  randomString(),
+					controller.RetainAnnotation:                "true",
+				},
+			},
+		}
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(sveltosCluster).Build()
+
+		mapReady := make(chan struct{})
+		close(mapReady)
+		controller.RemoveStaleSveltosClusters(context.TODO(), c, c, controller.DefaultClaudieMetadata, 0, mapReady, logr.Logger{})
+
+		Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(sveltosCluster), sveltosCluster)).To(Succeed())
+	})
+})
@@ -0,0 +1,112 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+	logs "github.com/projectsveltos/libsveltos/lib/logsettings"
+)
+
+// rotation describes how a Secret relates to the Secret currently owning its SveltosCluster
+type rotation int
+
+const (
+	// rotationNone means Secrets are not two generations of the same cluster kubeconfig
+	rotationNone rotation = iota
+
+	// rotationNewer means Secret is a newer kubeconfig for the cluster of the current owner
+	rotationNewer
+
+	// rotationOlder means Secret is an older kubeconfig for the cluster of the current owner
+	rotationOlder
+)
+
+// getRotation returns whether secret rotates the kubeconfig of currentOwner. Claudie rotates a cluster
+// kubeconfig by writing a new Secret for the same cluster: the most recently created one is preferred.
+// Only Secrets of the same cluster and of the same Claudie project are a rotation: same cluster names
+// in different projects (or Secrets with no project label) are distinct clusters, subject to ConflictPolicy.
+// Secrets created within the same second cannot be ordered and are not considered a rotation.
+func (r *SecretReconciler) getRotation(ctx context.Context, secret *corev1.Secret,
+	currentOwner types.NamespacedName) (rotation, error) {
+
+	owner := &corev1.Secret{}
+	err := r.Get(ctx, currentOwner, owner)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return rotationNone, nil
+		}
+		return rotationNone, err
+	}
+
+	if !owner.DeletionTimestamp.IsZero() || !r.shouldReconcileSecret(owner) {
+		return rotationNone, nil
+	}
+
+	if r.getClusterName(owner) != r.getClusterName(secret) {
+		return rotationNone, nil
+	}
+
+	if project := owner.Labels[claudieProject]; project == "" || project != secret.Labels[claudieProject] {
+		return rotationNone, nil
+	}
+
+	switch {
+	case owner.CreationTimestamp.Before(&secret.CreationTimestamp):
+		return rotationNewer, nil
+	case secret.CreationTimestamp.Before(&owner.CreationTimestamp):
+		return rotationOlder, nil
+	default:
+		return rotationNone, nil
+	}
+}
+
+// handleRotation verifies whether secret and the Secret currently owning sveltosCluster are two generations
+// of the same cluster kubeconfig. Returns handled set to true if so, along with whether reconciliation of
+// SveltosCluster should proceed (it does only when secret is the newer one).
+func (r *SecretReconciler) handleRotation(ctx context.Context, sveltosCluster *libsveltosv1alpha1.SveltosCluster,
+	secret *corev1.Secret, currentOwner types.NamespacedName, logger logr.Logger) (handled, proceed bool, err error) {
+
+	rot, err := r.getRotation(ctx, secret, currentOwner)
+	if err != nil {
+		return false, false, err
+	}
+
+	switch rot {
+	case rotationNewer:
+		msg := fmt.Sprintf("SveltosCluster %s/%s moved from Secret %s to newer Secret %s",
+			sveltosCluster.Namespace, sveltosCluster.Name, currentOwner.Name, secret.Name)
+		logger.V(logs.LogInfo).Info(msg)
+		r.recordEvent(secret, corev1.EventTypeNormal, reasonKubeconfigRotated, msg)
+		return true, true, nil
+	case rotationOlder:
+		msg := fmt.Sprintf("SveltosCluster %s/%s uses newer Secret %s",
+			sveltosCluster.Namespace, sveltosCluster.Name, currentOwner.Name)
+		logger.V(logs.LogDebug).Info(msg)
+		r.recordEvent(secret, corev1.EventTypeNormal, reasonSecretSuperseded, msg)
+		return true, false, nil
+	default:
+		return false, false, nil
+	}
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"gianlucam76/claudie-sveltos-integration/internal/controller"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+)
+
+var _ = Describe("Kubeconfig rotation", func() {
+	var oldSecret, newSecret *corev1.Secret
+	var sveltosClusterKey types.NamespacedName
+
+	BeforeEach(func() {
+		creation := time.Now().Add(-time.Hour)
+
+		oldSecret = getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		oldSecret.Labels[controller.ClaudieProject] = "project-a"
+		oldSecret.CreationTimestamp = metav1.NewTime(creation)
+		Expect(addTypeInformationToObject(scheme, oldSecret)).To(Succeed())
+
+		newSecret = getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		newSecret.Namespace = oldSecret.Namespace
+		newSecret.Labels[controller.ClaudieCluster] = oldSecret.Labels[controller.ClaudieCluster]
+		newSecret.Labels[controller.ClaudieProject] = oldSecret.Labels[controller.ClaudieProject]
+		newSecret.CreationTimestamp = metav1.NewTime(creation.Add(time.Minute))
+		Expect(addTypeInformationToObject(scheme, newSecret)).To(Succeed())
+
+		sveltosClusterKey = types.NamespacedName{
+			Namespace: oldSecret.Namespace,
+			Name:      oldSecret.Labels[controller.ClaudieCluster],
+		}
+	})
+
+	It("moves SveltosCluster to the newer Secret and keeps it when the older one is deleted", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(oldSecret, newSecret).Build()
+		reconciler := getSecretReconciler(c)
+		recorder := record.NewFakeRecorder(10)
+		reconciler.EventRecorder = recorder
+
+		oldRef := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(oldSecret)}
+		newRef := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(newSecret)}
+
+		_, err := reconciler.Reconcile(context.TODO(), oldRef)
+		Expect(err).To(BeNil())
+		sveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+		Expect(c.Get(context.TODO(), sveltosClusterKey, sveltosCluster)).To(Succeed())
+		Expect(sveltosCluster.Spec.KubeconfigName).To(Equal(oldSecret.Name))
+
+		// Newer Secret arrives: SveltosCluster moves to it, even with the default (Refuse) ConflictPolicy
+		_, err = reconciler.Reconcile(context.TODO(), newRef)
+		Expect(err).To(BeNil())
+		Expect(c.Get(context.TODO(), sveltosClusterKey, sveltosCluster)).To(Succeed())
+		Expect(sveltosCluster.Spec.KubeconfigName).To(Equal(newSecret.Name))
+		Expect(controller.GetClaudieSecret(sveltosCluster)).To(Equal(&newRef.NamespacedName))
+		Expect(reconciler.SecretToCluster()).To(HaveKey(newRef.NamespacedName))
+		Expect(reconciler.SecretToCluster()).ToNot(HaveKey(oldRef.NamespacedName))
+		Eventually(recorder.Events).Should(Receive(ContainSubstring(controller.ReasonKubeconfigRotated)))
+
+		// Older Secret is reconciled again: it does not take SveltosCluster back
+		_, err = reconciler.Reconcile(context.TODO(), oldRef)
+		Expect(err).To(BeNil())
+		Expect(c.Get(context.TODO(), sveltosClusterKey, sveltosCluster)).To(Succeed())
+		Expect(sveltosCluster.Spec.KubeconfigName).To(Equal(newSecret.Name))
+		Eventually(recorder.Events).Should(Receive(ContainSubstring(controller.ReasonSecretSuperseded)))
+
+		// Older Secret is deleted: SveltosCluster is kept and older Secret is released
+		currentOldSecret := &corev1.Secret{}
+		Expect(c.Get(context.TODO(), oldRef.NamespacedName, currentOldSecret)).To(Succeed())
+		Expect(c.Delete(context.TODO(), currentOldSecret)).To(Succeed())
+		_, err = reconciler.Reconcile(context.TODO(), oldRef)
+		Expect(err).To(BeNil())
+
+		Expect(c.Get(context.TODO(), sveltosClusterKey, sveltosCluster)).To(Succeed())
+		Expect(sveltosCluster.Spec.KubeconfigName).To(Equal(newSecret.Name))
+		Expect(controller.GetClaudieSecret(sveltosCluster)).To(Equal(&newRef.NamespacedName))
+		err = c.Get(context.TODO(), oldRef.NamespacedName, currentOldSecret)
+		Expect(client.IgnoreNotFound(err)).To(BeNil())
+		Expect(err).ToNot(BeNil())
+	})
+
+	It("never moves SveltosCluster back to an older Secret", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(oldSecret, newSecret).Build()
+		reconciler := getSecretReconciler(c)
+
+		_, err := reconciler.Reconcile(context.TODO(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(newSecret)})
+		Expect(err).To(BeNil())
+		_, err = reconciler.Reconcile(context.TODO(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(oldSecret)})
+		Expect(err).To(BeNil())
+
+		sveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+		Expect(c.Get(context.TODO(), sveltosClusterKey, sveltosCluster)).To(Succeed())
+		Expect(sveltosCluster.Spec.KubeconfigName).To(Equal(newSecret.Name))
+		Expect(reconciler.SecretToCluster()).ToNot(HaveKey(client.ObjectKeyFromObject(oldSecret)))
+	})
+
+	DescribeTable("does not consider Secrets of different projects a rotation",
+		func(newProject string) {
+			if newProject == "" {
+				delete(newSecret.Labels, controller.ClaudieProject)
+			} else {
+				newSecret.Labels[controller.ClaudieProject] = newProject
+			}
+
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(oldSecret, newSecret).Build()
+			reconciler := getSecretReconciler(c)
+			recorder := record.NewFakeRecorder(10)
+			reconciler.EventRecorder = recorder
+
+			oldRef := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(oldSecret)}
+			newRef := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(newSecret)}
+
+			_, err := reconciler.Reconcile(context.TODO(), oldRef)
+			Expect(err).To(BeNil())
+			Expect(recorder.Events).To(Receive(ContainSubstring(controller.ReasonSveltosClusterCreated)))
+
+			// Newer Secret of another project is a collision, refused by the default ConflictPolicy
+			_, err = reconciler.Reconcile(context.TODO(), newRef)
+			Expect(err).To(BeNil())
+			Expect(recorder.Events).To(Receive(ContainSubstring(controller.ReasonSveltosClusterConflict)))
+
+			sveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+			Expect(c.Get(context.TODO(), sveltosClusterKey, sveltosCluster)).To(Succeed())
+			Expect(sveltosCluster.Spec.KubeconfigName).To(Equal(oldSecret.Name))
+			Expect(controller.GetClaudieSecret(sveltosCluster)).To(Equal(&oldRef.NamespacedName))
+			Expect(reconciler.SecretToCluster()).To(HaveKey(oldRef.NamespacedName))
+			Expect(reconciler.SecretToCluster()).ToNot(HaveKey(newRef.NamespacedName))
+		},
+		Entry("different project", "project-b"),
+		Entry("no project label", ""),
+	)
+})
@@ -19,20 +19,32 @@ package controller
 import (
 	"context"
 	"fmt"
+	"runtime/debug"
 	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
+	"golang.org/x/time/rate"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
 	logs "github.com/projectsveltos/libsveltos/lib/logsettings"
@@ -44,287 +56,1510 @@ type SecretReconciler struct {
 	Scheme               *runtime.Scheme
 	ConcurrentReconciles int
 
-	// use a Mutex to update Map as MaxConcurrentReconciles is higher than one
-	Mux sync.Mutex
+	// ConflictPolicy defines what to do when the SveltosCluster for a Claudie Secret
+	// already exists and it is owned by a different Secret
+	ConflictPolicy ConflictPolicy
+
+	// EventRecorder is used to record events on Claudie Secrets
+	EventRecorder record.EventRecorder
+
+	// EventFilter defines which event types are recorded
+	EventFilter EventFilter
+
+	// AutoTargetLabelKey and AutoTargetLabelValue, when AutoTargetLabelKey is set, define a label
+	// stamped on every SveltosCluster created for a Claudie Secret. A single ClusterProfile with
+	// a matching clusterSelector can then deploy baseline add-ons to all Claudie clusters.
+	AutoTargetLabelKey   string
+	AutoTargetLabelValue string
+
+	// MinClaudieVersion, when set, is the minimum Claudie version supported. Secrets produced
+	// by older Claudie versions are skipped.
+	MinClaudieVersion *version.Version
+
+	// DeletionRetention is how long, after a Secret cleanup, its SecretToCluster entry is retained
+	// (marked as deleting). Reconciliations of such Secret within this window do not recreate the
+	// SveltosCluster, so late-arriving duplicate events do not cause a recreate-then-delete cycle.
+	// Zero disables retention.
+	DeletionRetention time.Duration
+
+	// SpecDefaults, when set, contains the Spec used when creating a SveltosCluster,
+	// optionally keyed by Claudie provider
+	SpecDefaults *SpecDefaults
+
+	// NamespaceReconcileRate, when positive, is the maximum number of reconciles per second
+	// for Claudie Secrets in the same namespace. NamespaceReconcileBurst is the burst allowed
+	// on top of it. Reconciles exceeding the rate are requeued.
+	NamespaceReconcileRate  float64
+	NamespaceReconcileBurst int
+
+	// SecretFilters are applied to Secrets before they are enqueued for reconciliation
+	SecretFilters SecretFilters
+
+	// SkipOwnerReferences, when true, Claudie Secrets are not added as SveltosCluster OwnerReferences.
+	// The owning Secret is tracked via annotation only. Useful when SveltosCluster metadata is managed
+	// by an external (GitOps) tool.
+	SkipOwnerReferences bool
+
+	// ControllerOwnerReference, when true, marks the Secret OwnerReference of SveltosClusters as the
+	// controller one, with BlockOwnerDeletion set, so Kubernetes garbage collection treats the Claudie
+	// Secret as the SveltosCluster managing owner and other controllers cannot claim it.
+	ControllerOwnerReference bool
+
+	// DriftReconcileInterval, when positive, is the interval at which all managed SveltosClusters are
+	// reconciled, independently of Secret events, to correct drift. Zero disables it.
+	DriftReconcileInterval time.Duration
+
+	// ResyncPeriod, when positive, is the interval at which all Claudie Secrets are listed and
+	// enqueued for reconciliation, so drift is corrected even when events are missed. Zero disables it.
+	ResyncPeriod time.Duration
+
+	// ReconcileCallback, when set, is invoked after each reconciliation with the request and its outcome.
+	// Used by tests to wait for a specific Secret to be reconciled, and by embedders to observe progress.
+	ReconcileCallback func(req ctrl.Request, result ctrl.Result, err error)
+
+	// SveltosClusterVersion is the lib.projectsveltos.io API version SveltosClusters are written at
+	// (e.g. v1beta1). Defaults, when empty, to the version this controller is built against.
+	SveltosClusterVersion string
+
+	// AnnotateSecret, when true, makes the controller record on each Claudie Secret, as annotation,
+	// the namespace/name of the SveltosCluster created for it
+	AnnotateSecret bool
+
+	// UniqueNameSuffix, when true, appends a hash derived from the Secret namespace/name to the
+	// SveltosCluster name whenever the cluster label had to be sanitized, so different labels
+	// sanitizing to the same name do not collide
+	UniqueNameSuffix bool
+
+	// RetainOnLabelRemoval, when true, leaves the SveltosCluster in place when its Secret loses
+	// the Claudie labels. By default such SveltosCluster is removed, and recreated if labels are
+	// added back.
+	RetainOnLabelRemoval bool
+
+	// EnforcedSpecFields, when not empty, lists the SveltosCluster Spec fields (e.g. kubeconfigName)
+	// this controller is allowed to overwrite when updating an existing SveltosCluster.
+	// Fields outside the list are never touched on update.
+	EnforcedSpecFields []string
+
+	// SpecMapping maps Claudie Secret annotation keys to SveltosCluster Spec field paths (JSON field
+	// names separated by dots). Annotation values are converted to the field type and applied on
+	// create and update.
+	SpecMapping map[string]string
+
+	// BatchWindow, when positive, delays processing of Secrets not yet tracked so that Secrets
+	// appearing within the same window are processed together once the window closes.
+	BatchWindow time.Duration
+
+	// batchMux protects batchedSecrets and batchDeadline
+	batchMux sync.Mutex
+
+	// batchedSecrets contains Secrets waiting for their batch window to close, with the batch deadline
+	batchedSecrets map[types.NamespacedName]time.Time
+
+	// batchDeadline is the time the currently open batch closes
+	batchDeadline time.Time
+
+	// AdoptionPolicy defines what to do when the SveltosCluster for a Secret already exists
+	// but was not created by this controller. Defaults to AdoptionPolicyAdopt.
+	AdoptionPolicy AdoptionPolicy
+
+	// KubeconfigDataKey is the key, in the Claudie Secret, containing the kubeconfig.
+	// When empty, "kubeconfig" is used. If the key is not present, the value of the Claudie
+	// output label is tried.
+	KubeconfigDataKey string
+
+	// ClaudieLabel, ClaudieKubeconfigLabel and ClaudieClusterLabel are the label keys a Secret
+	// must carry to be reconciled. ClaudieClusterLabel value is used as SveltosCluster name.
+	// When empty, the label keys set by Claudie are used.
+	ClaudieLabel           string
+	ClaudieKubeconfigLabel string
+	ClaudieClusterLabel    string
+
+	// ClusterAPISecrets, when true, SveltosClusters are created for Cluster API kubeconfig Secrets
+	// ({cluster}-kubeconfig) as well, on top of Claudie ones. Cluster name is used as SveltosCluster name.
+	ClusterAPISecrets bool
+
+	// ClusterProfileStub, when set, is the template of a ClusterProfile (or any other Sveltos profile kind)
+	// created for each SveltosCluster, targeting only such SveltosCluster, so baseline add-ons are deployed
+	// right away. Stub is owned by the SveltosCluster and removed with it.
+	ClusterProfileStub *unstructured.Unstructured
+
+	// AuditSink, when set, is notified of every SveltosCluster create, update and delete
+	AuditSink AuditSink
+
+	// NamespaceMap maps Claudie Secret namespaces to the namespace their SveltosClusters are created in.
+	// Secrets in namespaces not in the map have their SveltosCluster in the Secret namespace.
+	NamespaceMap map[string]string
+
+	// WatchNamespaces, when set, lists the namespaces the controller cache is restricted to, on top of the
+	// NamespaceMap targets. SveltosClusters cannot be created in any other namespace.
+	WatchNamespaces []string
+
+	// PropagatedLabels lists the label keys copied, when present, from the Claudie Secret to the
+	// SveltosCluster, so ClusterProfiles can match on them. Other SveltosCluster labels are never touched.
+	PropagatedLabels []string
+
+	// LabelMapping maps Claudie Secret annotation keys to the SveltosCluster label keys their values are
+	// copied to (e.g. claudie.io/provider -> topology.kubernetes.io/region). Other labels are never touched.
+	LabelMapping map[string]string
+
+	// ExtraAnnotations are added to every SveltosCluster created for a Claudie Secret (e.g. cost-center,
+	// team ownership) and enforced on update. Other SveltosCluster annotations are never touched.
+	ExtraAnnotations map[string]string
+
+	// RetainOnSecretDelete, when true, keeps SveltosClusters when their Claudie Secret is deleted.
+	// Secrets are not added as SveltosCluster OwnerReferences (ownership is tracked via annotation)
+	// so SveltosClusters are not garbage collected either.
+	RetainOnSecretDelete bool
+
+	// StaleGracePeriod, when positive, is how long the Claudie Secret of a SveltosCluster must be missing
+	// before the stale sweep deletes the SveltosCluster. Secrets briefly disappearing (e.g. during Claudie
+	// upgrades) then cause no SveltosCluster churn. Zero deletes on the first sweep finding Secret missing.
+	StaleGracePeriod time.Duration
+
+	// APICallTimeout, when positive, bounds each call this controller makes to the API server, so a
+	// slow API server makes reconciliations fail and be requeued instead of stalling workers.
+	// Zero disables it.
+	APICallTimeout time.Duration
+
+	// ConnectivityProbe, when true, makes the controller check, in the background, whether the cluster
+	// API server is reachable with the kubeconfig every time a SveltosCluster is created or its
+	// kubeconfig changes. Outcome is reported with the reachable and probed-at annotations.
+	// ConnectivityProbeTimeout bounds each probe. Defaults to defaultConnectivityProbeTimeout.
+	ConnectivityProbe        bool
+	ConnectivityProbeTimeout time.Duration
+
+	// ServerSideApply, when true, makes existing SveltosClusters be updated with Server-Side Apply,
+	// using the claudie-sveltos-integration field manager, instead of replacing the whole object.
+	// Only the fields this controller owns are applied, so fields managed by users or other
+	// controllers are never overwritten.
+	ServerSideApply bool
+
+	// FailureBackoffBase is how long the controller rate limiter waits before retrying a failed
+	// reconciliation. The delay doubles on every consecutive failure of the same Secret, up to
+	// FailureBackoffMax, and is reset on success.
+	// Default to normalRequeueAfter and defaultFailureBackoffMax.
+	FailureBackoffBase time.Duration
+	FailureBackoffMax  time.Duration
+
+	// StaleSweepInterval is the interval at which SveltosClusters whose Claudie Secret does not exist
+	// anymore are looked for and removed. Defaults to defaultStaleSweepInterval.
+	StaleSweepInterval time.Duration
+
+	// DisableStaleSweep, when true, the stale sweep is never started. Cleanup then relies solely on
+	// Secret events, the cleanup finalizer and garbage collection: SveltosClusters whose Secret was
+	// deleted while this controller was not watching are not removed.
+	DisableStaleSweep bool
 
 	// When a cluster is created with Claudie, a Secret is created
 	// by Claudie containing the Kubeconfig to access such cluster.
 	// This controller automatically creates a SveltosCluster for each Claudie cluster,
 	// i.e when a Claudie Secret containing a cluster kubeconfig is detected, a SveltosCluster
 	// instance is created.
-	// This map contains the Claudie secret to SveltosCluster association
-	SecretToCluster map[types.NamespacedName]types.NamespacedName
+	// secretToCluster contains the Claudie secret to SveltosCluster association
+	secretToCluster clusterTracker
+
+	// mapReady is closed once the cache is synced (see SetupWithManager)
+	mapReady chan struct{}
+
+	// limitersMux protects namespaceLimiters
+	limitersMux sync.Mutex
+
+	// namespaceLimiters contains the per namespace rate limiters
+	namespaceLimiters map[string]*rate.Limiter
+
+	// probingMux protects probing
+	probingMux sync.Mutex
+
+	// probing contains SveltosClusters a connectivity probe is running for
+	probing map[types.NamespacedName]bool
+
+	// deletingMux protects deletingSecrets
+	deletingMux sync.Mutex
+
+	// deletingSecrets contains Secrets whose SveltosCluster was removed, with the time removal happened
+	deletingSecrets map[types.NamespacedName]time.Time
 }
 
+// ConflictPolicy defines how to handle a SveltosCluster already owned by a different Secret
+type ConflictPolicy string
+
+const (
+	// ConflictPolicyRefuse leaves the SveltosCluster untouched and reports the conflict
+	// with an Event on the Secret
+	ConflictPolicyRefuse = ConflictPolicy("Refuse")
+
+	// ConflictPolicyTakeOver makes the reconciled Secret the new owner of the SveltosCluster
+	ConflictPolicyTakeOver = ConflictPolicy("TakeOver")
+)
+
+// AdoptionPolicy defines how to handle a SveltosCluster which matches the name computed for a Secret
+// but is neither annotated as managed by this controller nor owned by any Secret
+type AdoptionPolicy string
+
+const (
+	// AdoptionPolicyAdopt makes the reconciled Secret the owner of the SveltosCluster and reports
+	// the adoption with an Event on the Secret
+	AdoptionPolicyAdopt = AdoptionPolicy("Adopt")
+
+	// AdoptionPolicyRefuse leaves the SveltosCluster untouched, reports it with a Warning Event
+	// on the Secret and retries later
+	AdoptionPolicyRefuse = AdoptionPolicy("Refuse")
+
+	// AdoptionPolicySkip leaves the SveltosCluster untouched, reports it with an Event on the Secret
+	// and does not retry
+	AdoptionPolicySkip = AdoptionPolicy("Skip")
+)
+
+const (
+	// DefaultClaudieLabel, DefaultClaudieKubeconfigLabel and DefaultClaudieClusterLabel are the
+	// label keys Claudie sets on the Secrets containing cluster kubeconfigs
+	DefaultClaudieLabel           = claudieLabel
+	DefaultClaudieKubeconfigLabel = claudieKubeconfig
+	DefaultClaudieClusterLabel    = claudieCluster
+
+	// DefaultConcurrentReconciles is the default maximum number of concurrent reconciliations
+	DefaultConcurrentReconciles = 10
+)
+
 const (
 	claudieLabel      = "app.kubernetes.io/part-of"
 	claudieKubeconfig = "claudie.io/output"
 	claudieCluster    = "claudie.io/cluster"
 
+	// claudieProject is the label Claudie sets, on every Secret it writes, to the name of the
+	// project (InputManifest) the cluster belongs to
+	claudieProject = "claudie.io/project"
+
+	// claudieVersionAnnotation, if present on a Claudie Secret, contains the version of
+	// Claudie which produced it
+	claudieVersionAnnotation = "claudie.io/version"
+
 	sveltosClusterClaudieAnnotation = "projectsveltos.io/claudie"
+
+	// sveltosClusterSecretAnnotation contains the name of the Claudie Secret a SveltosCluster
+	// was created for. Used to track ownership when OwnerReferences are not added.
+	sveltosClusterSecretAnnotation = "projectsveltos.io/claudie-secret"
+
+	// sveltosClusterSecretNamespaceAnnotation contains the namespace of the Claudie Secret a SveltosCluster
+	// was created for. SveltosClusters created by previous versions only carry it when such namespace
+	// differs from the SveltosCluster one.
+	sveltosClusterSecretNamespaceAnnotation = "projectsveltos.io/claudie-secret-namespace"
+
+	// Region and zone labels on the Claudie Secret are reported as annotations on the
+	// SveltosCluster so operators can filter clusters geographically
+	regionLabel                    = "topology.kubernetes.io/region"
+	zoneLabel                      = "topology.kubernetes.io/zone"
+	sveltosClusterRegionAnnotation = "projectsveltos.io/claudie-region"
+	sveltosClusterZoneAnnotation   = "projectsveltos.io/claudie-zone"
 )
 
 const (
-	// normalRequeueAfter is how long to wait before reconciling again if a failure happened
+	// normalRequeueAfter is how long (with jitter) to wait before reconciling again while SveltosCluster
+	// is being deleted. It is also the default delay before retrying a failed reconciliation.
 	normalRequeueAfter = 10 * time.Second
+
+	// defaultStaleSweepInterval is the default interval between stale SveltosCluster sweeps
+	defaultStaleSweepInterval = 2 * time.Minute
+
+	// staleSweepPageSize is the maximum number of SveltosClusters fetched with each List call
+	// of the stale sweep
+	staleSweepPageSize = 500
 )
 
-//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
-//+kubebuilder:rbac:groups=lib.projectsveltos.io,resources=sveltosclusters,verbs=get;list;watch;update;patch;create;delete
+var (
+	// errSveltosClusterDeleting is returned when the SveltosCluster for a Secret is being deleted
+	errSveltosClusterDeleting = errors.New("SveltosCluster is being deleted")
+)
 
-func (r *SecretReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	logger := ctrl.LoggerFrom(ctx)
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;delete
+//+kubebuilder:rbac:groups="",resources=secrets/finalizers,verbs=update
+//+kubebuilder:rbac:groups=lib.projectsveltos.io,resources=sveltosclusters,verbs=get;list;watch;update;patch;create;delete
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+//+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get
+//+kubebuilder:rbac:groups=config.projectsveltos.io,resources=clusterprofiles;profiles,verbs=get;create;delete
+
+func (r *SecretReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, reterr error) {
+	baseLogger := ctrl.LoggerFrom(ctx)
+	logger := baseLogger.WithValues(logKeySecret, req.NamespacedName.String())
 	logger.V(logs.LogInfo).Info("Reconciling")
 
+	// Registered first so it runs last and observes the final outcome
+	defer func() {
+		if r.ReconcileCallback != nil {
+			r.ReconcileCallback(req, result, reterr)
+		}
+	}()
+
+	// A malformed Secret must not crash the controller. Recover, record and retry.
+	defer func() {
+		if p := recover(); p != nil {
+			reconcilePanics.Inc()
+			logger.Error(fmt.Errorf("%v", p), "recovered from panic while reconciling Secret",
+				"stacktrace", string(debug.Stack()))
+			result = reconcile.Result{}
+			reterr = fmt.Errorf("recovered from panic: %v", p)
+		}
+	}()
+
 	// Fecth the Secret instance
 	secret := &corev1.Secret{}
 	if err := r.Get(ctx, req.NamespacedName, secret); err != nil {
 		if apierrors.IsNotFound(err) {
+			logger = r.getRemovedSecretLogger(baseLogger, req.NamespacedName)
 			err = r.cleanSveltosCluster(ctx, req, logger)
 			if err != nil {
-				logger.V(logs.LogInfo).Info(fmt.Sprintf("failed to reconcile: %v", err))
-				return reconcile.Result{Requeue: true, RequeueAfter: normalRequeueAfter}, nil
+				return reconcile.Result{}, err
 			}
+			return reconcile.Result{}, nil
 		}
 		logger.Error(err, "Failed to fetch Secret")
 		return reconcile.Result{}, errors.Wrapf(err, "Failed to fetch Secret %s", req.NamespacedName)
 	}
 
+	logger = r.getSecretLogger(baseLogger, secret)
+
 	// Handle deleted cluster
 	if !secret.DeletionTimestamp.IsZero() {
-		err := r.cleanSveltosCluster(ctx, req, logger)
+		err := r.reconcileDelete(ctx, secret, logger)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		return reconcile.Result{}, nil
+	}
+
+	if !r.shouldReconcileSecret(secret) || !r.SecretFilters.matches(secret) {
+		r.removeFromBatch(req.NamespacedName)
+		// Secrets outside the selector might be managed by another controller instance
+		if !r.SecretFilters.matchesSelector(secret) && !r.isSecretTracked(secret) {
+			logger.V(logs.LogDebug).Info("Secret does not match secret selector. Ignoring it")
+			return reconcile.Result{}, nil
+		}
+		// Secret might have lost its Claudie labels, gained the skip annotation or
+		// be in a namespace not allowed anymore
+		err := r.offboardSecret(ctx, secret, logger)
+		if err == nil {
+			// Secret events are not watched anymore, finalizer would block its deletion
+			err = r.removeCleanupFinalizer(ctx, secret)
+		}
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		return reconcile.Result{}, nil
+	}
+
+	if !r.isClaudieVersionSupported(secret, logger) {
+		return reconcile.Result{}, nil
+	}
+
+	if !r.hasClusterName(secret, logger) {
+		return reconcile.Result{}, nil
+	}
+
+	if !r.isTargetNamespaceWatched(secret, logger) {
+		return reconcile.Result{}, nil
+	}
+
+	// Once TTL expires, SveltosCluster is removed and not recreated
+	if isSecretExpired(secret, time.Now(), logger) {
+		logger.V(logs.LogDebug).Info("Secret TTL expired")
+		err := r.expireSecret(ctx, secret, logger)
 		if err != nil {
-			logger.V(logs.LogInfo).Info(fmt.Sprintf("failed to reconcile: %v", err))
-			return reconcile.Result{Requeue: true, RequeueAfter: normalRequeueAfter}, nil
+			return reconcile.Result{}, err
 		}
+		return reconcile.Result{}, nil
+	}
+
+	if delay := r.getNamespaceThrottle(req.Namespace); delay > 0 {
+		logger.V(logs.LogDebug).Info(fmt.Sprintf("namespace exceeded its reconcile rate. Requeue after %s", delay))
+		return reconcile.Result{RequeueAfter: delay}, nil
+	}
+
+	if delay := r.getBatchDelay(req.NamespacedName); delay > 0 {
+		logger.V(logs.LogDebug).Info(fmt.Sprintf("new Secret batched. Requeue after %s", delay))
+		return reconcile.Result{RequeueAfter: delay}, nil
+	}
+
+	if remaining := r.getDeletionRetention(req.NamespacedName); remaining > 0 {
+		logger.V(logs.LogDebug).Info("SveltosCluster for Secret was recently removed. Ignoring re-creation within retention window")
+		return reconcile.Result{RequeueAfter: remaining}, nil
 	}
 
-	if !r.shouldReconcileSecret(secret) {
+	kubeconfigSecret, _, err := r.resolveKubeconfig(ctx, secret)
+	if errors.Is(err, errKubeconfigSecretNotFound) {
+		logger.V(logs.LogInfo).Info(fmt.Sprintf("%v. Requeue till it is created", err))
+		return reconcile.Result{RequeueAfter: jitter(normalRequeueAfter, jitterFactor)}, nil
+	}
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	// Do not create a SveltosCluster Sveltos would never be able to use
+	if !r.hasKubeconfigData(kubeconfigSecret, logger) {
 		return reconcile.Result{}, nil
 	}
+	if err := r.validateKubeconfig(kubeconfigSecret); err != nil {
+		return reconcile.Result{}, errors.Wrap(err, "invalid kubeconfig, SveltosCluster not reconciled")
+	}
 
-	err := r.createSveltosCluster(ctx, secret, logger)
+	err = r.createSveltosCluster(ctx, secret, logger)
+	if errors.Is(err, errSveltosClusterDeleting) {
+		logger.V(logs.LogDebug).Info("SveltosCluster is being deleted. Requeue to recreate it once deletion completes")
+		return reconcile.Result{Requeue: true, RequeueAfter: jitter(normalRequeueAfter, jitterFactor)}, nil
+	}
 	if err != nil {
-		logger.V(logs.LogInfo).Info(fmt.Sprintf("failed to reconcile: %v", err))
-		return reconcile.Result{Requeue: true, RequeueAfter: normalRequeueAfter}, nil
+		r.recordEvent(secret, corev1.EventTypeWarning, reasonReconcileFailed,
+			fmt.Sprintf("failed to reconcile SveltosCluster: %v", err))
+		return reconcile.Result{}, err
 	}
 
-	return reconcile.Result{}, nil
+	// Reconcile again when TTL expires, so SveltosCluster is removed right away
+	return reconcile.Result{RequeueAfter: getExpirationRequeue(secret, time.Now())}, nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *SecretReconciler) SetupWithManager(ctx context.Context, mgr ctrl.Manager, logger logr.Logger) error {
-	go cleanStaleSveltosCluster(ctx, mgr.GetClient(), logger)
+	// Fail fast instead of failing every reconciliation
+	if err := ValidateScheme(mgr.GetScheme()); err != nil {
+		return err
+	}
+
+	if r.EventRecorder == nil {
+		r.EventRecorder = mgr.GetEventRecorderFor(eventRecorderName)
+	}
+
+	r.Client = newTimeoutClient(r.Client, r.APICallTimeout)
+
+	// Reads bypassing the cache are bounded by APICallTimeout too
+	apiReader := newTimeoutReader(mgr.GetAPIReader(), r.APICallTimeout)
+
+	// Cache is not started yet, so SveltosClusters are read directly from the API server.
+	// If this fails, the initial reconciliation of all existing Secrets rebuilds the map anyway.
+	if err := r.rebuildSecretToClusterMap(ctx, apiReader, logger); err != nil {
+		logger.V(logs.LogInfo).Info(fmt.Sprintf("failed to rebuild SecretToCluster map: %v", err))
+	}
+
+	// mapReady is closed once the cache is synced, after the map rebuild above. It does not wait
+	// for the initial reconciliation of existing Secrets to complete the map. The stale sweep only
+	// uses it as a gate, so no Secret is considered gone before the cache holds them all: the sweep
+	// never reads the map. Drift correction, resync and the ready check use it the same way.
+	r.mapReady = make(chan struct{})
+	go func() {
+		if mgr.GetCache().WaitForCacheSync(ctx) {
+			close(r.mapReady)
+		}
+	}()
+
+	// Stale SveltosClusters are removed, and drift is corrected, only by the elected leader.
+	// Other replicas would race with it on deletions.
+	err := r.addStaleSweep(mgr.Add, apiReader, logger)
+	if err != nil {
+		return err
+	}
 
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&corev1.Secret{}).
+	if r.DriftReconcileInterval > 0 {
+		err = mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+			r.correctDrift(ctx, r.mapReady, logger)
+			return nil
+		}))
+		if err != nil {
+			return err
+		}
+	}
+
+	secretController := ctrl.NewControllerManagedBy(mgr).
+		For(&corev1.Secret{}, builder.WithPredicates(r.getSecretPredicate(), r.getClaudieSecretPredicate())).
+		Watches(&libsveltosv1alpha1.SveltosCluster{},
+			handler.EnqueueRequestsFromMapFunc(r.requeueClaudieSecret),
+			builder.WithPredicates(getSveltosClusterPredicate())).
 		WithOptions(controller.Options{
-			MaxConcurrentReconciles: r.ConcurrentReconciles,
-		}).
-		Complete(r)
+			MaxConcurrentReconciles: r.getConcurrentReconciles(),
+			RateLimiter:             newFailureRateLimiter(r.getFailureBackoffBase(), r.getFailureBackoffMax()),
+		})
+
+	// Periodic resync feeds the controller workqueue, instead of reconciling on its own
+	if r.ResyncPeriod > 0 {
+		resyncEvents := make(chan event.GenericEvent)
+		err = mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+			r.resyncClaudieSecrets(ctx, r.mapReady, resyncEvents, logger)
+			return nil
+		}))
+		if err != nil {
+			return err
+		}
+		secretController = secretController.WatchesRawSource(
+			source.Channel(resyncEvents, &handler.EnqueueRequestForObject{}))
+	}
+
+	return secretController.Complete(r)
 }
 
 // shouldReconcileSecret looks at Secret labels and return whether reconciler
 // should process this one or not.
-// Only Claudie secrets containing a cluster Kubeconfig are reconciled.
+// Only Claudie secrets containing a cluster Kubeconfig, and matching SecretFilters Selector if set,
+// are reconciled.
 func (r *SecretReconciler) shouldReconcileSecret(secret *corev1.Secret) bool {
 	if secret.Labels == nil {
 		return false
 	}
 
-	if _, ok := secret.Labels[claudieLabel]; !ok {
+	if !r.getSecretClassifier(secret).matches(secret) {
 		return false
 	}
 
-	if _, ok := secret.Labels[claudieKubeconfig]; !ok {
+	// Multiple controller instances might each manage a subset of Claudie Secrets
+	return r.SecretFilters.matchesSelector(secret)
+}
+
+// getClaudieLabel returns the label key identifying Secrets produced by Claudie
+func (r *SecretReconciler) getClaudieLabel() string {
+	if r.ClaudieLabel == "" {
+		return claudieLabel
+	}
+	return r.ClaudieLabel
+}
+
+// getClaudieKubeconfigLabel returns the label key identifying Claudie Secrets containing a kubeconfig
+func (r *SecretReconciler) getClaudieKubeconfigLabel() string {
+	if r.ClaudieKubeconfigLabel == "" {
+		return claudieKubeconfig
+	}
+	return r.ClaudieKubeconfigLabel
+}
+
+// getClaudieClusterLabel returns the label key containing the name of the Claudie cluster
+func (r *SecretReconciler) getClaudieClusterLabel() string {
+	if r.ClaudieClusterLabel == "" {
+		return claudieCluster
+	}
+	return r.ClaudieClusterLabel
+}
+
+// isClaudieVersionSupported returns false if Secret was produced by a Claudie version older than
+// MinClaudieVersion. Secrets not reporting any Claudie version are considered supported.
+func (r *SecretReconciler) isClaudieVersionSupported(secret *corev1.Secret, logger logr.Logger) bool {
+	if r.MinClaudieVersion == nil {
+		return true
+	}
+
+	claudieVersion, ok := secret.Annotations[claudieVersionAnnotation]
+	if !ok {
+		return true
+	}
+
+	currentVersion, err := version.ParseGeneric(claudieVersion)
+	if err != nil {
+		logger.V(logs.LogInfo).Info(fmt.Sprintf("skipping Secret: failed to parse Claudie version %q: %v",
+			claudieVersion, err))
 		return false
 	}
 
-	if _, ok := secret.Labels[claudieCluster]; !ok {
+	if currentVersion.LessThan(r.MinClaudieVersion) {
+		logger.V(logs.LogInfo).Info(fmt.Sprintf("skipping Secret: Claudie version %s is older than minimum supported version %s",
+			currentVersion, r.MinClaudieVersion))
 		return false
 	}
 
 	return true
 }
 
-func (r *SecretReconciler) getSveltosClusterName(secret *corev1.Secret) string {
-	return secret.Labels[claudieCluster]
-}
-
+// getSveltosClusterNamespace returns the namespace the SveltosCluster for secret is created in.
+// In order, the targetNamespaceAnnotation on the Secret and the NamespaceMap entry for the Secret
+// namespace are used. By default, SveltosCluster and Secret are in the same namespace.
+// When namespaces differ, Secret cannot be added as OwnerReference: ownership is tracked via
+// annotations and cleanup relies on a finalizer on the Secret.
 func (r *SecretReconciler) getSveltosClusterNamespace(secret *corev1.Secret) string {
-	// SveltosCluster and Secret must be in same namespace. Secret is added as OwnerReference
-	// for SveltosCluster.
-	return secret.Namespace
+	if namespace := secret.Annotations[targetNamespaceAnnotation]; namespace != "" {
+		return namespace
+	}
+
+	if namespace, ok := r.NamespaceMap[secret.Namespace]; ok {
+		return namespace
+	}
+
+	return r.getSecretClassifier(secret).getClusterNamespace(secret)
 }
 
-// cleanSveltosCluster removes SveltosCluster (if any exists) for a given secret
+// cleanSveltosCluster removes SveltosCluster (if any exists) for a given secret.
+// logger is expected to carry the Secret identity (see getRemovedSecretLogger).
 func (r *SecretReconciler) cleanSveltosCluster(ctx context.Context, secretRef ctrl.Request,
 	logger logr.Logger) error {
 
-	r.Mux.Lock()
-	defer r.Mux.Unlock()
+	r.removeFromBatch(secretRef.NamespacedName)
 
 	secretKey := types.NamespacedName{Namespace: secretRef.Namespace, Name: secretRef.Name}
-	sveltosClusterInfo, ok := r.SecretToCluster[secretKey]
+	sveltosClusterInfo, ok := r.secretToCluster.Get(secretKey)
 	if !ok {
 		return nil
 	}
 
-	logger = logger.WithValues("secret", fmt.Sprintf("%s/%s", secretKey.Namespace, secretKey.Name))
 	logger.V(logs.LogInfo).Info("removing SveltosCluster for Secret")
 
 	sveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
 	err := r.Get(ctx, sveltosClusterInfo, sveltosCluster)
 	if err != nil {
 		if apierrors.IsNotFound(err) {
-			delete(r.SecretToCluster, secretKey)
+			err = removeMirroredKubeconfig(ctx, r.Client, sveltosClusterInfo)
+			if err != nil {
+				return err
+			}
+			sveltosCluster.Namespace = sveltosClusterInfo.Namespace
+			sveltosCluster.Name = sveltosClusterInfo.Name
+			err = r.removeClusterProfileStub(ctx, sveltosCluster)
+			if err != nil {
+				return err
+			}
+			r.forgetSveltosCluster(secretKey, sveltosClusterInfo, true)
 			return nil
 		}
 
 		return err
 	}
 
-	err = r.Delete(ctx, sveltosCluster)
+	// SveltosCluster was released from Claudie management, never delete it
+	if isUnmanaged(sveltosCluster) {
+		r.forgetSveltosCluster(secretKey, sveltosClusterInfo, false)
+		return nil
+	}
+
+	if isRetained(sveltosCluster) {
+		return r.retainSveltosCluster(ctx, secretKey, sveltosClusterInfo, sveltosCluster, logger)
+	}
+
+	if isOrphanPolicy(sveltosCluster) {
+		return r.orphanSveltosCluster(ctx, secretKey, sveltosClusterInfo, sveltosCluster, logger)
+	}
+
+	err = deleteWithRetry(ctx, r.Client, sveltosCluster)
+	// Claudie Secret might not exist anymore, so provider is not known
+	recordReconcileOutcome(actionDelete, unknownProvider, err)
+	if err != nil {
+		r.recordDeleteFailure(ctx, secretKey, sveltosClusterInfo, err)
+		return err
+	}
+	r.recordDeleteSuccess(ctx, secretKey, sveltosCluster)
+	r.recordAudit(ctx, actionDelete, secretKey, sveltosClusterInfo)
+
+	err = removeMirroredKubeconfig(ctx, r.Client, sveltosClusterInfo)
 	if err != nil {
 		return err
 	}
 
-	delete(r.SecretToCluster, secretKey)
+	err = r.removeClusterProfileStub(ctx, sveltosCluster)
+	if err != nil {
+		return err
+	}
+
+	r.forgetSveltosCluster(secretKey, sveltosClusterInfo, true)
 	return nil
 }
 
+// forgetSveltosCluster stops tracking sveltosClusterInfo for Secret and, if deleted, marks Secret as
+// deleting. Nothing is done if, in the meantime, Secret started tracking a different SveltosCluster.
+func (r *SecretReconciler) forgetSveltosCluster(secretKey, sveltosClusterInfo types.NamespacedName, deleted bool) {
+	if !r.secretToCluster.CompareAndDelete(secretKey, sveltosClusterInfo) {
+		return
+	}
+
+	if deleted {
+		r.markSecretAsDeleting(secretKey)
+	}
+}
+
+// reconcileDelete removes the SveltosCluster for a Secret being deleted. Cleanup finalizer, if present,
+// is removed only once SveltosCluster is gone.
+func (r *SecretReconciler) reconcileDelete(ctx context.Context, secret *corev1.Secret, logger logr.Logger) error {
+	if controllerutil.ContainsFinalizer(secret, claudieCleanupFinalizer) {
+		if err := r.trackForCleanup(ctx, secret); err != nil {
+			return err
+		}
+	}
+
+	err := r.cleanSveltosCluster(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(secret)}, logger)
+	if err != nil {
+		return err
+	}
+
+	return r.removeCleanupFinalizer(ctx, secret)
+}
+
+// offboardSecret removes the SveltosCluster of a tracked Secret which lost its Claudie labels.
+// Secret is not marked as deleting, so if labels are added back, SveltosCluster is recreated
+// right away.
+func (r *SecretReconciler) offboardSecret(ctx context.Context, secret *corev1.Secret, logger logr.Logger) error {
+	if r.RetainOnLabelRemoval {
+		return nil
+	}
+
+	secretKey := types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name}
+
+	if _, tracked := r.secretToCluster.Get(secretKey); !tracked {
+		return nil
+	}
+
+	logger.V(logs.LogInfo).Info("Secret is not a Claudie Secret anymore")
+	err := r.cleanSveltosCluster(ctx, ctrl.Request{NamespacedName: secretKey}, logger)
+	if err != nil {
+		return err
+	}
+
+	r.deletingMux.Lock()
+	delete(r.deletingSecrets, secretKey)
+	r.deletingMux.Unlock()
+
+	return r.removeSecretBackReference(ctx, secret)
+}
+
+// recordDeleteFailure records a Warning event on the Secret, if it still exists, reporting
+// its SveltosCluster could not be deleted
+func (r *SecretReconciler) recordDeleteFailure(ctx context.Context, secretKey, sveltosClusterInfo types.NamespacedName,
+	deleteErr error) {
+
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, secretKey, secret); err != nil {
+		return
+	}
+
+	r.recordEvent(secret, corev1.EventTypeWarning, reasonSveltosClusterDeleteFailed,
+		fmt.Sprintf("failed to delete SveltosCluster %s/%s: %v",
+			sveltosClusterInfo.Namespace, sveltosClusterInfo.Name, deleteErr))
+}
+
+// recordDeleteSuccess records an event reporting the SveltosCluster was deleted. Event is recorded
+// on the Secret if it still exists, on the SveltosCluster otherwise.
+func (r *SecretReconciler) recordDeleteSuccess(ctx context.Context, secretKey types.NamespacedName,
+	sveltosCluster *libsveltosv1alpha1.SveltosCluster) {
+
+	msg := fmt.Sprintf("deleted SveltosCluster %s/%s for Secret %s/%s",
+		sveltosCluster.Namespace, sveltosCluster.Name, secretKey.Namespace, secretKey.Name)
+
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, secretKey, secret); err != nil {
+		r.recordEvent(sveltosCluster, corev1.EventTypeNormal, reasonSveltosClusterDeleted, msg)
+		return
+	}
+
+	r.recordEvent(secret, corev1.EventTypeNormal, reasonSveltosClusterDeleted, msg)
+}
+
 // createSveltosCluster creates, if not existing already, a SveltosCluster for a Claudie Secret containing
 // kubeconfig to acces kubernetes cluster.
 // Secret is added as OwnerReference.
 // If SveltosCluster already exists, it gets updated.
-func (r *SecretReconciler) createSveltosCluster(ctx context.Context, secret *corev1.Secret, logger logr.Logger) error {
-	logger = logger.WithValues("secret", fmt.Sprintf("%s/%s", secret.Namespace, secret.Name))
+// When the kubeconfig in the Claudie Secret cannot be used as it is (for instance a specific context was
+// requested), the rewritten kubeconfig is mirrored to a Secret owned by the SveltosCluster.
+// logger is expected to carry the Secret identity (see getSecretLogger).
+func (r *SecretReconciler) createSveltosCluster(ctx context.Context, secret *corev1.Secret,
+	logger logr.Logger) (reterr error) {
+
 	logger.V(logs.LogInfo).Info("reconciling secret")
 
+	action := actionCreate
+	defer func() {
+		recordReconcileOutcome(action, secret.Labels[claudieProviderLabel], reterr)
+	}()
+
 	sveltosClusterNamespace := r.getSveltosClusterNamespace(secret)
 	sveltosClusterName := r.getSveltosClusterName(secret)
 
-	r.updateSecretToClusterMap(secret, sveltosClusterNamespace, sveltosClusterName)
+	if err := validateNamespaceName(sveltosClusterNamespace); err != nil {
+		r.recordEvent(secret, corev1.EventTypeWarning, reasonInvalidNamespace, err.Error())
+		return err
+	}
+
+	// Kubeconfig might be stored in a different Secret referenced by the Claudie one
+	kubeconfigSecret, kubeconfigName, err := r.resolveKubeconfig(ctx, secret)
+	if err != nil {
+		return err
+	}
+
+	mirroredKubeconfig, err := r.getKubeconfigToMirror(kubeconfigSecret)
+	if err != nil {
+		return err
+	}
+
+	// When the kubeconfig key in the Claudie Secret changes, SveltosCluster is updated
+	// in place to report the new key. The report is informational only: Sveltos has no
+	// kubeconfig key field and reads the kubeconfig from the Secret data.
+	kubeconfigKeyName := r.getKubeconfigKey(kubeconfigSecret)
+	if mirroredKubeconfig != nil {
+		kubeconfigName = getMirroredKubeconfigName(sveltosClusterName)
+		kubeconfigKeyName = kubeconfigDataKey
+	}
 
+	sveltosClusterKey := types.NamespacedName{Namespace: sveltosClusterNamespace, Name: sveltosClusterName}
 	sveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
-	err := r.Get(ctx,
-		types.NamespacedName{Namespace: sveltosClusterNamespace, Name: sveltosClusterName},
-		sveltosCluster)
+	err = r.Get(ctx, sveltosClusterKey, sveltosCluster)
 	if err != nil {
-		if apierrors.IsNotFound(err) {
-			sveltosCluster.Namespace = sveltosClusterNamespace
-			sveltosCluster.Name = sveltosClusterName
-			sveltosCluster.Spec.KubeconfigName = secret.Name
-			// SveltosCluster labels are used by Projectsveltos controller to decide
-			// which add-ons/applications to deploy. So we only set OwnerReference and
-			// Annotations and do not add any labels. Labels are managed by users only.
-			r.addAnnotation(sveltosCluster)
-			r.addOwnerReference(sveltosCluster, secret)
-			return r.Create(ctx, sveltosCluster)
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+
+		// When SveltosCluster is created in a namespace other than the Secret one, such
+		// namespace might not be ready yet. Requeue till it is Active.
+		if sveltosClusterNamespace != secret.Namespace {
+			err = r.verifyNamespaceActive(ctx, sveltosClusterNamespace)
+			if err != nil {
+				return err
+			}
+		}
+
+		sveltosCluster.Namespace = sveltosClusterNamespace
+		sveltosCluster.Name = sveltosClusterName
+		if spec := r.getSpecDefaults(secret); spec != nil {
+			sveltosCluster.Spec = *spec
+		}
+		r.applySpecMapping(sveltosCluster, secret, logger)
+		applyPausedAnnotation(sveltosCluster, secret, logger)
+		sveltosCluster.Spec.KubeconfigName = kubeconfigName
+		setKubeconfigKeyAnnotation(sveltosCluster, kubeconfigKeyName)
+		// SveltosCluster labels are used by Projectsveltos controller to decide
+		// which add-ons/applications to deploy. So we only set OwnerReference and
+		// Annotations and do not add any labels (other than the optional auto-target one
+		// and the explicitly allow-listed Secret ones).
+		// Labels are managed by users only.
+		r.addAutoTargetLabel(sveltosCluster)
+		r.copyAllowedLabels(secret, sveltosCluster)
+		r.applyLabelMapping(sveltosCluster, secret, logger)
+		r.addAnnotation(sveltosCluster, secret)
+		r.addRetainAnnotation(sveltosCluster)
+		addDeletePolicyAnnotation(sveltosCluster, secret, logger)
+		r.addTopologyAnnotations(sveltosCluster, secret)
+		r.addEndpointAnnotation(sveltosCluster, kubeconfigSecret, logger)
+		r.addExpirationAnnotation(sveltosCluster, secret)
+		r.addFreshnessAnnotations(sveltosCluster, kubeconfigSecret, time.Now())
+		r.addOwnerReference(sveltosCluster, secret)
+		setDecision(sveltosCluster, decisionCreated)
+		// Finalizer is added before SveltosCluster is created, so SveltosCluster is never leaked
+		// when Secret is deleted
+		err = r.addCleanupFinalizer(ctx, secret)
+		if err != nil {
+			return err
+		}
+		err = r.writeSveltosCluster(ctx, sveltosCluster, true)
+		if err == nil {
+			r.recordEvent(secret, corev1.EventTypeNormal, reasonSveltosClusterCreated,
+				fmt.Sprintf("created SveltosCluster %s/%s", sveltosClusterNamespace, sveltosClusterName))
+			r.recordAudit(ctx, actionCreate, client.ObjectKeyFromObject(secret), sveltosClusterKey)
+			r.startConnectivityProbe(sveltosClusterKey, r.getKubeconfigToProbe(kubeconfigSecret, mirroredKubeconfig), logger)
+			r.updateSecretToClusterMap(secret, sveltosClusterNamespace, sveltosClusterName)
+			r.annotateSecret(ctx, secret, sveltosClusterKey, logger)
+			err = r.removeDuplicateSveltosClusters(ctx, secret, sveltosClusterKey, logger)
+			if err != nil {
+				return err
+			}
+			err = r.reconcileClusterProfileStub(ctx, sveltosCluster)
+			if err != nil {
+				return err
+			}
+			return r.reconcileMirroredKubeconfig(ctx, sveltosCluster, mirroredKubeconfig, false)
+		}
+
+		if !apierrors.IsAlreadyExists(err) {
+			return err
+		}
+
+		// A concurrent reconciliation created the SveltosCluster in the meantime.
+		// Proceed with the update path.
+		logger.V(logs.LogDebug).Info("SveltosCluster already exists. Updating it")
+		sveltosCluster = &libsveltosv1alpha1.SveltosCluster{}
+		err = r.Get(ctx, sveltosClusterKey, sveltosCluster)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Updating a SveltosCluster being deleted is futile. Wait for deletion to complete,
+	// SveltosCluster will then be recreated.
+	action = actionUpdate
+	if !sveltosCluster.DeletionTimestamp.IsZero() {
+		return errSveltosClusterDeleting
+	}
+
+	if isUnmanaged(sveltosCluster) {
+		action = actionSkip
+		return r.releaseSveltosCluster(ctx, sveltosCluster, secret, logger)
+	}
+
+	proceed, err := r.handleAdoption(sveltosCluster, secret, logger)
+	if !proceed {
+		action = actionSkip
+		return err
+	}
+
+	proceed, err = r.handleOwnerConflict(ctx, sveltosCluster, secret, logger)
+	if !proceed {
+		action = actionSkip
+		return err
+	}
+
+	// Secret is now confirmed as the SveltosCluster owner. Secrets refused or skipped above own
+	// nothing and get no finalizer.
+	err = r.addCleanupFinalizer(ctx, secret)
+	if err != nil {
+		return err
+	}
+
+	r.updateSecretToClusterMap(secret, sveltosClusterNamespace, sveltosClusterName)
+
+	wasMirrored := sveltosCluster.Spec.KubeconfigName == getMirroredKubeconfigName(sveltosClusterName)
+	var decision string
+	var kubeconfigChanged bool
+	refetch := false
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		// SveltosCluster was modified by someone else since it was fetched. Changes are
+		// re-applied to its current version.
+		if refetch {
+			logger.V(logs.LogDebug).Info("conflict updating SveltosCluster. Retrying")
+			sveltosCluster = &libsveltosv1alpha1.SveltosCluster{}
+			if err := r.Get(ctx, sveltosClusterKey, sveltosCluster); err != nil {
+				return err
+			}
+			if !sveltosCluster.DeletionTimestamp.IsZero() {
+				return errSveltosClusterDeleting
+			}
+		}
+		refetch = true
+
+		original := sveltosCluster.DeepCopy()
+		r.applySpecMapping(sveltosCluster, secret, logger)
+		applyPausedAnnotation(sveltosCluster, secret, logger)
+		sveltosCluster.Spec.KubeconfigName = kubeconfigName
+		setKubeconfigKeyAnnotation(sveltosCluster, kubeconfigKeyName)
+		r.addAutoTargetLabel(sveltosCluster)
+		r.copyAllowedLabels(secret, sveltosCluster)
+		r.applyLabelMapping(sveltosCluster, secret, logger)
+		r.addAnnotation(sveltosCluster, secret)
+		r.addRetainAnnotation(sveltosCluster)
+		addDeletePolicyAnnotation(sveltosCluster, secret, logger)
+		r.addTopologyAnnotations(sveltosCluster, secret)
+		r.addEndpointAnnotation(sveltosCluster, kubeconfigSecret, logger)
+		r.addExpirationAnnotation(sveltosCluster, secret)
+		r.addFreshnessAnnotations(sveltosCluster, kubeconfigSecret, time.Now())
+		r.addOwnerReference(sveltosCluster, secret)
+		if err := r.restrictSpecUpdate(original, sveltosCluster); err != nil {
+			return err
+		}
+		kubeconfigChanged = original.Annotations[kubeconfigHashAnnotation] != sveltosCluster.Annotations[kubeconfigHashAnnotation] ||
+			original.Spec.KubeconfigName != sveltosCluster.Spec.KubeconfigName
+		decision = getUpdateDecision(original, sveltosCluster)
+		if decision == decisionUnchanged {
+			// SveltosCluster is already in the desired state. Skip the write, so no needless
+			// API call is made and resourceVersion does not change.
+			return nil
 		}
+		setDecision(sveltosCluster, decision)
+		if r.ServerSideApply {
+			return r.applySveltosCluster(ctx, sveltosCluster, secret)
+		}
+		return r.writeSveltosCluster(ctx, sveltosCluster, false)
+	})
+	if err != nil {
+		// SveltosCluster deleted in the meantime is recreated once its deletion event is processed
+		return client.IgnoreNotFound(err)
+	}
+	if _, probed := sveltosCluster.Annotations[reachableAnnotation]; kubeconfigChanged || !probed {
+		r.startConnectivityProbe(sveltosClusterKey, r.getKubeconfigToProbe(kubeconfigSecret, mirroredKubeconfig), logger)
+	}
+	if decision != decisionUnchanged {
+		r.recordEvent(secret, corev1.EventTypeNormal, reasonSveltosClusterUpdated,
+			fmt.Sprintf("SveltosCluster %s/%s %s", sveltosClusterNamespace, sveltosClusterName, decision))
+		r.recordAudit(ctx, actionUpdate, client.ObjectKeyFromObject(secret), sveltosClusterKey)
+	}
 
+	r.annotateSecret(ctx, secret, sveltosClusterKey, logger)
+	err = r.removeDuplicateSveltosClusters(ctx, secret, sveltosClusterKey, logger)
+	if err != nil {
+		return err
+	}
+	err = r.reconcileClusterProfileStub(ctx, sveltosCluster)
+	if err != nil {
 		return err
 	}
+	return r.reconcileMirroredKubeconfig(ctx, sveltosCluster, mirroredKubeconfig, wasMirrored)
+}
+
+// handleAdoption verifies whether SveltosCluster was created by someone else (it is neither annotated
+// as managed by this controller nor owned by any Secret) and, if so, applies the configured AdoptionPolicy.
+// Returns true if reconciliation of SveltosCluster should proceed.
+func (r *SecretReconciler) handleAdoption(sveltosCluster *libsveltosv1alpha1.SveltosCluster,
+	secret *corev1.Secret, logger logr.Logger) (bool, error) {
+
+	if isSveltosClusterForClaudie(sveltosCluster) || getClaudieSecret(sveltosCluster) != nil {
+		return true, nil
+	}
+
+	// SveltosCluster created by this controller whose annotations and OwnerReferences were removed
+	// by someone else. It is not adopted, it is restored.
+	trackingSecret, tracked := r.secretToCluster.GetSecret(client.ObjectKeyFromObject(sveltosCluster))
+	if tracked && trackingSecret == client.ObjectKeyFromObject(secret) {
+		return true, nil
+	}
+
+	switch r.AdoptionPolicy {
+	case AdoptionPolicyRefuse:
+		msg := fmt.Sprintf("SveltosCluster %s/%s exists and is not managed by Claudie. Refusing to adopt it",
+			sveltosCluster.Namespace, sveltosCluster.Name)
+		logger.V(logs.LogInfo).Info(msg)
+		r.recordEvent(secret, corev1.EventTypeWarning, reasonSveltosClusterAdoptionRefused, msg)
+		return false, errors.New(msg)
+	case AdoptionPolicySkip:
+		msg := fmt.Sprintf("SveltosCluster %s/%s exists and is not managed by Claudie. Skipping it",
+			sveltosCluster.Namespace, sveltosCluster.Name)
+		logger.V(logs.LogInfo).Info(msg)
+		r.recordEvent(secret, corev1.EventTypeNormal, reasonSveltosClusterAdoptionSkipped, msg)
+		return false, nil
+	default:
+		msg := fmt.Sprintf("adopting SveltosCluster %s/%s not previously managed by Claudie",
+			sveltosCluster.Namespace, sveltosCluster.Name)
+		logger.V(logs.LogInfo).Info(msg)
+		r.recordEvent(secret, corev1.EventTypeNormal, reasonSveltosClusterAdopted, msg)
+		return true, nil
+	}
+}
+
+// handleOwnerConflict verifies whether SveltosCluster is already owned by a Secret different
+// from the one being reconciled and, if so, applies the configured ConflictPolicy.
+// A newer Secret for the same cluster (kubeconfig rotation) always takes over, regardless of
+// ConflictPolicy, while an older one never does.
+// Returns true if reconciliation of SveltosCluster should proceed.
+func (r *SecretReconciler) handleOwnerConflict(ctx context.Context, sveltosCluster *libsveltosv1alpha1.SveltosCluster,
+	secret *corev1.Secret, logger logr.Logger) (bool, error) {
+
+	currentOwner := getClaudieSecret(sveltosCluster)
+	if currentOwner == nil || *currentOwner == client.ObjectKeyFromObject(secret) {
+		return true, nil
+	}
+
+	rotated, proceed, err := r.handleRotation(ctx, sveltosCluster, secret, *currentOwner, logger)
+	if err != nil {
+		return false, err
+	}
+	if rotated && !proceed {
+		return false, nil
+	}
+
+	if !rotated {
+		if r.ConflictPolicy != ConflictPolicyTakeOver {
+			msg := fmt.Sprintf("SveltosCluster %s/%s is already owned by Secret %s",
+				sveltosCluster.Namespace, sveltosCluster.Name, currentOwner.Name)
+			logger.V(logs.LogInfo).Info(msg)
+			r.recordEvent(secret, corev1.EventTypeWarning, reasonSveltosClusterConflict, msg)
+			return false, nil
+		}
+
+		msg := fmt.Sprintf("taking over SveltosCluster %s/%s previously owned by Secret %s",
+			sveltosCluster.Namespace, sveltosCluster.Name, currentOwner.Name)
+		logger.V(logs.LogInfo).Info(msg)
+		r.recordEvent(secret, corev1.EventTypeNormal, reasonSveltosClusterTakenOver, msg)
+	}
+
+	removeSecretOwnerReferences(sveltosCluster)
+
+	// Previous owner must not delete this SveltosCluster anymore when removed
+	r.secretToCluster.Delete(*currentOwner)
+
+	return true, nil
+}
+
+// removeSecretOwnerReferences removes all Secrets from SveltosCluster OwnerReferences
+func removeSecretOwnerReferences(sveltosCluster *libsveltosv1alpha1.SveltosCluster) {
+	ownerReferences := make([]metav1.OwnerReference, 0)
+	for i := range sveltosCluster.OwnerReferences {
+		if sveltosCluster.OwnerReferences[i].Kind == "Secret" {
+			continue
+		}
+		ownerReferences = append(ownerReferences, sveltosCluster.OwnerReferences[i])
+	}
+
+	sveltosCluster.OwnerReferences = ownerReferences
+}
+
+// reconcileMirroredKubeconfig makes sure the mirrored kubeconfig Secret exists when needed and
+// it is removed when not needed anymore.
+func (r *SecretReconciler) reconcileMirroredKubeconfig(ctx context.Context, sveltosCluster *libsveltosv1alpha1.SveltosCluster,
+	mirroredKubeconfig []byte, wasMirrored bool) error {
+
+	if mirroredKubeconfig != nil {
+		return r.mirrorKubeconfig(ctx, sveltosCluster, mirroredKubeconfig)
+	}
+
+	if wasMirrored {
+		return removeMirroredKubeconfig(ctx, r.Client,
+			types.NamespacedName{Namespace: sveltosCluster.Namespace, Name: sveltosCluster.Name})
+	}
 
-	r.addAnnotation(sveltosCluster)
-	r.addOwnerReference(sveltosCluster, secret)
-	return r.Update(ctx, sveltosCluster)
+	return nil
 }
 
-// addAnnotation adds an annotation to SveltosCluster indicating it was created for a Claudie Secret
-func (r *SecretReconciler) addAnnotation(sveltosCluster *libsveltosv1alpha1.SveltosCluster) {
+// addAnnotation adds an annotation to SveltosCluster indicating it was created for a Claudie Secret,
+// along with the configured ExtraAnnotations. Claudie annotation always wins over ExtraAnnotations.
+// Annotation value records the Secret UID, so provenance can be verified.
+func (r *SecretReconciler) addAnnotation(sveltosCluster *libsveltosv1alpha1.SveltosCluster,
+	secret *corev1.Secret) {
+
 	if sveltosCluster.Annotations == nil {
 		sveltosCluster.Annotations = make(map[string]string)
 	}
 
-	sveltosCluster.Annotations[sveltosClusterClaudieAnnotation] = "ok"
+	for key, value := range r.ExtraAnnotations {
+		sveltosCluster.Annotations[key] = value
+	}
+
+	sveltosCluster.Annotations[sveltosClusterClaudieAnnotation] = getClaudieMarker(secret)
+	// Secret exists
+	delete(sveltosCluster.Annotations, sveltosClusterSecretMissingAnnotation)
+}
+
+// addTopologyAnnotations copies region and zone labels of the Claudie Secret as annotations
+// on the SveltosCluster. Annotations are removed when corresponding label is not on the
+// Secret anymore.
+func (r *SecretReconciler) addTopologyAnnotations(sveltosCluster *libsveltosv1alpha1.SveltosCluster,
+	secret *corev1.Secret) {
+
+	topology := map[string]string{
+		regionLabel: sveltosClusterRegionAnnotation,
+		zoneLabel:   sveltosClusterZoneAnnotation,
+	}
+
+	for label, annotation := range topology {
+		value, ok := secret.Labels[label]
+		if !ok || value == "" {
+			delete(sveltosCluster.Annotations, annotation)
+			continue
+		}
+
+		if sveltosCluster.Annotations == nil {
+			sveltosCluster.Annotations = make(map[string]string)
+		}
+		sveltosCluster.Annotations[annotation] = value
+	}
+}
+
+// addAutoTargetLabel adds, if configured, the auto-target label to SveltosCluster.
+// If the label is already present, its value is left untouched so users can override it.
+func (r *SecretReconciler) addAutoTargetLabel(sveltosCluster *libsveltosv1alpha1.SveltosCluster) {
+	if r.AutoTargetLabelKey == "" {
+		return
+	}
+
+	if sveltosCluster.Labels == nil {
+		sveltosCluster.Labels = make(map[string]string)
+	}
+
+	if _, ok := sveltosCluster.Labels[r.AutoTargetLabelKey]; ok {
+		return
+	}
+
+	sveltosCluster.Labels[r.AutoTargetLabelKey] = r.AutoTargetLabelValue
+}
+
+// copyAllowedLabels copies the PropagatedLabels present on the Claudie Secret to the SveltosCluster.
+// Labels not in PropagatedLabels, or missing on the Secret, are left untouched.
+func (r *SecretReconciler) copyAllowedLabels(secret *corev1.Secret, sveltosCluster *libsveltosv1alpha1.SveltosCluster) {
+	for _, key := range r.PropagatedLabels {
+		value, ok := secret.Labels[key]
+		if !ok {
+			continue
+		}
+
+		if sveltosCluster.Labels == nil {
+			sveltosCluster.Labels = make(map[string]string)
+		}
+		sveltosCluster.Labels[key] = value
+	}
 }
 
 // addOwnerReference adds secret as owner for sveltosCluster
 // When cleaning up, a SveltosCluster can be removed only if corresponding Secret is not present anymore.
+// When SkipOwnerReferences or RetainOnSecretDelete is set, or secret is in a different namespace (cross
+// namespace OwnerReferences are not allowed), secret is recorded via annotation instead.
+// Secret namespace is always recorded via annotation, so Secret can be found whatever the namespace
+// SveltosCluster is created in.
 func (r *SecretReconciler) addOwnerReference(sveltosCluster, secret client.Object) {
+	annotations := sveltosCluster.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[sveltosClusterSecretNamespaceAnnotation] = secret.GetNamespace()
+	sveltosCluster.SetAnnotations(annotations)
+
+	if r.RetainOnSecretDelete {
+		// Secret OwnerReferences would get SveltosCluster garbage collected on Secret deletion
+		ownerReferences := make([]metav1.OwnerReference, 0)
+		for _, ref := range sveltosCluster.GetOwnerReferences() {
+			if ref.Kind != "Secret" {
+				ownerReferences = append(ownerReferences, ref)
+			}
+		}
+		sveltosCluster.SetOwnerReferences(ownerReferences)
+	}
+
+	if r.SkipOwnerReferences || r.RetainOnSecretDelete || sveltosCluster.GetNamespace() != secret.GetNamespace() {
+		annotations[sveltosClusterSecretAnnotation] = secret.GetName()
+		sveltosCluster.SetAnnotations(annotations)
+		return
+	}
+
 	onwerReferences := sveltosCluster.GetOwnerReferences()
 	if onwerReferences == nil {
 		onwerReferences = make([]metav1.OwnerReference, 0)
 	}
 
+	found := false
 	for i := range onwerReferences {
 		ref := &onwerReferences[i]
-		if ref.Kind == secret.GetObjectKind().GroupVersionKind().Kind &&
-			ref.Name == secret.GetName() {
-
-			return
+		if ref.Kind != secret.GetObjectKind().GroupVersionKind().Kind {
+			continue
 		}
+
+		// At most one OwnerReference can be the controller one
+		isSecret := ref.Name == secret.GetName()
+		r.setOwnerReferenceController(ref, isSecret)
+		found = found || isSecret
 	}
 
-	apiVersion, kind := secret.GetObjectKind().GroupVersionKind().ToAPIVersionAndKind()
+	if !found {
+		apiVersion, kind := secret.GetObjectKind().GroupVersionKind().ToAPIVersionAndKind()
 
-	onwerReferences = append(onwerReferences,
-		metav1.OwnerReference{
+		ref := metav1.OwnerReference{
 			APIVersion: apiVersion,
 			Kind:       kind,
 			Name:       secret.GetName(),
 			UID:        secret.GetUID(),
-		},
-	)
+		}
+		r.setOwnerReferenceController(&ref, true)
+		onwerReferences = append(onwerReferences, ref)
+	}
 
 	sveltosCluster.SetOwnerReferences(onwerReferences)
 }
 
+// setOwnerReferenceController sets, when ControllerOwnerReference is true and controller is true,
+// Controller and BlockOwnerDeletion on the Secret OwnerReference ref. Otherwise it clears them.
+func (r *SecretReconciler) setOwnerReferenceController(ref *metav1.OwnerReference, controller bool) {
+	if !r.ControllerOwnerReference || !controller {
+		ref.Controller = nil
+		ref.BlockOwnerDeletion = nil
+		return
+	}
+
+	isController := true
+	blockOwnerDeletion := true
+	ref.Controller = &isController
+	ref.BlockOwnerDeletion = &blockOwnerDeletion
+}
+
 // updateSecretToClusterMap updates internal map that keeps track of SveltosCluster for a given Secret
 func (r *SecretReconciler) updateSecretToClusterMap(secret *corev1.Secret, sveltosClusterNamespace, sveltosClusterName string) {
 	secretRef := types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name}
+	r.secretToCluster.Set(secretRef, types.NamespacedName{Namespace: sveltosClusterNamespace, Name: sveltosClusterName})
+}
+
+// markSecretAsDeleting records, if DeletionRetention is set, that SveltosCluster for Secret has just
+// been removed
+func (r *SecretReconciler) markSecretAsDeleting(secretKey types.NamespacedName) {
+	if r.DeletionRetention == 0 {
+		return
+	}
+
+	r.deletingMux.Lock()
+	defer r.deletingMux.Unlock()
+
+	if r.deletingSecrets == nil {
+		r.deletingSecrets = make(map[types.NamespacedName]time.Time)
+	}
+
+	r.deletingSecrets[secretKey] = time.Now()
+}
+
+// getDeletionRetention returns for how long reconciliations of Secret must still be ignored
+// because its SveltosCluster was recently removed. Returns zero if Secret can be reconciled.
+// Expired entries are removed.
+func (r *SecretReconciler) getDeletionRetention(secretKey types.NamespacedName) time.Duration {
+	r.deletingMux.Lock()
+	defer r.deletingMux.Unlock()
 
-	r.Mux.Lock()
-	defer r.Mux.Unlock()
+	deletionTime, ok := r.deletingSecrets[secretKey]
+	if !ok {
+		return 0
+	}
 
-	r.SecretToCluster[secretRef] = types.NamespacedName{Namespace: sveltosClusterNamespace, Name: sveltosClusterName}
+	remaining := r.DeletionRetention - time.Since(deletionTime)
+	if remaining <= 0 {
+		delete(r.deletingSecrets, secretKey)
+		return 0
+	}
+
+	return remaining
+}
+
+// getConcurrentReconciles returns the maximum number of concurrent reconciliations. Zero would
+// leave controller-runtime to pick a single worker, so unset values fall back to the default.
+func (r *SecretReconciler) getConcurrentReconciles() int {
+	if r.ConcurrentReconciles < 1 {
+		return DefaultConcurrentReconciles
+	}
+	return r.ConcurrentReconciles
+}
+
+// addStaleSweep adds, via add, the runnable periodically removing stale SveltosClusters.
+// SveltosClusters are listed with reader. Nothing is added when DisableStaleSweep is set.
+func (r *SecretReconciler) addStaleSweep(add func(manager.Runnable) error, reader client.Reader,
+	logger logr.Logger) error {
+
+	if r.DisableStaleSweep {
+		logger.V(logs.LogInfo).Info("stale SveltosCluster sweep disabled")
+		return nil
+	}
+
+	return add(manager.RunnableFunc(func(ctx context.Context) error {
+		cleanStaleSveltosCluster(ctx, r.Client, reader, r.getClaudieMetadata(), r.getStaleSweepInterval(),
+			r.StaleGracePeriod, r.mapReady, logger)
+		return nil
+	}))
+}
+
+// getStaleSweepInterval returns the interval between stale SveltosCluster sweeps
+func (r *SecretReconciler) getStaleSweepInterval() time.Duration {
+	if r.StaleSweepInterval <= 0 {
+		return defaultStaleSweepInterval
+	}
+	return r.StaleSweepInterval
 }
 
-// cleanStaleSveltosCluster is a background task that fetches existing SveltosClusters.
+// cleanStaleSveltosCluster is a background task that, every interval (with jitter), fetches existing SveltosClusters.
 // If Owned by a Claudie secret that does not exist anymore, SveltosCluster is deleted.
-func cleanStaleSveltosCluster(ctx context.Context, c client.Client, logger logr.Logger) {
+// No SveltosCluster is deleted till mapReady is closed, nor before its Secret has been missing
+// for gracePeriod. Returns when ctx is done.
+func cleanStaleSveltosCluster(ctx context.Context, c client.Client, reader client.Reader, metadata *claudieMetadata,
+	interval, gracePeriod time.Duration, mapReady <-chan struct{}, logger logr.Logger) {
+
 	for {
-		const sleepTime = 2 * time.Minute
-		time.Sleep(sleepTime)
+		timer := time.NewTimer(jitter(interval, jitterFactor))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			logger.V(logs.LogInfo).Info("stopping stale SveltosCluster cleanup")
+			return
+		case <-timer.C:
+			removeStaleSveltosClusters(ctx, c, reader, metadata, gracePeriod, mapReady, logger)
+		}
+	}
+}
+
+// removeStaleSveltosClusters deletes all SveltosClusters created for a Claudie Secret which
+// does not exist anymore (for at least gracePeriod).
+// SveltosClusters are listed with reader, a page at a time. reader must serve List from the API
+// server (e.g. the manager APIReader): the cache does not support Continue, and with Limit set it
+// returns the first page only.
+// SveltosClusters with the Orphan delete policy are released instead, removing metadata.
+// Right after a restart, SecretToCluster map is not rebuilt yet. Till mapReady is closed,
+// deletions are deferred to a later pass.
+func removeStaleSveltosClusters(ctx context.Context, c client.Client, reader client.Reader, metadata *claudieMetadata,
+	gracePeriod time.Duration, mapReady <-chan struct{}, logger logr.Logger) {
+
+	select {
+	case <-mapReady:
+	default:
+		logger.V(logs.LogInfo).Info("SecretToCluster map not rebuilt yet. Deferring stale SveltosCluster cleanup")
+		return
+	}
 
+	listOptions := []client.ListOption{client.Limit(staleSweepPageSize)}
+	for {
+		// SveltosClusters are listed a page at a time, so memory is bounded in large fleets
 		sveltosClusters := &libsveltosv1alpha1.SveltosClusterList{}
-		err := c.List(context.TODO(), sveltosClusters)
+		err := reader.List(ctx, sveltosClusters, listOptions...)
 		if err != nil {
-			continue
+			logger.V(logs.LogInfo).Info(fmt.Sprintf("failed to list SveltosClusters: %v", err))
+			return
 		}
 
 		for i := range sveltosClusters.Items {
-			sveltosCluster := &sveltosClusters.Items[i]
-
-			// ignore SveltosCluster if marked for deletion
-			if !sveltosCluster.DeletionTimestamp.IsZero() {
-				continue
+			// Manager is shutting down. Do not issue requests against a closing client.
+			if ctx.Err() != nil {
+				logger.V(logs.LogInfo).Info("context canceled. Stopping stale SveltosCluster cleanup")
+				return
 			}
 
-			// ignore SveltosCluster if not created for a Claudie Secret
-			if !isSveltosClusterForClaudie(sveltosCluster) {
-				continue
-			}
+			removeIfStale(ctx, c, &sveltosClusters.Items[i], metadata, gracePeriod, logger)
+		}
 
-			claudieSecret := getClaudieSecret(sveltosCluster)
-			if claudieSecret == nil {
-				logger.V(logs.LogInfo).Info(
-					fmt.Sprintf("found SveltosCluster %s/%s with no Claudie reference",
-						sveltosCluster.Namespace, sveltosCluster.Name))
-			}
+		if sveltosClusters.Continue == "" {
+			break
+		}
+		listOptions = []client.ListOption{client.Limit(staleSweepPageSize), client.Continue(sveltosClusters.Continue)}
+	}
 
-			if !isClaudieSecretRemoved(ctx, c, claudieSecret) {
-				continue
-			}
+	// Only completed sweeps are reported, so alerts fire when sweeps stop running or keep failing
+	lastSweepTimestamp.SetToCurrentTime()
+}
 
-			err = c.Delete(ctx, sveltosCluster)
-			if err != nil {
-				logger.V(logs.LogInfo).Info(
-					fmt.Sprintf("failed to delete sveltosCluster %s/%s: %v",
-						sveltosCluster.Namespace, sveltosCluster.Name, err))
-			}
-		}
+// removeIfStale deletes sveltosCluster if it was created for a Claudie Secret which does not exist
+// anymore (for at least gracePeriod)
+func removeIfStale(ctx context.Context, c client.Client, sveltosCluster *libsveltosv1alpha1.SveltosCluster,
+	metadata *claudieMetadata, gracePeriod time.Duration, logger logr.Logger) {
+
+	// ignore SveltosCluster if marked for deletion
+	if !sveltosCluster.DeletionTimestamp.IsZero() {
+		return
+	}
+
+	// ignore SveltosCluster if not created for a Claudie Secret or released from Claudie management
+	if !isSveltosClusterForClaudie(sveltosCluster) || isUnmanaged(sveltosCluster) {
+		return
+	}
+
+	sveltosClusterLogger := logger.WithValues(logKeySveltosCluster,
+		types.NamespacedName{Namespace: sveltosCluster.Namespace, Name: sveltosCluster.Name}.String())
+
+	claudieSecret := getClaudieSecret(sveltosCluster)
+	if claudieSecret == nil {
+		sveltosClusterLogger.V(logs.LogInfo).Info("found SveltosCluster with no Claudie reference")
+		return
+	}
+	sveltosClusterLogger = sveltosClusterLogger.WithValues(logKeySecret, claudieSecret.String())
+
+	if isSveltosClusterExpired(sveltosCluster, time.Now()) {
+		removeExpiredSveltosCluster(ctx, c, sveltosCluster, claudieSecret, sveltosClusterLogger)
+		return
+	}
+
+	// Retained SveltosClusters outlive their Claudie Secret
+	if isRetained(sveltosCluster) {
+		return
+	}
+
+	if !areClaudieSecretsRemoved(ctx, c, sveltosCluster, sveltosClusterLogger) {
+		clearSecretMissing(ctx, c, sveltosCluster, sveltosClusterLogger)
+		return
+	}
+
+	// Claudie Secrets might briefly disappear (e.g. during Claudie upgrades). Wait for the grace
+	// period to avoid SveltosCluster churn and add-ons redeployment.
+	if !isStaleGracePeriodOver(ctx, c, sveltosCluster, gracePeriod, time.Now(), sveltosClusterLogger) {
+		return
+	}
+
+	staleDetected.Inc()
+	if isOrphanPolicy(sveltosCluster) {
+		orphanStaleSveltosCluster(ctx, c, sveltosCluster, metadata, sveltosClusterLogger)
+		return
+	}
+
+	err := deleteWithRetry(ctx, c, sveltosCluster)
+	if err != nil {
+		staleDeleteErrors.Inc()
+		sveltosClusterLogger.V(logs.LogInfo).Info(fmt.Sprintf("failed to delete sveltosCluster: %v", err))
+		return
+	}
+
+	err = removeMirroredKubeconfig(ctx, c,
+		types.NamespacedName{Namespace: sveltosCluster.Namespace, Name: sveltosCluster.Name})
+	if err != nil {
+		sveltosClusterLogger.V(logs.LogInfo).Info(
+			fmt.Sprintf("failed to delete mirrored kubeconfig for sveltosCluster: %v", err))
 	}
 }
 
@@ -339,26 +1574,114 @@ func isSveltosClusterForClaudie(sveltosCluster *libsveltosv1alpha1.SveltosCluste
 	return ok
 }
 
+// getClaudieSecret returns the Claudie Secret sveltosCluster was created for. When more than one Secret
+// owns sveltosCluster (only possible after manual edits), the owner whose UID is recorded in the Claudie
+// annotation wins. Otherwise the first Secret owner is returned.
 func getClaudieSecret(sveltosCluster *libsveltosv1alpha1.SveltosCluster) *types.NamespacedName {
+	markedUID := getMarkedSecretUID(sveltosCluster)
+
+	var claudieSecret *types.NamespacedName
 	for i := range sveltosCluster.OwnerReferences {
 		ref := &sveltosCluster.OwnerReferences[i]
-		if ref.Kind == "Secret" {
-			return &types.NamespacedName{
+		if ref.Kind != "Secret" {
+			continue
+		}
+
+		if claudieSecret == nil || (markedUID != "" && ref.UID == markedUID) {
+			claudieSecret = &types.NamespacedName{
 				Name:      ref.Name,
-				Namespace: sveltosCluster.Namespace,
+				Namespace: getClaudieSecretNamespace(sveltosCluster),
 			}
 		}
+		if markedUID == "" || ref.UID == markedUID {
+			break
+		}
+	}
+	if claudieSecret != nil {
+		return claudieSecret
+	}
+
+	// OwnerReferences are not added when SkipOwnerReferences is set or Secret is in a different namespace
+	if secretName := sveltosCluster.Annotations[sveltosClusterSecretAnnotation]; secretName != "" {
+		return &types.NamespacedName{
+			Name:      secretName,
+			Namespace: getClaudieSecretNamespace(sveltosCluster),
+		}
 	}
 
 	return nil
 }
 
-func isClaudieSecretRemoved(ctx context.Context, c client.Client, claudieSecret *types.NamespacedName) bool {
+// getClaudieSecretNamespace returns the namespace of the Claudie Secret sveltosCluster was created for.
+// SveltosClusters created by previous versions do not record it when Secret is in their own namespace.
+func getClaudieSecretNamespace(sveltosCluster *libsveltosv1alpha1.SveltosCluster) string {
+	if secretNamespace := sveltosCluster.Annotations[sveltosClusterSecretNamespaceAnnotation]; secretNamespace != "" {
+		return secretNamespace
+	}
+	return sveltosCluster.Namespace
+}
+
+// getClaudieSecrets returns all Secrets owning sveltosCluster. Falls back to getClaudieSecret when
+// ownership is tracked via annotations only.
+func getClaudieSecrets(sveltosCluster *libsveltosv1alpha1.SveltosCluster) []types.NamespacedName {
+	claudieSecrets := make([]types.NamespacedName, 0, 1)
+	for i := range sveltosCluster.OwnerReferences {
+		ref := &sveltosCluster.OwnerReferences[i]
+		if ref.Kind == "Secret" {
+			claudieSecrets = append(claudieSecrets,
+				types.NamespacedName{Namespace: getClaudieSecretNamespace(sveltosCluster), Name: ref.Name})
+		}
+	}
+
+	if len(claudieSecrets) == 0 {
+		if claudieSecret := getClaudieSecret(sveltosCluster); claudieSecret != nil {
+			claudieSecrets = append(claudieSecrets, *claudieSecret)
+		}
+	}
+
+	return claudieSecrets
+}
+
+// areClaudieSecretsRemoved returns true if all Secrets owning sveltosCluster are removed. Secret UID
+// recorded in the Claudie annotation is only verified for the Secret returned by getClaudieSecret.
+func areClaudieSecretsRemoved(ctx context.Context, c client.Client,
+	sveltosCluster *libsveltosv1alpha1.SveltosCluster, logger logr.Logger) bool {
+
+	claudieSecret := getClaudieSecret(sveltosCluster)
+	claudieSecrets := getClaudieSecrets(sveltosCluster)
+	if len(claudieSecrets) > 1 {
+		logger.V(logs.LogInfo).Info(
+			fmt.Sprintf("SveltosCluster is owned by %d Secrets. It is removed only once all of them are",
+				len(claudieSecrets)))
+	}
+
+	for i := range claudieSecrets {
+		var uid types.UID
+		if claudieSecret != nil && claudieSecrets[i] == *claudieSecret {
+			uid = getMarkedSecretUID(sveltosCluster)
+		}
+		if !isClaudieSecretRemoved(ctx, c, &claudieSecrets[i], uid) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isClaudieSecretRemoved returns true if claudieSecret does not exist or is being deleted. When uid is
+// set, a Secret with same name but a different UID was recreated and the original one is considered removed.
+func isClaudieSecretRemoved(ctx context.Context, c client.Client, claudieSecret *types.NamespacedName,
+	uid types.UID) bool {
+
 	secret := &corev1.Secret{}
 	err := c.Get(ctx, *claudieSecret, secret)
 	if err != nil {
 		return apierrors.IsNotFound(err)
 	}
 
+	if uid != "" && secret.UID != "" && secret.UID != uid {
+		return true
+	}
+
 	return !secret.DeletionTimestamp.IsZero()
 }
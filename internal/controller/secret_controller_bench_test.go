@@ -0,0 +1,62 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"fmt"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"gianlucam76/claudie-sveltos-integration/internal/controller"
+)
+
+// BenchmarkIsSecretTracked measures SecretToCluster lookups, done for every Secret event, performed
+// by many workers in parallel while SecretToCluster is occasionally updated
+func BenchmarkIsSecretTracked(b *testing.B) {
+	const trackedSecrets = 1000
+
+	reconciler := &controller.SecretReconciler{}
+
+	secrets := make([]*corev1.Secret, trackedSecrets)
+	for i := range secrets {
+		secrets[i] = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "claudie", Name: fmt.Sprintf("secret-%d", i)},
+		}
+		reconciler.TrackSveltosCluster(types.NamespacedName{Namespace: "claudie", Name: secrets[i].Name},
+			types.NamespacedName{Namespace: "claudie", Name: fmt.Sprintf("cluster-%d", i)})
+	}
+
+	b.SetParallelism(10)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			secret := secrets[i%trackedSecrets]
+			if i%100 == 0 {
+				controller.ForgetSveltosCluster(reconciler,
+					types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name},
+					types.NamespacedName{Namespace: "claudie", Name: randomString()}, false)
+			} else {
+				controller.IsSecretTracked(reconciler, secret)
+			}
+			i++
+		}
+	})
+}
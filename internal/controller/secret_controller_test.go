@@ -18,19 +18,28 @@ package controller_test
 
 import (
 	"context"
+	"fmt"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/go-logr/logr"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/client-go/tools/record"
 
 	"gianlucam76/claudie-sveltos-integration/internal/controller"
 
@@ -56,23 +65,114 @@ var _ = Describe("SecretReconciler", func() {
 
 		sveltosCluster.Annotations[controller.SveltosClusterClaudieAnnotation] = "ok"
 		Expect(controller.IsSveltosClusterForClaudie(sveltosCluster)).To(BeTrue())
+
+		sveltosCluster.Annotations[controller.SveltosClusterClaudieAnnotation] = "v2:" + randomString()
+		Expect(controller.IsSveltosClusterForClaudie(sveltosCluster)).To(BeTrue())
+	})
+
+	It("getConcurrentReconciles defaults to 10 and never returns less than 1", func() {
+		reconciler := getSecretReconciler(fake.NewClientBuilder().WithScheme(scheme).Build())
+
+		reconciler.ConcurrentReconciles = 0
+		Expect(controller.GetConcurrentReconciles(reconciler)).To(Equal(10))
+
+		reconciler.ConcurrentReconciles = -1
+		Expect(controller.GetConcurrentReconciles(reconciler)).To(Equal(10))
+
+		reconciler.ConcurrentReconciles = 3
+		Expect(controller.GetConcurrentReconciles(reconciler)).To(Equal(3))
+	})
+
+	It("addStaleSweep does not start the stale sweep when DisableStaleSweep is set", func() {
+		reconciler := getSecretReconciler(fake.NewClientBuilder().WithScheme(scheme).Build())
+
+		added := 0
+		add := func(_ manager.Runnable) error {
+			added++
+			return nil
+		}
+
+		reconciler.DisableStaleSweep = true
+		Expect(controller.AddStaleSweep(reconciler, add, reconciler.Client, logr.Logger{})).To(Succeed())
+		Expect(added).To(BeZero())
+
+		reconciler.DisableStaleSweep = false
+		Expect(controller.AddStaleSweep(reconciler, add, reconciler.Client, logr.Logger{})).To(Succeed())
+		Expect(added).To(Equal(1))
 	})
 
 	It("isClaudieSecretRemoved returns true when Secret is not existing anymore", func() {
 		c := fake.NewClientBuilder().WithScheme(scheme).Build()
 
 		claudieSecret := &types.NamespacedName{Namespace: randomString(), Name: randomString()}
-		Expect(controller.IsClaudieSecretRemoved(context.TODO(), c, claudieSecret)).To(BeTrue())
+		Expect(controller.IsClaudieSecretRemoved(context.TODO(), c, claudieSecret, "")).To(BeTrue())
 
 		secret := &corev1.Secret{
 			ObjectMeta: metav1.ObjectMeta{
 				Namespace: claudieSecret.Namespace,
 				Name:      claudieSecret.Name,
+				UID:       types.UID(randomString()),
 			},
 		}
 
 		Expect(c.Create(context.TODO(), secret)).To(Succeed())
-		Expect(controller.IsClaudieSecretRemoved(context.TODO(), c, claudieSecret)).To(BeFalse())
+		Expect(controller.IsClaudieSecretRemoved(context.TODO(), c, claudieSecret, "")).To(BeFalse())
+		Expect(controller.IsClaudieSecretRemoved(context.TODO(), c, claudieSecret, secret.UID)).To(BeFalse())
+
+		// A Secret with same name but different UID was recreated: original one was removed
+		Expect(controller.IsClaudieSecretRemoved(context.TODO(), c, claudieSecret, types.UID(randomString()))).To(BeTrue())
+	})
+
+	It("addOwnerReference records Secret namespace and getClaudieSecret prefers it", func() {
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+
+		reconciler := getSecretReconciler(fake.NewClientBuilder().WithScheme(scheme).Build())
+		reconciler.SkipOwnerReferences = true
+
+		// SveltosCluster in a different namespace than the Claudie Secret
+		sveltosCluster := &libsveltosv1alpha1.SveltosCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: randomString(),
+				Name:      randomString(),
+			},
+		}
+		controller.AddOwnerReference(reconciler, sveltosCluster, secret)
+		Expect(sveltosCluster.Annotations).To(HaveKeyWithValue(controller.SveltosClusterSecretNamespaceAnnotation,
+			secret.Namespace))
+		Expect(controller.GetClaudieSecret(sveltosCluster)).To(Equal(
+			&types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name}))
+
+		// Namespace is recorded even when SveltosCluster is in the Claudie Secret namespace
+		sveltosCluster.Namespace = secret.Namespace
+		sveltosCluster.Annotations = nil
+		reconciler.SkipOwnerReferences = false
+		controller.AddOwnerReference(reconciler, sveltosCluster, secret)
+		Expect(sveltosCluster.OwnerReferences).To(HaveLen(1))
+		Expect(sveltosCluster.Annotations).To(HaveKeyWithValue(controller.SveltosClusterSecretNamespaceAnnotation,
+			secret.Namespace))
+	})
+
+	It("getClaudieSecret falls back to SveltosCluster namespace for legacy SveltosClusters", func() {
+		secretName := randomString()
+		sveltosCluster := &libsveltosv1alpha1.SveltosCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: randomString(),
+				Name:      randomString(),
+				Annotations: map[string]string{
+					controller.SveltosClusterSecretAnnotation: secretName,
+				},
+			},
+		}
+		Expect(controller.GetClaudieSecret(sveltosCluster)).To(Equal(
+			&types.NamespacedName{Namespace: sveltosCluster.Namespace, Name: secretName}))
+
+		sveltosCluster.Annotations = nil
+		sveltosCluster.OwnerReferences = []metav1.OwnerReference{
+			{Kind: "Secret", APIVersion: "v1", Name: secretName},
+		}
+		Expect(controller.GetClaudieSecret(sveltosCluster)).To(Equal(
+			&types.NamespacedName{Namespace: sveltosCluster.Namespace, Name: secretName}))
 	})
 
 	It("getClaudieSecret returns secret", func() {
@@ -103,6 +203,72 @@ var _ = Describe("SecretReconciler", func() {
 		Expect(secretInfo.Name).To(Equal(secret.Name))
 	})
 
+	It("getClaudieSecret returns the Secret recorded in Claudie annotation when owned by multiple Secrets", func() {
+		namespace := randomString()
+		first := metav1.OwnerReference{Kind: "Secret", APIVersion: "v1", Name: randomString(), UID: types.UID(randomString())}
+		second := metav1.OwnerReference{Kind: "Secret", APIVersion: "v1", Name: randomString(), UID: types.UID(randomString())}
+
+		sveltosCluster := &libsveltosv1alpha1.SveltosCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:       namespace,
+				Name:            randomString(),
+				OwnerReferences: []metav1.OwnerReference{first, second},
+			},
+		}
+
+		// Without provenance, first Secret owner is returned
+		Expect(*controller.GetClaudieSecret(sveltosCluster)).To(Equal(types.NamespacedName{Namespace: namespace, Name: first.Name}))
+
+		sveltosCluster.Annotations = map[string]string{
+			controller.SveltosClusterClaudieAnnotation: "v2:" + string(second.UID),
+		}
+		Expect(*controller.GetClaudieSecret(sveltosCluster)).To(Equal(types.NamespacedName{Namespace: namespace, Name: second.Name}))
+
+		// Recorded Secret not among owners
+		sveltosCluster.Annotations[controller.SveltosClusterClaudieAnnotation] = "v2:" + randomString()
+		Expect(*controller.GetClaudieSecret(sveltosCluster)).To(Equal(types.NamespacedName{Namespace: namespace, Name: first.Name}))
+
+		Expect(controller.GetClaudieSecrets(sveltosCluster)).To(ConsistOf(
+			types.NamespacedName{Namespace: namespace, Name: first.Name},
+			types.NamespacedName{Namespace: namespace, Name: second.Name}))
+	})
+
+	It("removeStaleSveltosClusters removes SveltosClusters owned by multiple Secrets once all are removed", func() {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: randomString(),
+				Name:      randomString(),
+			},
+		}
+
+		sveltosCluster := &libsveltosv1alpha1.SveltosCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: secret.Namespace,
+				Name:      randomString(),
+				Annotations: map[string]string{
+					controller.SveltosClusterClaudieAnnotation: "ok",
+				},
+				OwnerReferences: []metav1.OwnerReference{
+					{Kind: "Secret", APIVersion: "v1", Name: randomString()},
+					{Kind: "Secret", APIVersion: "v1", Name: secret.Name},
+				},
+			},
+		}
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret, sveltosCluster).Build()
+
+		mapReady := make(chan struct{})
+		close(mapReady)
+		controller.RemoveStaleSveltosClusters(context.TODO(), c, c, controller.DefaultClaudieMetadata, 0, mapReady, logr.Logger{})
+		Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(sveltosCluster), sveltosCluster)).To(Succeed())
+
+		Expect(c.Delete(context.TODO(), secret)).To(Succeed())
+		controller.RemoveStaleSveltosClusters(context.TODO(), c, c, controller.DefaultClaudieMetadata, 0, mapReady, logr.Logger{})
+		err := c.Get(context.TODO(), client.ObjectKeyFromObject(sveltosCluster), sveltosCluster)
+		Expect(err).ToNot(BeNil())
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+
 	It("shouldReconcileSecret returns true for Claudie secrets", func() {
 		c := fake.NewClientBuilder().WithScheme(scheme).Build()
 		reconciler := getSecretReconciler(c)
@@ -131,6 +297,133 @@ var _ = Describe("SecretReconciler", func() {
 		Expect(controller.ShouldReconcileSecret(reconciler, secret)).To(BeTrue())
 	})
 
+	It("shouldReconcileSecret and getSveltosClusterName use configured label keys", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := getSecretReconciler(c)
+		reconciler.ClaudieLabel = "example.com/part-of"
+		reconciler.ClaudieKubeconfigLabel = "example.com/output"
+		reconciler.ClaudieClusterLabel = "example.com/cluster"
+
+		clusterName := randomString()
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: randomString(),
+				Name:      randomString(),
+				Labels: map[string]string{
+					controller.ClaudieLabel:      randomString(),
+					controller.ClaudieKubeconfig: randomString(),
+					controller.ClaudieCluster:    randomString(),
+				},
+			},
+		}
+
+		// Default label keys are not considered anymore
+		Expect(controller.ShouldReconcileSecret(reconciler, secret)).To(BeFalse())
+
+		secret.Labels["example.com/part-of"] = randomString()
+		secret.Labels["example.com/output"] = randomString()
+		Expect(controller.ShouldReconcileSecret(reconciler, secret)).To(BeFalse())
+
+		secret.Labels["example.com/cluster"] = clusterName
+		Expect(controller.ShouldReconcileSecret(reconciler, secret)).To(BeTrue())
+		Expect(controller.GetSveltosClusterName(reconciler, secret)).To(Equal(clusterName))
+	})
+
+	It("shouldReconcileSecret only returns true for Claudie secrets matching the secret selector", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := getSecretReconciler(c)
+		reconciler.SecretFilters.Selector = labels.SelectorFromSet(labels.Set{"team": "a"})
+
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: randomString(),
+				Name:      randomString(),
+				Labels: map[string]string{
+					"team": "a",
+				},
+			},
+		}
+
+		// Selector alone is not enough
+		Expect(controller.ShouldReconcileSecret(reconciler, secret)).To(BeFalse())
+
+		secret.Labels[controller.ClaudieLabel] = randomString()
+		secret.Labels[controller.ClaudieKubeconfig] = randomString()
+		secret.Labels[controller.ClaudieCluster] = randomString()
+		Expect(controller.ShouldReconcileSecret(reconciler, secret)).To(BeTrue())
+
+		secret.Labels["team"] = "b"
+		Expect(controller.ShouldReconcileSecret(reconciler, secret)).To(BeFalse())
+	})
+
+	It("Reconcile ignores Claudie Secrets not matching the secret selector", func() {
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+		reconciler := getSecretReconciler(c)
+		reconciler.SecretFilters.Selector = labels.SelectorFromSet(labels.Set{"team": "a"})
+
+		secretRef := reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name},
+		}
+		sveltosClusterKey := types.NamespacedName{Namespace: secret.Namespace, Name: secret.Labels[controller.ClaudieCluster]}
+
+		result, err := reconciler.Reconcile(context.TODO(), secretRef)
+		Expect(err).To(BeNil())
+		Expect(result.Requeue).To(BeFalse())
+		err = c.Get(context.TODO(), sveltosClusterKey, &libsveltosv1alpha1.SveltosCluster{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		Expect(reconciler.SecretToCluster()).ToNot(HaveKey(secretRef.NamespacedName))
+
+		currentSecret := &corev1.Secret{}
+		Expect(c.Get(context.TODO(), secretRef.NamespacedName, currentSecret)).To(Succeed())
+		Expect(currentSecret.Finalizers).ToNot(ContainElement(controller.ClaudieCleanupFinalizer))
+
+		// Once Secret matches, it is managed
+		currentSecret.Labels["team"] = "a"
+		Expect(c.Update(context.TODO(), currentSecret)).To(Succeed())
+
+		_, err = reconciler.Reconcile(context.TODO(), secretRef)
+		Expect(err).To(BeNil())
+		Expect(c.Get(context.TODO(), sveltosClusterKey, &libsveltosv1alpha1.SveltosCluster{})).To(Succeed())
+		Expect(reconciler.SecretToCluster()).To(HaveKeyWithValue(secretRef.NamespacedName, sveltosClusterKey))
+	})
+
+	It("isClaudieVersionSupported skips Secrets produced by Claudie versions older than minimum", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := getSecretReconciler(c)
+
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: randomString(),
+				Name:      randomString(),
+				Annotations: map[string]string{
+					controller.ClaudieVersionAnnotation: "v0.7.2",
+				},
+			},
+		}
+
+		// No minimum version configured
+		Expect(controller.IsClaudieVersionSupported(reconciler, secret, logr.Logger{})).To(BeTrue())
+
+		reconciler.MinClaudieVersion = version.MustParseGeneric("v0.8.0")
+		Expect(controller.IsClaudieVersionSupported(reconciler, secret, logr.Logger{})).To(BeFalse())
+
+		secret.Annotations[controller.ClaudieVersionAnnotation] = "v0.8.0"
+		Expect(controller.IsClaudieVersionSupported(reconciler, secret, logr.Logger{})).To(BeTrue())
+
+		secret.Annotations[controller.ClaudieVersionAnnotation] = "v0.9.1"
+		Expect(controller.IsClaudieVersionSupported(reconciler, secret, logr.Logger{})).To(BeTrue())
+
+		secret.Annotations[controller.ClaudieVersionAnnotation] = randomString()
+		Expect(controller.IsClaudieVersionSupported(reconciler, secret, logr.Logger{})).To(BeFalse())
+
+		// Secret not reporting any version
+		delete(secret.Annotations, controller.ClaudieVersionAnnotation)
+		Expect(controller.IsClaudieVersionSupported(reconciler, secret, logr.Logger{})).To(BeTrue())
+	})
+
 	It("getSveltosClusterNamespace returns secret namespace", func() {
 		c := fake.NewClientBuilder().WithScheme(scheme).Build()
 		reconciler := getSecretReconciler(c)
@@ -145,6 +438,110 @@ var _ = Describe("SecretReconciler", func() {
 		Expect(controller.GetSveltosClusterNamespace(reconciler, secret)).To(Equal(secret.Namespace))
 	})
 
+	It("cleanSveltosCluster records a Warning event on the Secret when SveltosCluster delete fails", func() {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: randomString(),
+				Name:      randomString(),
+			},
+		}
+
+		sveltosCluster := &libsveltosv1alpha1.SveltosCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: secret.Namespace,
+				Name:      randomString(),
+			},
+		}
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret, sveltosCluster).
+			WithInterceptorFuncs(interceptor.Funcs{
+				Delete: func(ctx context.Context, wc client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+					return apierrors.NewForbidden(libsveltosv1alpha1.GroupVersion.WithResource("sveltosclusters").GroupResource(),
+						obj.GetName(), nil)
+				},
+			}).Build()
+		reconciler := getSecretReconciler(c)
+		recorder := record.NewFakeRecorder(10)
+		reconciler.EventRecorder = recorder
+
+		secretRef := reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name},
+		}
+		reconciler.TrackSveltosCluster(secretRef.NamespacedName, types.NamespacedName{
+			Namespace: sveltosCluster.Namespace, Name: sveltosCluster.Name,
+		})
+
+		Expect(controller.CleanSveltosCluster(reconciler, context.TODO(), secretRef, logr.Logger{})).ToNot(Succeed())
+		Expect(recorder.Events).To(Receive(And(
+			HavePrefix(corev1.EventTypeWarning),
+			ContainSubstring(controller.ReasonSveltosClusterDeleteFailed),
+			ContainSubstring(sveltosCluster.Name))))
+
+		// Entry is kept so cleanup is retried
+		Expect(reconciler.SecretToCluster()).To(HaveKey(secretRef.NamespacedName))
+	})
+
+	DescribeTable("cleanSveltosCluster retries SveltosCluster deletion failing with transient errors",
+		func(failures int, succeeds bool) {
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: randomString(),
+					Name:      randomString(),
+				},
+			}
+
+			sveltosCluster := &libsveltosv1alpha1.SveltosCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: secret.Namespace,
+					Name:      randomString(),
+				},
+			}
+
+			deletes := 0
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret, sveltosCluster).
+				WithInterceptorFuncs(interceptor.Funcs{
+					Delete: func(ctx context.Context, wc client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+						if _, ok := obj.(*libsveltosv1alpha1.SveltosCluster); ok {
+							deletes++
+							if deletes <= failures {
+								return apierrors.NewServiceUnavailable("API server is restarting")
+							}
+						}
+						return wc.Delete(ctx, obj, opts...)
+					},
+				}).Build()
+			reconciler := getSecretReconciler(c)
+			recorder := record.NewFakeRecorder(10)
+			reconciler.EventRecorder = recorder
+
+			secretRef := reconcile.Request{
+				NamespacedName: types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name},
+			}
+			reconciler.TrackSveltosCluster(secretRef.NamespacedName, types.NamespacedName{
+				Namespace: sveltosCluster.Namespace, Name: sveltosCluster.Name,
+			})
+
+			before := testutil.ToFloat64(controller.SveltosClusterDeleteFailures)
+			err := controller.CleanSveltosCluster(reconciler, context.TODO(), secretRef, logr.Logger{})
+			if succeeds {
+				Expect(err).To(BeNil())
+				Expect(deletes).To(Equal(failures + 1))
+				Expect(reconciler.SecretToCluster()).ToNot(HaveKey(secretRef.NamespacedName))
+				Expect(testutil.ToFloat64(controller.SveltosClusterDeleteFailures)).To(Equal(before))
+				return
+			}
+
+			// Retries are capped, persistent failure is reported
+			Expect(err).ToNot(BeNil())
+			Expect(deletes).To(BeNumerically("<", failures))
+			Expect(recorder.Events).To(Receive(ContainSubstring(controller.ReasonSveltosClusterDeleteFailed)))
+			Expect(testutil.ToFloat64(controller.SveltosClusterDeleteFailures)).To(Equal(before + 1))
+			Expect(reconciler.SecretToCluster()).To(HaveKey(secretRef.NamespacedName))
+		},
+		Entry("transient failures", 2, true),
+		Entry("persistent failures", 100, false),
+	)
+
 	It("cleanSveltosCluster deletes SveltosCluster", func() {
 		secret := &corev1.Secret{
 			ObjectMeta: metav1.ObjectMeta{
@@ -178,9 +575,9 @@ var _ = Describe("SecretReconciler", func() {
 			NamespacedName: types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name},
 		}
 
-		reconciler.SecretToCluster[secretRef.NamespacedName] = types.NamespacedName{
+		reconciler.TrackSveltosCluster(secretRef.NamespacedName, types.NamespacedName{
 			Namespace: sveltosCluster.Namespace, Name: sveltosCluster.Name,
-		}
+		})
 
 		Expect(controller.CleanSveltosCluster(reconciler, context.TODO(), secretRef, logr.Logger{})).To(BeNil())
 
@@ -192,57 +589,42 @@ var _ = Describe("SecretReconciler", func() {
 		Expect(apierrors.IsNotFound(err)).To(BeTrue())
 	})
 
-	It("addOwnerReference add secret as SveltosCluster's OwnerReference", func() {
-		secret := &corev1.Secret{
-			ObjectMeta: metav1.ObjectMeta{
-				Namespace: randomString(),
-				Name:      randomString(),
-			},
-		}
-		Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
-
+	It("Reconcile returns no error when Secret is absent and only fails on genuine fetch errors", func() {
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
 		sveltosCluster := &libsveltosv1alpha1.SveltosCluster{
 			ObjectMeta: metav1.ObjectMeta{
 				Namespace: secret.Namespace,
-				Name:      randomString(),
+				Name:      secret.Labels[controller.ClaudieCluster],
 			},
 		}
 
-		initObjects := []client.Object{
-			secret, sveltosCluster,
-		}
-
-		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(initObjects...).Build()
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(sveltosCluster).Build()
 		reconciler := getSecretReconciler(c)
 
-		controller.AddOwnerReference(reconciler, sveltosCluster, secret)
-
-		Expect(sveltosCluster.OwnerReferences).ToNot(BeNil())
-		Expect(len(sveltosCluster.OwnerReferences)).To(Equal(1))
-		Expect(sveltosCluster.OwnerReferences[0].Kind).To(Equal("Secret"))
-		Expect(sveltosCluster.OwnerReferences[0].Name).To(Equal(secret.Name))
-	})
+		secretRef := reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name},
+		}
+		sveltosClusterKey := types.NamespacedName{Namespace: sveltosCluster.Namespace, Name: sveltosCluster.Name}
+		reconciler.TrackSveltosCluster(secretRef.NamespacedName, sveltosClusterKey)
 
-	It("addAnnotation adds claudie annotation to SveltosCluster", func() {
-		c := fake.NewClientBuilder().WithScheme(scheme).Build()
-		reconciler := getSecretReconciler(c)
+		result, err := reconciler.Reconcile(context.TODO(), secretRef)
+		Expect(err).To(BeNil())
+		Expect(result).To(Equal(reconcile.Result{}))
+		err = c.Get(context.TODO(), sveltosClusterKey, &libsveltosv1alpha1.SveltosCluster{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
 
-		sveltosCluster := &libsveltosv1alpha1.SveltosCluster{
-			ObjectMeta: metav1.ObjectMeta{
-				Namespace: randomString(),
-				Name:      randomString(),
+		c = fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, wc client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				return apierrors.NewServiceUnavailable("API server unavailable")
 			},
-		}
+		}).Build()
+		reconciler = getSecretReconciler(c)
 
-		controller.AddAnnotation(reconciler, sveltosCluster)
-		Expect(sveltosCluster.Annotations).ToNot(BeNil())
-		Expect(sveltosCluster.Annotations[controller.SveltosClusterClaudieAnnotation]).ToNot(BeEmpty())
+		_, err = reconciler.Reconcile(context.TODO(), secretRef)
+		Expect(err).ToNot(BeNil())
 	})
 
-	It("createSveltosCluster creates a SveltosCluster for a Claudie secret", func() {
-		c := fake.NewClientBuilder().WithScheme(scheme).Build()
-		reconciler := getSecretReconciler(c)
-
+	It("Reconcile does not recreate SveltosCluster within the deletion retention window", func() {
 		secret := &corev1.Secret{
 			ObjectMeta: metav1.ObjectMeta{
 				Namespace: randomString(),
@@ -255,25 +637,1327 @@ var _ = Describe("SecretReconciler", func() {
 			},
 		}
 
-		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+		sveltosCluster := &libsveltosv1alpha1.SveltosCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: secret.Namespace,
+				Name:      secret.Labels[controller.ClaudieCluster],
+			},
+		}
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret, sveltosCluster).Build()
+		reconciler := getSecretReconciler(c)
+		reconciler.DeletionRetention = time.Minute
+
+		secretRef := reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name},
+		}
+		reconciler.TrackSveltosCluster(secretRef.NamespacedName, types.NamespacedName{
+			Namespace: sveltosCluster.Namespace, Name: sveltosCluster.Name,
+		})
+
+		Expect(controller.CleanSveltosCluster(reconciler, context.TODO(), secretRef, logr.Logger{})).To(Succeed())
+		Expect(controller.GetDeletionRetention(reconciler, secretRef.NamespacedName)).To(BeNumerically(">", 0))
+
+		// A late-arriving event for the Secret does not recreate the SveltosCluster
+		result, err := reconciler.Reconcile(context.TODO(), secretRef)
+		Expect(err).To(BeNil())
+		Expect(result.RequeueAfter).To(BeNumerically(">", 0))
 
 		currentSveltosClusters := &libsveltosv1alpha1.SveltosClusterList{}
 		Expect(c.List(context.TODO(), currentSveltosClusters)).To(Succeed())
-		Expect(len(currentSveltosClusters.Items)).To(Equal(1))
-		Expect(currentSveltosClusters.Items[0].Namespace).To(Equal(secret.Namespace))
-		Expect(currentSveltosClusters.Items[0].Spec.KubeconfigName).To(Equal(secret.Name))
-		Expect(currentSveltosClusters.Items[0].Annotations).ToNot(BeNil())
-		Expect(currentSveltosClusters.Items[0].Annotations[controller.SveltosClusterClaudieAnnotation]).ToNot(BeEmpty())
-		Expect(currentSveltosClusters.Items[0].OwnerReferences).ToNot(BeNil())
-		Expect(len(currentSveltosClusters.Items[0].OwnerReferences)).To(Equal(1))
-		Expect(currentSveltosClusters.Items[0].OwnerReferences[0].Name).To(Equal(secret.Name))
+		Expect(len(currentSveltosClusters.Items)).To(Equal(0))
 	})
-})
 
-func getSecretReconciler(c client.Client) *controller.SecretReconciler {
-	return &controller.SecretReconciler{
-		Client:          c,
-		Mux:             sync.Mutex{},
-		SecretToCluster: map[types.NamespacedName]types.NamespacedName{},
-	}
+	It("createSveltosCluster waits for SveltosCluster being deleted and then recreates it", func() {
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+
+		now := metav1.Now()
+		sveltosCluster := &libsveltosv1alpha1.SveltosCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:         secret.Namespace,
+				Name:              secret.Labels[controller.ClaudieCluster],
+				DeletionTimestamp: &now,
+				Finalizers:        []string{randomString()},
+			},
+		}
+
+		updates := 0
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret, sveltosCluster).
+			WithInterceptorFuncs(interceptor.Funcs{
+				Update: func(ctx context.Context, wc client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+					if _, ok := obj.(*libsveltosv1alpha1.SveltosCluster); ok {
+						updates++
+					}
+					return wc.Update(ctx, obj, opts...)
+				},
+			}).Build()
+		reconciler := getSecretReconciler(c)
+
+		secretRef := reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name},
+		}
+
+		result, err := reconciler.Reconcile(context.TODO(), secretRef)
+		Expect(err).To(BeNil())
+		Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+		Expect(updates).To(Equal(0))
+
+		// Deletion completes
+		sveltosClusterKey := types.NamespacedName{Namespace: sveltosCluster.Namespace, Name: sveltosCluster.Name}
+		currentSveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+		Expect(c.Get(context.TODO(), sveltosClusterKey, currentSveltosCluster)).To(Succeed())
+		currentSveltosCluster.Finalizers = nil
+		Expect(c.Update(context.TODO(), currentSveltosCluster)).To(Succeed())
+		Expect(apierrors.IsNotFound(c.Get(context.TODO(), sveltosClusterKey, currentSveltosCluster))).To(BeTrue())
+
+		result, err = reconciler.Reconcile(context.TODO(), secretRef)
+		Expect(err).To(BeNil())
+		Expect(result.Requeue).To(BeFalse())
+
+		currentSveltosCluster = &libsveltosv1alpha1.SveltosCluster{}
+		Expect(c.Get(context.TODO(), sveltosClusterKey, currentSveltosCluster)).To(Succeed())
+		Expect(currentSveltosCluster.DeletionTimestamp.IsZero()).To(BeTrue())
+		Expect(currentSveltosCluster.Spec.KubeconfigName).To(Equal(secret.Name))
+	})
+
+	It("Reconcile removes SveltosCluster when Secret loses Claudie labels and recreates it when labels are back", func() {
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+		reconciler := getSecretReconciler(c)
+		reconciler.DeletionRetention = time.Hour
+		reconciler.AnnotateSecret = true
+
+		secretRef := reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name},
+		}
+		sveltosClusterKey := types.NamespacedName{Namespace: secret.Namespace, Name: secret.Labels[controller.ClaudieCluster]}
+
+		_, err := reconciler.Reconcile(context.TODO(), secretRef)
+		Expect(err).To(BeNil())
+		Expect(c.Get(context.TODO(), sveltosClusterKey, &libsveltosv1alpha1.SveltosCluster{})).To(Succeed())
+
+		// Labels are lost
+		currentSecret := &corev1.Secret{}
+		Expect(c.Get(context.TODO(), secretRef.NamespacedName, currentSecret)).To(Succeed())
+		Expect(currentSecret.Finalizers).To(ContainElement(controller.ClaudieCleanupFinalizer))
+		claudieLabels := currentSecret.Labels
+		currentSecret.Labels = nil
+		Expect(c.Update(context.TODO(), currentSecret)).To(Succeed())
+
+		_, err = reconciler.Reconcile(context.TODO(), secretRef)
+		Expect(err).To(BeNil())
+		err = c.Get(context.TODO(), sveltosClusterKey, &libsveltosv1alpha1.SveltosCluster{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		Expect(reconciler.SecretToCluster()).ToNot(HaveKey(secretRef.NamespacedName))
+		Expect(controller.GetDeletionRetention(reconciler, secretRef.NamespacedName)).To(BeZero())
+		Expect(c.Get(context.TODO(), secretRef.NamespacedName, currentSecret)).To(Succeed())
+		Expect(currentSecret.Annotations).ToNot(HaveKey(controller.SecretSveltosClusterAnnotation))
+		Expect(currentSecret.Finalizers).ToNot(ContainElement(controller.ClaudieCleanupFinalizer))
+
+		// Labels are back
+		currentSecret.Labels = claudieLabels
+		Expect(c.Update(context.TODO(), currentSecret)).To(Succeed())
+
+		result, err := reconciler.Reconcile(context.TODO(), secretRef)
+		Expect(err).To(BeNil())
+		Expect(result.Requeue).To(BeFalse())
+		Expect(c.Get(context.TODO(), sveltosClusterKey, &libsveltosv1alpha1.SveltosCluster{})).To(Succeed())
+		Expect(reconciler.SecretToCluster()).To(HaveKeyWithValue(secretRef.NamespacedName, sveltosClusterKey))
+		Expect(c.Get(context.TODO(), secretRef.NamespacedName, currentSecret)).To(Succeed())
+		Expect(currentSecret.Annotations).To(HaveKey(controller.SecretSveltosClusterAnnotation))
+		Expect(currentSecret.Finalizers).To(ContainElement(controller.ClaudieCleanupFinalizer))
+	})
+
+	It("Reconcile removes SveltosCluster when a tracked Secret gains the skip annotation", func() {
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+		reconciler := getSecretReconciler(c)
+
+		secretRef := reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name},
+		}
+		sveltosClusterKey := types.NamespacedName{Namespace: secret.Namespace, Name: secret.Labels[controller.ClaudieCluster]}
+
+		_, err := reconciler.Reconcile(context.TODO(), secretRef)
+		Expect(err).To(BeNil())
+		Expect(c.Get(context.TODO(), sveltosClusterKey, &libsveltosv1alpha1.SveltosCluster{})).To(Succeed())
+
+		currentSecret := &corev1.Secret{}
+		Expect(c.Get(context.TODO(), secretRef.NamespacedName, currentSecret)).To(Succeed())
+		if currentSecret.Annotations == nil {
+			currentSecret.Annotations = map[string]string{}
+		}
+		currentSecret.Annotations[controller.SkipAnnotation] = "true"
+		Expect(c.Update(context.TODO(), currentSecret)).To(Succeed())
+
+		_, err = reconciler.Reconcile(context.TODO(), secretRef)
+		Expect(err).To(BeNil())
+		err = c.Get(context.TODO(), sveltosClusterKey, &libsveltosv1alpha1.SveltosCluster{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		Expect(reconciler.SecretToCluster()).ToNot(HaveKey(secretRef.NamespacedName))
+		Expect(c.Get(context.TODO(), secretRef.NamespacedName, currentSecret)).To(Succeed())
+		Expect(currentSecret.Finalizers).ToNot(ContainElement(controller.ClaudieCleanupFinalizer))
+	})
+
+	It("Reconcile leaves SveltosCluster in place when Secret loses labels and RetainOnLabelRemoval is set", func() {
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+		reconciler := getSecretReconciler(c)
+		reconciler.RetainOnLabelRemoval = true
+
+		secretRef := reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name},
+		}
+		sveltosClusterKey := types.NamespacedName{Namespace: secret.Namespace, Name: secret.Labels[controller.ClaudieCluster]}
+
+		_, err := reconciler.Reconcile(context.TODO(), secretRef)
+		Expect(err).To(BeNil())
+
+		currentSecret := &corev1.Secret{}
+		Expect(c.Get(context.TODO(), secretRef.NamespacedName, currentSecret)).To(Succeed())
+		currentSecret.Labels = nil
+		Expect(c.Update(context.TODO(), currentSecret)).To(Succeed())
+
+		_, err = reconciler.Reconcile(context.TODO(), secretRef)
+		Expect(err).To(BeNil())
+		Expect(c.Get(context.TODO(), sveltosClusterKey, &libsveltosv1alpha1.SveltosCluster{})).To(Succeed())
+
+		// Secret is not managed anymore, so its deletion must not be blocked
+		Expect(c.Get(context.TODO(), secretRef.NamespacedName, currentSecret)).To(Succeed())
+		Expect(currentSecret.Finalizers).ToNot(ContainElement(controller.ClaudieCleanupFinalizer))
+	})
+
+	It("Reconcile deletes SveltosCluster before releasing a deleted Secret, even after a restart", func() {
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+		reconciler := getSecretReconciler(c)
+
+		secretRef := reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name},
+		}
+		sveltosClusterKey := types.NamespacedName{Namespace: secret.Namespace, Name: secret.Labels[controller.ClaudieCluster]}
+
+		_, err := reconciler.Reconcile(context.TODO(), secretRef)
+		Expect(err).To(BeNil())
+
+		currentSecret := &corev1.Secret{}
+		Expect(c.Get(context.TODO(), secretRef.NamespacedName, currentSecret)).To(Succeed())
+		Expect(currentSecret.Finalizers).To(ContainElement(controller.ClaudieCleanupFinalizer))
+
+		// Finalizer keeps Secret around till SveltosCluster is deleted
+		Expect(c.Delete(context.TODO(), currentSecret)).To(Succeed())
+		Expect(c.Get(context.TODO(), secretRef.NamespacedName, currentSecret)).To(Succeed())
+		Expect(currentSecret.DeletionTimestamp.IsZero()).To(BeFalse())
+
+		// Controller restarted, SecretToCluster map is empty
+		reconciler = getSecretReconciler(c)
+		_, err = reconciler.Reconcile(context.TODO(), secretRef)
+		Expect(err).To(BeNil())
+
+		err = c.Get(context.TODO(), sveltosClusterKey, &libsveltosv1alpha1.SveltosCluster{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		err = c.Get(context.TODO(), secretRef.NamespacedName, currentSecret)
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("Reconcile invokes ReconcileCallback with request and outcome", func() {
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+		reconciler := getSecretReconciler(c)
+
+		type outcome struct {
+			req    reconcile.Request
+			result reconcile.Result
+			err    error
+		}
+		outcomes := make(chan outcome, 1)
+		reconciler.ReconcileCallback = func(req reconcile.Request, result reconcile.Result, err error) {
+			outcomes <- outcome{req: req, result: result, err: err}
+		}
+
+		secretRef := reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name},
+		}
+		go func() {
+			defer GinkgoRecover()
+			_, _ = reconciler.Reconcile(context.TODO(), secretRef)
+		}()
+
+		var o outcome
+		Eventually(outcomes).Should(Receive(&o))
+		Expect(o.req).To(Equal(secretRef))
+		Expect(o.err).To(BeNil())
+		Expect(o.result.Requeue).To(BeFalse())
+
+		// Reconciliation is complete once callback is invoked
+		currentSveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+		Expect(c.Get(context.TODO(),
+			types.NamespacedName{Namespace: secret.Namespace, Name: secret.Labels[controller.ClaudieCluster]},
+			currentSveltosCluster)).To(Succeed())
+	})
+
+	It("getDeletionRetention returns zero once retention window has expired", func() {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: randomString(),
+				Name:      randomString(),
+			},
+		}
+
+		sveltosCluster := &libsveltosv1alpha1.SveltosCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: secret.Namespace,
+				Name:      randomString(),
+			},
+		}
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(sveltosCluster).Build()
+		reconciler := getSecretReconciler(c)
+		reconciler.DeletionRetention = time.Millisecond
+
+		secretRef := reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name},
+		}
+		reconciler.TrackSveltosCluster(secretRef.NamespacedName, types.NamespacedName{
+			Namespace: sveltosCluster.Namespace, Name: sveltosCluster.Name,
+		})
+
+		Expect(controller.CleanSveltosCluster(reconciler, context.TODO(), secretRef, logr.Logger{})).To(Succeed())
+		Eventually(func() time.Duration {
+			return controller.GetDeletionRetention(reconciler, secretRef.NamespacedName)
+		}, time.Second, 10*time.Millisecond).Should(BeZero())
+	})
+
+	It("addOwnerReference add secret as SveltosCluster's OwnerReference", func() {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: randomString(),
+				Name:      randomString(),
+			},
+		}
+		Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+
+		sveltosCluster := &libsveltosv1alpha1.SveltosCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: secret.Namespace,
+				Name:      randomString(),
+			},
+		}
+
+		initObjects := []client.Object{
+			secret, sveltosCluster,
+		}
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(initObjects...).Build()
+		reconciler := getSecretReconciler(c)
+
+		controller.AddOwnerReference(reconciler, sveltosCluster, secret)
+
+		Expect(sveltosCluster.OwnerReferences).ToNot(BeNil())
+		Expect(len(sveltosCluster.OwnerReferences)).To(Equal(1))
+		Expect(sveltosCluster.OwnerReferences[0].Kind).To(Equal("Secret"))
+		Expect(sveltosCluster.OwnerReferences[0].Name).To(Equal(secret.Name))
+	})
+
+	It("addOwnerReference sets Secret as controller owner when ControllerOwnerReference is set", func() {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: randomString(),
+				Name:      randomString(),
+			},
+		}
+		Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+
+		isController := true
+		sveltosCluster := &libsveltosv1alpha1.SveltosCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: secret.Namespace,
+				Name:      randomString(),
+				OwnerReferences: []metav1.OwnerReference{
+					{APIVersion: "v1", Kind: "Secret", Name: randomString(), Controller: &isController},
+				},
+			},
+		}
+
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := getSecretReconciler(c)
+		reconciler.ControllerOwnerReference = true
+
+		controller.AddOwnerReference(reconciler, sveltosCluster, secret)
+
+		Expect(len(sveltosCluster.OwnerReferences)).To(Equal(2))
+		// Only one OwnerReference can be the controller one
+		Expect(sveltosCluster.OwnerReferences[0].Controller).To(BeNil())
+		Expect(sveltosCluster.OwnerReferences[1].Name).To(Equal(secret.Name))
+		Expect(sveltosCluster.OwnerReferences[1].Controller).ToNot(BeNil())
+		Expect(*sveltosCluster.OwnerReferences[1].Controller).To(BeTrue())
+		Expect(sveltosCluster.OwnerReferences[1].BlockOwnerDeletion).ToNot(BeNil())
+		Expect(*sveltosCluster.OwnerReferences[1].BlockOwnerDeletion).To(BeTrue())
+
+		// Disabling the option clears the fields on the existing OwnerReference
+		reconciler.ControllerOwnerReference = false
+		controller.AddOwnerReference(reconciler, sveltosCluster, secret)
+		Expect(len(sveltosCluster.OwnerReferences)).To(Equal(2))
+		Expect(sveltosCluster.OwnerReferences[1].Controller).To(BeNil())
+		Expect(sveltosCluster.OwnerReferences[1].BlockOwnerDeletion).To(BeNil())
+	})
+
+	It("addOwnerReference tracks secret via annotation only when SkipOwnerReferences is set", func() {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: randomString(),
+				Name:      randomString(),
+			},
+		}
+		Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+
+		sveltosCluster := &libsveltosv1alpha1.SveltosCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: secret.Namespace,
+				Name:      randomString(),
+			},
+		}
+
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := getSecretReconciler(c)
+		reconciler.SkipOwnerReferences = true
+
+		controller.AddOwnerReference(reconciler, sveltosCluster, secret)
+
+		Expect(sveltosCluster.OwnerReferences).To(BeEmpty())
+		Expect(sveltosCluster.Annotations[controller.SveltosClusterSecretAnnotation]).To(Equal(secret.Name))
+
+		claudieSecret := controller.GetClaudieSecret(sveltosCluster)
+		Expect(claudieSecret).ToNot(BeNil())
+		Expect(*claudieSecret).To(Equal(types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name}))
+	})
+
+	It("createSveltosCluster does not add OwnerReferences when SkipOwnerReferences is set", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := getSecretReconciler(c)
+		reconciler.SkipOwnerReferences = true
+
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+		// Update path
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+
+		currentSveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+		Expect(c.Get(context.TODO(),
+			types.NamespacedName{Namespace: secret.Namespace, Name: secret.Labels[controller.ClaudieCluster]},
+			currentSveltosCluster)).To(Succeed())
+		Expect(currentSveltosCluster.OwnerReferences).To(BeEmpty())
+		Expect(currentSveltosCluster.Annotations[controller.SveltosClusterSecretAnnotation]).To(Equal(secret.Name))
+		Expect(currentSveltosCluster.Annotations[controller.SveltosClusterClaudieAnnotation]).ToNot(BeEmpty())
+	})
+
+	It("addAnnotation adds claudie annotation to SveltosCluster", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := getSecretReconciler(c)
+
+		sveltosCluster := &libsveltosv1alpha1.SveltosCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: randomString(),
+				Name:      randomString(),
+			},
+		}
+
+		controller.AddAnnotation(reconciler, sveltosCluster, nil)
+		Expect(sveltosCluster.Annotations).ToNot(BeNil())
+		Expect(sveltosCluster.Annotations[controller.SveltosClusterClaudieAnnotation]).ToNot(BeEmpty())
+
+		// Secret UID, when known, is recorded
+		secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{UID: types.UID(randomString())}}
+		controller.AddAnnotation(reconciler, sveltosCluster, secret)
+		Expect(sveltosCluster.Annotations[controller.SveltosClusterClaudieAnnotation]).
+			To(Equal("v2:" + string(secret.UID)))
+	})
+
+	It("addAnnotation merges ExtraAnnotations leaving other annotations untouched", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := getSecretReconciler(c)
+		reconciler.ExtraAnnotations = map[string]string{
+			"cost-center": "1234",
+			"team":        "platform",
+			controller.SveltosClusterClaudieAnnotation: "overridden",
+		}
+
+		sveltosCluster := &libsveltosv1alpha1.SveltosCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: randomString(),
+				Name:      randomString(),
+				Annotations: map[string]string{
+					"team":  "someone-else",
+					"owner": "user",
+				},
+			},
+		}
+
+		controller.AddAnnotation(reconciler, sveltosCluster, &corev1.Secret{})
+		Expect(sveltosCluster.Annotations).To(HaveKeyWithValue("cost-center", "1234"))
+		Expect(sveltosCluster.Annotations).To(HaveKeyWithValue("team", "platform"))
+		Expect(sveltosCluster.Annotations).To(HaveKeyWithValue("owner", "user"))
+		Expect(sveltosCluster.Annotations).To(HaveKeyWithValue(controller.SveltosClusterClaudieAnnotation, "ok"))
+	})
+
+	It("addTopologyAnnotations adds region and zone annotations when present on Secret", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := getSecretReconciler(c)
+
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: randomString(),
+				Name:      randomString(),
+				Labels: map[string]string{
+					controller.RegionLabel: "eu-west-1",
+					controller.ZoneLabel:   "eu-west-1a",
+				},
+			},
+		}
+
+		sveltosCluster := &libsveltosv1alpha1.SveltosCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: secret.Namespace,
+				Name:      randomString(),
+			},
+		}
+
+		controller.AddTopologyAnnotations(reconciler, sveltosCluster, secret)
+		Expect(sveltosCluster.Annotations).To(HaveKeyWithValue(controller.SveltosClusterRegionAnnotation, "eu-west-1"))
+		Expect(sveltosCluster.Annotations).To(HaveKeyWithValue(controller.SveltosClusterZoneAnnotation, "eu-west-1a"))
+
+		// Zone label removed from Secret
+		delete(secret.Labels, controller.ZoneLabel)
+		controller.AddTopologyAnnotations(reconciler, sveltosCluster, secret)
+		Expect(sveltosCluster.Annotations).To(HaveKeyWithValue(controller.SveltosClusterRegionAnnotation, "eu-west-1"))
+		Expect(sveltosCluster.Annotations).ToNot(HaveKey(controller.SveltosClusterZoneAnnotation))
+	})
+
+	It("addTopologyAnnotations adds no annotation when Secret has no region and zone", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := getSecretReconciler(c)
+
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: randomString(),
+				Name:      randomString(),
+			},
+		}
+
+		sveltosCluster := &libsveltosv1alpha1.SveltosCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: secret.Namespace,
+				Name:      randomString(),
+			},
+		}
+
+		controller.AddTopologyAnnotations(reconciler, sveltosCluster, secret)
+		Expect(sveltosCluster.Annotations).ToNot(HaveKey(controller.SveltosClusterRegionAnnotation))
+		Expect(sveltosCluster.Annotations).ToNot(HaveKey(controller.SveltosClusterZoneAnnotation))
+	})
+
+	It("createSveltosCluster creates a SveltosCluster for a Claudie secret", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := getSecretReconciler(c)
+
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: randomString(),
+				Name:      randomString(),
+				Labels: map[string]string{
+					controller.ClaudieLabel:      "claudie",
+					controller.ClaudieKubeconfig: "kubeconfig",
+					controller.ClaudieCluster:    randomString(),
+				},
+			},
+		}
+
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+
+		currentSveltosClusters := &libsveltosv1alpha1.SveltosClusterList{}
+		Expect(c.List(context.TODO(), currentSveltosClusters)).To(Succeed())
+		Expect(len(currentSveltosClusters.Items)).To(Equal(1))
+		Expect(currentSveltosClusters.Items[0].Namespace).To(Equal(secret.Namespace))
+		Expect(currentSveltosClusters.Items[0].Spec.KubeconfigName).To(Equal(secret.Name))
+		Expect(currentSveltosClusters.Items[0].Annotations).ToNot(BeNil())
+		Expect(currentSveltosClusters.Items[0].Annotations[controller.SveltosClusterClaudieAnnotation]).ToNot(BeEmpty())
+		Expect(currentSveltosClusters.Items[0].OwnerReferences).ToNot(BeNil())
+		Expect(len(currentSveltosClusters.Items[0].OwnerReferences)).To(Equal(1))
+		Expect(currentSveltosClusters.Items[0].OwnerReferences[0].Name).To(Equal(secret.Name))
+	})
+
+	It("createSveltosCluster does not take over SveltosCluster owned by a different Secret with Refuse policy", func() {
+		secret, sveltosCluster := getSecretAndSveltosClusterOwnedByOtherSecret()
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(sveltosCluster).Build()
+		reconciler := getSecretReconciler(c)
+		reconciler.ConflictPolicy = controller.ConflictPolicyRefuse
+		recorder := record.NewFakeRecorder(10)
+		reconciler.EventRecorder = recorder
+
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+
+		currentSveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+		Expect(c.Get(context.TODO(),
+			types.NamespacedName{Namespace: sveltosCluster.Namespace, Name: sveltosCluster.Name},
+			currentSveltosCluster)).To(Succeed())
+		Expect(len(currentSveltosCluster.OwnerReferences)).To(Equal(1))
+		Expect(currentSveltosCluster.OwnerReferences[0].Name).To(Equal(sveltosCluster.OwnerReferences[0].Name))
+
+		Expect(recorder.Events).To(Receive(ContainSubstring(controller.ReasonSveltosClusterConflict)))
+
+		_, ok := reconciler.SecretToCluster()[types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name}]
+		Expect(ok).To(BeFalse())
+	})
+
+	It("Reconcile does not let two Secrets with the same cluster label fight over one SveltosCluster", func() {
+		// Same cluster name in two Claudie projects. Second Secret is created later, as an API server would report.
+		creation := time.Now().Add(-time.Hour)
+		first := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		first.Labels[controller.ClaudieProject] = "project-a"
+		first.CreationTimestamp = metav1.NewTime(creation)
+		Expect(addTypeInformationToObject(scheme, first)).To(Succeed())
+		second := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		second.Namespace = first.Namespace
+		second.Labels[controller.ClaudieCluster] = first.Labels[controller.ClaudieCluster]
+		second.Labels[controller.ClaudieProject] = "project-b"
+		second.CreationTimestamp = metav1.NewTime(creation.Add(time.Minute))
+		Expect(addTypeInformationToObject(scheme, second)).To(Succeed())
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(first, second).Build()
+		reconciler := getSecretReconciler(c)
+		recorder := record.NewFakeRecorder(10)
+		reconciler.EventRecorder = recorder
+
+		firstRef := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: first.Namespace, Name: first.Name}}
+		secondRef := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: second.Namespace, Name: second.Name}}
+		sveltosClusterKey := types.NamespacedName{Namespace: first.Namespace, Name: first.Labels[controller.ClaudieCluster]}
+
+		_, err := reconciler.Reconcile(context.TODO(), firstRef)
+		Expect(err).To(BeNil())
+		Expect(recorder.Events).To(Receive(ContainSubstring(controller.ReasonSveltosClusterCreated)))
+
+		_, err = reconciler.Reconcile(context.TODO(), secondRef)
+		Expect(err).To(BeNil())
+		Expect(recorder.Events).To(Receive(ContainSubstring(controller.ReasonSveltosClusterConflict)))
+
+		currentSveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+		Expect(c.Get(context.TODO(), sveltosClusterKey, currentSveltosCluster)).To(Succeed())
+		Expect(controller.GetClaudieSecret(currentSveltosCluster)).To(Equal(&firstRef.NamespacedName))
+		Expect(currentSveltosCluster.Spec.KubeconfigName).To(Equal(first.Name))
+		Expect(reconciler.SecretToCluster()).To(HaveKey(firstRef.NamespacedName))
+		Expect(reconciler.SecretToCluster()).ToNot(HaveKey(secondRef.NamespacedName))
+
+		// Only the owner gets the cleanup finalizer: the refused Secret owns nothing
+		currentSecret := &corev1.Secret{}
+		Expect(c.Get(context.TODO(), firstRef.NamespacedName, currentSecret)).To(Succeed())
+		Expect(currentSecret.Finalizers).To(ContainElement(controller.ClaudieCleanupFinalizer))
+		Expect(c.Get(context.TODO(), secondRef.NamespacedName, currentSecret)).To(Succeed())
+		Expect(currentSecret.Finalizers).ToNot(ContainElement(controller.ClaudieCleanupFinalizer))
+
+		// Removing the Secret which lost the conflict leaves SveltosCluster in place
+		Expect(c.Delete(context.TODO(), currentSecret)).To(Succeed())
+		_, err = reconciler.Reconcile(context.TODO(), secondRef)
+		Expect(err).To(BeNil())
+		Expect(c.Get(context.TODO(), sveltosClusterKey, currentSveltosCluster)).To(Succeed())
+		Expect(controller.GetClaudieSecret(currentSveltosCluster)).To(Equal(&firstRef.NamespacedName))
+	})
+
+	DescribeTable("createSveltosCluster applies AdoptionPolicy to SveltosCluster not managed by Claudie",
+		func(policy controller.AdoptionPolicy, expectedReason string, adopted, succeeds bool) {
+			secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+			Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+
+			// Pure name match: no Claudie annotation and no owner
+			sveltosCluster := &libsveltosv1alpha1.SveltosCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: secret.Namespace,
+					Name:      secret.Labels[controller.ClaudieCluster],
+				},
+			}
+
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret, sveltosCluster).Build()
+			reconciler := getSecretReconciler(c)
+			reconciler.AdoptionPolicy = policy
+			recorder := record.NewFakeRecorder(10)
+			reconciler.EventRecorder = recorder
+
+			err := controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})
+			if succeeds {
+				Expect(err).To(BeNil())
+			} else {
+				Expect(err).ToNot(BeNil())
+			}
+			Expect(recorder.Events).To(Receive(ContainSubstring(expectedReason)))
+
+			currentSveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+			Expect(c.Get(context.TODO(),
+				types.NamespacedName{Namespace: sveltosCluster.Namespace, Name: sveltosCluster.Name},
+				currentSveltosCluster)).To(Succeed())
+
+			_, tracked := reconciler.SecretToCluster()[types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name}]
+			Expect(tracked).To(Equal(adopted))
+			if adopted {
+				Expect(currentSveltosCluster.Annotations).To(HaveKey(controller.SveltosClusterClaudieAnnotation))
+				Expect(currentSveltosCluster.Spec.KubeconfigName).To(Equal(secret.Name))
+				return
+			}
+
+			Expect(currentSveltosCluster.Annotations).ToNot(HaveKey(controller.SveltosClusterClaudieAnnotation))
+			Expect(currentSveltosCluster.OwnerReferences).To(BeEmpty())
+			Expect(currentSveltosCluster.Spec.KubeconfigName).To(BeEmpty())
+		},
+		Entry("default policy adopts", controller.AdoptionPolicy(""), controller.ReasonSveltosClusterAdopted, true, true),
+		Entry("Adopt", controller.AdoptionPolicyAdopt, controller.ReasonSveltosClusterAdopted, true, true),
+		Entry("Refuse", controller.AdoptionPolicyRefuse, controller.ReasonSveltosClusterAdoptionRefused, false, false),
+		Entry("Skip", controller.AdoptionPolicySkip, controller.ReasonSveltosClusterAdoptionSkipped, false, true),
+	)
+
+	It("createSveltosCluster takes over SveltosCluster owned by a different Secret with TakeOver policy", func() {
+		secret, sveltosCluster := getSecretAndSveltosClusterOwnedByOtherSecret()
+		otherSecret := types.NamespacedName{Namespace: sveltosCluster.Namespace, Name: sveltosCluster.OwnerReferences[0].Name}
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(sveltosCluster).Build()
+		reconciler := getSecretReconciler(c)
+		reconciler.ConflictPolicy = controller.ConflictPolicyTakeOver
+		reconciler.TrackSveltosCluster(otherSecret, types.NamespacedName{Namespace: sveltosCluster.Namespace, Name: sveltosCluster.Name})
+
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+
+		currentSveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+		Expect(c.Get(context.TODO(),
+			types.NamespacedName{Namespace: sveltosCluster.Namespace, Name: sveltosCluster.Name},
+			currentSveltosCluster)).To(Succeed())
+		Expect(len(currentSveltosCluster.OwnerReferences)).To(Equal(1))
+		Expect(currentSveltosCluster.OwnerReferences[0].Name).To(Equal(secret.Name))
+
+		_, ok := reconciler.SecretToCluster()[otherSecret]
+		Expect(ok).To(BeFalse())
+		_, ok = reconciler.SecretToCluster()[types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name}]
+		Expect(ok).To(BeTrue())
+	})
+
+	It("createSveltosCluster adds auto-target label on create and preserves user override on update", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := getSecretReconciler(c)
+		reconciler.AutoTargetLabelKey = "projectsveltos.io/claudie-cluster"
+		reconciler.AutoTargetLabelValue = "true"
+
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: randomString(),
+				Name:      randomString(),
+				Labels: map[string]string{
+					controller.ClaudieLabel:      "claudie",
+					controller.ClaudieKubeconfig: "kubeconfig",
+					controller.ClaudieCluster:    randomString(),
+				},
+			},
+		}
+
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+
+		sveltosClusterKey := types.NamespacedName{Namespace: secret.Namespace, Name: secret.Labels[controller.ClaudieCluster]}
+		currentSveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+		Expect(c.Get(context.TODO(), sveltosClusterKey, currentSveltosCluster)).To(Succeed())
+		Expect(currentSveltosCluster.Labels).To(HaveKeyWithValue(reconciler.AutoTargetLabelKey, "true"))
+
+		// User overrides label value
+		currentSveltosCluster.Labels[reconciler.AutoTargetLabelKey] = "false"
+		Expect(c.Update(context.TODO(), currentSveltosCluster)).To(Succeed())
+
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+		Expect(c.Get(context.TODO(), sveltosClusterKey, currentSveltosCluster)).To(Succeed())
+		Expect(currentSveltosCluster.Labels).To(HaveKeyWithValue(reconciler.AutoTargetLabelKey, "false"))
+	})
+
+	It("createSveltosCluster adds no label when auto-target label is not configured", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := getSecretReconciler(c)
+
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: randomString(),
+				Name:      randomString(),
+				Labels: map[string]string{
+					controller.ClaudieLabel:      "claudie",
+					controller.ClaudieKubeconfig: "kubeconfig",
+					controller.ClaudieCluster:    randomString(),
+				},
+			},
+		}
+
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+
+		currentSveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+		Expect(c.Get(context.TODO(),
+			types.NamespacedName{Namespace: secret.Namespace, Name: secret.Labels[controller.ClaudieCluster]},
+			currentSveltosCluster)).To(Succeed())
+		Expect(currentSveltosCluster.Labels).To(BeEmpty())
+	})
+
+	It("createSveltosCluster copies allow-listed Secret labels and leaves the others untouched", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := getSecretReconciler(c)
+		reconciler.PropagatedLabels = []string{controller.RegionLabel, "environment"}
+
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		secret.Labels[controller.RegionLabel] = "eu-west"
+		secret.Labels["environment"] = "production"
+		secret.Labels["team"] = randomString()
+
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+
+		sveltosClusterKey := types.NamespacedName{Namespace: secret.Namespace, Name: secret.Labels[controller.ClaudieCluster]}
+		currentSveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+		Expect(c.Get(context.TODO(), sveltosClusterKey, currentSveltosCluster)).To(Succeed())
+		Expect(currentSveltosCluster.Labels).To(Equal(map[string]string{
+			controller.RegionLabel: "eu-west",
+			"environment":          "production",
+		}))
+
+		// User added labels are preserved, label removed from Secret is not removed from SveltosCluster
+		currentSveltosCluster.Labels["user"] = "label"
+		Expect(c.Update(context.TODO(), currentSveltosCluster)).To(Succeed())
+		secret.Labels[controller.RegionLabel] = "us-east"
+		delete(secret.Labels, "environment")
+
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+		Expect(c.Get(context.TODO(), sveltosClusterKey, currentSveltosCluster)).To(Succeed())
+		Expect(currentSveltosCluster.Labels).To(Equal(map[string]string{
+			controller.RegionLabel: "us-east",
+			"environment":          "production",
+			"user":                 "label",
+		}))
+	})
+
+	It("removeStaleSveltosClusters defers deletions till SecretToCluster map is ready", func() {
+		sveltosCluster := &libsveltosv1alpha1.SveltosCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: randomString(),
+				Name:      randomString(),
+				Annotations: map[string]string{
+					controller.SveltosClusterClaudieAnnotation: "ok",
+				},
+				OwnerReferences: []metav1.OwnerReference{
+					{
+						Kind:       "Secret",
+						APIVersion: "v1",
+						Name:       randomString(),
+					},
+				},
+			},
+		}
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(sveltosCluster).Build()
+		sveltosClusterKey := types.NamespacedName{Namespace: sveltosCluster.Namespace, Name: sveltosCluster.Name}
+
+		mapReady := make(chan struct{})
+		controller.RemoveStaleSveltosClusters(context.TODO(), c, c, controller.DefaultClaudieMetadata, 0, mapReady, logr.Logger{})
+
+		currentSveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+		Expect(c.Get(context.TODO(), sveltosClusterKey, currentSveltosCluster)).To(Succeed())
+
+		close(mapReady)
+		controller.RemoveStaleSveltosClusters(context.TODO(), c, c, controller.DefaultClaudieMetadata, 0, mapReady, logr.Logger{})
+
+		err := c.Get(context.TODO(), sveltosClusterKey, currentSveltosCluster)
+		Expect(err).ToNot(BeNil())
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("removeStaleSveltosClusters pages through the API reader and sweeps every cached SveltosCluster", func() {
+		namespace := randomString()
+		initObjects := make([]client.Object, 0)
+		for i := 0; i < controller.StaleSweepPageSize+1; i++ {
+			initObjects = append(initObjects, &libsveltosv1alpha1.SveltosCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: namespace,
+					Name:      randomString(),
+					Annotations: map[string]string{
+						controller.SveltosClusterClaudieAnnotation: "ok",
+					},
+					OwnerReferences: []metav1.OwnerReference{
+						{Kind: "Secret", APIVersion: "v1", Name: randomString()},
+					},
+				},
+			})
+		}
+
+		base := fake.NewClientBuilder().WithScheme(scheme).WithObjects(initObjects...).Build()
+
+		// Fake client ignores Limit and Continue. API server pages are emulated, ordering SveltosClusters
+		// by name and using the last returned name as continue token.
+		pages := 0
+		apiReader := interceptor.NewClient(base, interceptor.Funcs{
+			List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+				sveltosClusters, ok := list.(*libsveltosv1alpha1.SveltosClusterList)
+				if !ok {
+					return c.List(ctx, list, opts...)
+				}
+
+				listOptions := &client.ListOptions{}
+				listOptions.ApplyOptions(opts)
+				if err := c.List(ctx, sveltosClusters); err != nil {
+					return err
+				}
+				if listOptions.Limit == 0 {
+					return nil
+				}
+				pages++
+
+				sort.Slice(sveltosClusters.Items, func(i, j int) bool {
+					return sveltosClusters.Items[i].Name < sveltosClusters.Items[j].Name
+				})
+				items := make([]libsveltosv1alpha1.SveltosCluster, 0)
+				for i := range sveltosClusters.Items {
+					if sveltosClusters.Items[i].Name > listOptions.Continue {
+						items = append(items, sveltosClusters.Items[i])
+					}
+				}
+
+				sveltosClusters.Continue = ""
+				if len(items) > int(listOptions.Limit) {
+					items = items[:listOptions.Limit]
+					sveltosClusters.Continue = items[len(items)-1].Name
+				}
+				sveltosClusters.Items = items
+				return nil
+			},
+		})
+
+		// Manager client serves List from the cache, which rejects Continue and, with Limit set,
+		// returns the first page only with no continue token
+		cachedClient := interceptor.NewClient(base, interceptor.Funcs{
+			List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+				listOptions := &client.ListOptions{}
+				listOptions.ApplyOptions(opts)
+				if listOptions.Continue != "" {
+					return fmt.Errorf("continue list option is not supported by the cache")
+				}
+				if err := c.List(ctx, list); err != nil {
+					return err
+				}
+				sveltosClusters, ok := list.(*libsveltosv1alpha1.SveltosClusterList)
+				if ok && listOptions.Limit > 0 && len(sveltosClusters.Items) > int(listOptions.Limit) {
+					sveltosClusters.Items = sveltosClusters.Items[:listOptions.Limit]
+				}
+				return nil
+			},
+		})
+
+		mapReady := make(chan struct{})
+		close(mapReady)
+		controller.RemoveStaleSveltosClusters(context.TODO(), cachedClient, apiReader, controller.DefaultClaudieMetadata,
+			0, mapReady, logr.Logger{})
+
+		Expect(pages).To(Equal(2))
+		currentSveltosClusters := &libsveltosv1alpha1.SveltosClusterList{}
+		Expect(cachedClient.List(context.TODO(), currentSveltosClusters)).To(Succeed())
+		Expect(currentSveltosClusters.Items).To(BeEmpty())
+	})
+
+	It("removeStaleSveltosClusters removes SveltosClusters whose Secret was recreated", func() {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: randomString(),
+				Name:      randomString(),
+				UID:       types.UID(randomString()),
+			},
+		}
+
+		getSveltosCluster := func(marker string) *libsveltosv1alpha1.SveltosCluster {
+			return &libsveltosv1alpha1.SveltosCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: secret.Namespace,
+					Name:      randomString(),
+					Annotations: map[string]string{
+						controller.SveltosClusterClaudieAnnotation: marker,
+					},
+					OwnerReferences: []metav1.OwnerReference{
+						{Kind: "Secret", APIVersion: "v1", Name: secret.Name},
+					},
+				},
+			}
+		}
+
+		current := getSveltosCluster("v2:" + string(secret.UID))
+		legacy := getSveltosCluster("ok")
+		recreated := getSveltosCluster("v2:" + randomString())
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret, current, legacy, recreated).Build()
+
+		mapReady := make(chan struct{})
+		close(mapReady)
+		controller.RemoveStaleSveltosClusters(context.TODO(), c, c, controller.DefaultClaudieMetadata, 0, mapReady, logr.Logger{})
+
+		Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(current), current)).To(Succeed())
+		Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(legacy), legacy)).To(Succeed())
+		err := c.Get(context.TODO(), client.ObjectKeyFromObject(recreated), recreated)
+		Expect(err).ToNot(BeNil())
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("removeStaleSveltosClusters uses the passed context and stops once it is canceled", func() {
+		sveltosCluster := &libsveltosv1alpha1.SveltosCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: randomString(),
+				Name:      randomString(),
+				Annotations: map[string]string{
+					controller.SveltosClusterClaudieAnnotation: "ok",
+				},
+				OwnerReferences: []metav1.OwnerReference{
+					{Kind: "Secret", APIVersion: "v1", Name: randomString()},
+				},
+			},
+		}
+
+		ctx, cancel := context.WithCancel(context.TODO())
+		defer cancel()
+
+		// Requests issued after List
+		requests := 0
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(sveltosCluster).WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, wc client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				requests++
+				return wc.Get(ctx, key, obj, opts...)
+			},
+			List: func(ctx context.Context, wc client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+				// As a real client would, fail requests once context is canceled
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				err := wc.List(ctx, list, opts...)
+				// Manager shuts down while sweep is in progress
+				cancel()
+				return err
+			},
+			Delete: func(ctx context.Context, wc client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+				requests++
+				return wc.Delete(ctx, obj, opts...)
+			},
+		}).Build()
+
+		mapReady := make(chan struct{})
+		close(mapReady)
+		controller.RemoveStaleSveltosClusters(ctx, c, c, controller.DefaultClaudieMetadata, 0, mapReady, logr.Logger{})
+		Expect(requests).To(BeZero())
+
+		// Context already canceled: nothing is listed
+		controller.RemoveStaleSveltosClusters(ctx, c, c, controller.DefaultClaudieMetadata, 0, mapReady, logr.Logger{})
+		Expect(requests).To(BeZero())
+		Expect(c.Get(context.TODO(), client.ObjectKeyFromObject(sveltosCluster), sveltosCluster)).To(Succeed())
+	})
+
+	It("cleanStaleSveltosCluster sweeps at the configured interval and stops when context is done", func() {
+		sveltosCluster := &libsveltosv1alpha1.SveltosCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: randomString(),
+				Name:      randomString(),
+				Annotations: map[string]string{
+					controller.SveltosClusterClaudieAnnotation: "ok",
+				},
+				OwnerReferences: []metav1.OwnerReference{
+					{
+						Kind:       "Secret",
+						APIVersion: "v1",
+						Name:       randomString(),
+					},
+				},
+			},
+		}
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(sveltosCluster).Build()
+		sveltosClusterKey := types.NamespacedName{Namespace: sveltosCluster.Namespace, Name: sveltosCluster.Name}
+
+		mapReady := make(chan struct{})
+		close(mapReady)
+
+		ctx, cancel := context.WithCancel(context.TODO())
+		stopped := make(chan struct{})
+		go func() {
+			controller.CleanStaleSveltosCluster(ctx, c, c, controller.DefaultClaudieMetadata, 10*time.Millisecond, 0, mapReady, logr.Logger{})
+			close(stopped)
+		}()
+
+		Eventually(func() bool {
+			err := c.Get(context.TODO(), sveltosClusterKey, &libsveltosv1alpha1.SveltosCluster{})
+			return apierrors.IsNotFound(err)
+		}, time.Second, 10*time.Millisecond).Should(BeTrue())
+
+		cancel()
+		Eventually(stopped, time.Second).Should(BeClosed())
+	})
+
+	It("cleanSveltosCluster does not forget a SveltosCluster tracked while deletion was in progress", func() {
+		reconciler := getSecretReconciler(fake.NewClientBuilder().WithScheme(scheme).Build())
+		reconciler.DeletionRetention = time.Minute
+
+		secretKey := types.NamespacedName{Namespace: randomString(), Name: randomString()}
+		oldSveltosCluster := types.NamespacedName{Namespace: secretKey.Namespace, Name: randomString()}
+		newSveltosCluster := types.NamespacedName{Namespace: secretKey.Namespace, Name: randomString()}
+		reconciler.TrackSveltosCluster(secretKey, newSveltosCluster)
+
+		controller.ForgetSveltosCluster(reconciler, secretKey, oldSveltosCluster, true)
+		Expect(reconciler.SecretToCluster()).To(HaveKeyWithValue(secretKey, newSveltosCluster))
+		Expect(controller.GetDeletionRetention(reconciler, secretKey)).To(BeZero())
+
+		controller.ForgetSveltosCluster(reconciler, secretKey, newSveltosCluster, true)
+		Expect(reconciler.SecretToCluster()).ToNot(HaveKey(secretKey))
+		Expect(controller.GetDeletionRetention(reconciler, secretKey)).ToNot(BeZero())
+	})
+
+	It("Reconcile keeps SecretToCluster consistent when many Secrets are processed concurrently", func() {
+		const concurrency = 20
+		secrets := make([]*corev1.Secret, concurrency)
+		objects := make([]client.Object, concurrency)
+		for i := range secrets {
+			secrets[i] = getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+			Expect(addTypeInformationToObject(scheme, secrets[i])).To(Succeed())
+			objects[i] = secrets[i]
+		}
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+		reconciler := getSecretReconciler(c)
+
+		var wg sync.WaitGroup
+		for i := range secrets {
+			wg.Add(1)
+			go func(secret *corev1.Secret) {
+				defer GinkgoRecover()
+				defer wg.Done()
+				_, err := reconciler.Reconcile(context.TODO(),
+					reconcile.Request{NamespacedName: client.ObjectKeyFromObject(secret)})
+				Expect(err).To(BeNil())
+				Expect(controller.IsSecretTracked(reconciler, secret)).To(BeTrue())
+			}(secrets[i])
+		}
+		wg.Wait()
+		Expect(reconciler.SecretToCluster()).To(HaveLen(concurrency))
+
+		for i := range secrets {
+			Expect(c.Delete(context.TODO(), secrets[i])).To(Succeed())
+		}
+		for i := range secrets {
+			wg.Add(1)
+			go func(secret *corev1.Secret) {
+				defer GinkgoRecover()
+				defer wg.Done()
+				_, err := reconciler.Reconcile(context.TODO(),
+					reconcile.Request{NamespacedName: client.ObjectKeyFromObject(secret)})
+				Expect(err).To(BeNil())
+			}(secrets[i])
+		}
+		wg.Wait()
+		Expect(reconciler.SecretToCluster()).To(BeEmpty())
+	})
+
+	DescribeTable("createSveltosCluster does not write a SveltosCluster already in the desired state",
+		func(serverSideApply bool) {
+			secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+			Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+			sveltosClusterKey := types.NamespacedName{Namespace: secret.Namespace, Name: secret.Labels[controller.ClaudieCluster]}
+
+			writes := 0
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).WithInterceptorFuncs(interceptor.Funcs{
+				Update: func(ctx context.Context, wc client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+					if _, ok := obj.(*libsveltosv1alpha1.SveltosCluster); ok {
+						writes++
+					}
+					return wc.Update(ctx, obj, opts...)
+				},
+				Patch: func(ctx context.Context, wc client.WithWatch, obj client.Object, patch client.Patch,
+					opts ...client.PatchOption) error {
+
+					writes++
+					return nil
+				},
+			}).Build()
+
+			reconciler := getSecretReconciler(c)
+			reconciler.ServerSideApply = serverSideApply
+			Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+
+			sveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+			Expect(c.Get(context.TODO(), sveltosClusterKey, sveltosCluster)).To(Succeed())
+			resourceVersion := sveltosCluster.ResourceVersion
+
+			writes = 0
+			Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+			Expect(writes).To(BeZero())
+
+			Expect(c.Get(context.TODO(), sveltosClusterKey, sveltosCluster)).To(Succeed())
+			Expect(sveltosCluster.ResourceVersion).To(Equal(resourceVersion))
+		},
+		Entry("with Update", false),
+		Entry("with Server-Side Apply", true),
+	)
+
+	It("createSveltosCluster re-applies its changes when SveltosCluster update conflicts", func() {
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+		sveltosClusterKey := types.NamespacedName{Namespace: secret.Namespace, Name: secret.Labels[controller.ClaudieCluster]}
+
+		userLabel := randomString()
+		updates := 0
+		concurrentUpdate := false
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).WithInterceptorFuncs(interceptor.Funcs{
+			Update: func(ctx context.Context, wc client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+				if _, ok := obj.(*libsveltosv1alpha1.SveltosCluster); !ok {
+					return wc.Update(ctx, obj, opts...)
+				}
+				updates++
+				// Another actor modifies SveltosCluster right before the first update
+				if concurrentUpdate {
+					concurrentUpdate = false
+					current := &libsveltosv1alpha1.SveltosCluster{}
+					Expect(wc.Get(ctx, sveltosClusterKey, current)).To(Succeed())
+					current.Labels = map[string]string{userLabel: "true"}
+					current.Annotations = nil
+					current.OwnerReferences = nil
+					Expect(wc.Update(ctx, current)).To(Succeed())
+				}
+				return wc.Update(ctx, obj, opts...)
+			},
+		}).Build()
+
+		reconciler := getSecretReconciler(c)
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+
+		// Drift, so SveltosCluster needs to be updated
+		currentSveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+		Expect(c.Get(context.TODO(), sveltosClusterKey, currentSveltosCluster)).To(Succeed())
+		delete(currentSveltosCluster.Annotations, controller.SveltosClusterClaudieAnnotation)
+		Expect(c.Update(context.TODO(), currentSveltosCluster)).To(Succeed())
+
+		concurrentUpdate = true
+		updates = 0
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+		Expect(updates).To(Equal(2))
+
+		Expect(c.Get(context.TODO(), sveltosClusterKey, currentSveltosCluster)).To(Succeed())
+		Expect(currentSveltosCluster.Labels).To(HaveKey(userLabel))
+		Expect(controller.IsSveltosClusterForClaudie(currentSveltosCluster)).To(BeTrue())
+		Expect(currentSveltosCluster.OwnerReferences).To(HaveLen(1))
+		Expect(currentSveltosCluster.OwnerReferences[0].Name).To(Equal(secret.Name))
+	})
+
+	It("createSveltosCluster switches to update when a concurrent reconcile already created SveltosCluster", func() {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: randomString(),
+				Name:      randomString(),
+				Labels: map[string]string{
+					controller.ClaudieLabel:      "claudie",
+					controller.ClaudieKubeconfig: "kubeconfig",
+					controller.ClaudieCluster:    randomString(),
+				},
+			},
+		}
+		Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+
+		creates := 0
+		updates := 0
+		// Simulate a concurrent reconciliation: first Get of SveltosCluster does not see
+		// the SveltosCluster created by the other worker.
+		staleGet := true
+		c := fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, wc client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				if _, ok := obj.(*libsveltosv1alpha1.SveltosCluster); ok && staleGet {
+					staleGet = false
+					return apierrors.NewNotFound(libsveltosv1alpha1.GroupVersion.WithResource("sveltosclusters").GroupResource(), key.Name)
+				}
+				return wc.Get(ctx, key, obj, opts...)
+			},
+			Create: func(ctx context.Context, wc client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				err := wc.Create(ctx, obj, opts...)
+				if err == nil {
+					creates++
+				}
+				return err
+			},
+			Update: func(ctx context.Context, wc client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+				if _, ok := obj.(*libsveltosv1alpha1.SveltosCluster); ok {
+					updates++
+				}
+				return wc.Update(ctx, obj, opts...)
+			},
+		}).Build()
+
+		// SveltosCluster created by the other worker
+		sveltosCluster := &libsveltosv1alpha1.SveltosCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: secret.Namespace,
+				Name:      secret.Labels[controller.ClaudieCluster],
+			},
+		}
+		Expect(c.Create(context.TODO(), sveltosCluster)).To(Succeed())
+
+		reconciler := getSecretReconciler(c)
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+		Expect(creates).To(Equal(1))
+		Expect(updates).To(Equal(1))
+
+		currentSveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+		Expect(c.Get(context.TODO(),
+			types.NamespacedName{Namespace: sveltosCluster.Namespace, Name: sveltosCluster.Name},
+			currentSveltosCluster)).To(Succeed())
+		Expect(currentSveltosCluster.Annotations[controller.SveltosClusterClaudieAnnotation]).ToNot(BeEmpty())
+		Expect(len(currentSveltosCluster.OwnerReferences)).To(Equal(1))
+		Expect(currentSveltosCluster.OwnerReferences[0].Name).To(Equal(secret.Name))
+	})
+})
+
+func getSecretReconciler(c client.Client) *controller.SecretReconciler {
+	return &controller.SecretReconciler{
+		Client: c,
+	}
+}
+
+// getSecretAndSveltosClusterOwnedByOtherSecret returns a Claudie Secret and a SveltosCluster
+// with the name expected for such Secret but owned by a different Secret
+func getSecretAndSveltosClusterOwnedByOtherSecret() (*corev1.Secret, *libsveltosv1alpha1.SveltosCluster) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: randomString(),
+			Name:      randomString(),
+			Labels: map[string]string{
+				controller.ClaudieLabel:      "claudie",
+				controller.ClaudieKubeconfig: "kubeconfig",
+				controller.ClaudieCluster:    randomString(),
+			},
+		},
+	}
+	Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+
+	sveltosCluster := &libsveltosv1alpha1.SveltosCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: secret.Namespace,
+			Name:      secret.Labels[controller.ClaudieCluster],
+			Annotations: map[string]string{
+				controller.SveltosClusterClaudieAnnotation: "ok",
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					Kind:       "Secret",
+					APIVersion: "v1",
+					Name:       randomString(),
+				},
+			},
+		},
+	}
+
+	return secret, sveltosCluster
 }
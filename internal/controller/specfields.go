@@ -0,0 +1,99 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+)
+
+// getSpecFieldNames returns the JSON names of all SveltosCluster Spec fields
+func getSpecFieldNames() map[string]bool {
+	names := make(map[string]bool)
+
+	t := reflect.TypeOf(libsveltosv1alpha1.SveltosClusterSpec{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			names[name] = true
+		}
+	}
+
+	return names
+}
+
+// ValidateSpecFields verifies all fields are SveltosCluster Spec fields (in their JSON form,
+// e.g. kubeconfigName)
+func ValidateSpecFields(fields []string) error {
+	names := getSpecFieldNames()
+	for i := range fields {
+		if !names[fields[i]] {
+			return fmt.Errorf("%q is not a SveltosCluster spec field", fields[i])
+		}
+	}
+
+	return nil
+}
+
+// restrictSpecUpdate reverts, on sveltosCluster, any change to Spec fields not in EnforcedSpecFields,
+// so that fields outside the allow-list are never touched on update.
+// When EnforcedSpecFields is empty, all fields can be enforced.
+func (r *SecretReconciler) restrictSpecUpdate(original, sveltosCluster *libsveltosv1alpha1.SveltosCluster) error {
+	if len(r.EnforcedSpecFields) == 0 {
+		return nil
+	}
+
+	originalSpec, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&original.Spec)
+	if err != nil {
+		return err
+	}
+
+	desiredSpec, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&sveltosCluster.Spec)
+	if err != nil {
+		return err
+	}
+
+	allowed := make(map[string]bool, len(r.EnforcedSpecFields))
+	for i := range r.EnforcedSpecFields {
+		allowed[r.EnforcedSpecFields[i]] = true
+	}
+
+	spec := make(map[string]interface{})
+	for field, value := range originalSpec {
+		if !allowed[field] {
+			spec[field] = value
+		}
+	}
+	for field, value := range desiredSpec {
+		if allowed[field] {
+			spec[field] = value
+		}
+	}
+
+	restricted := libsveltosv1alpha1.SveltosClusterSpec{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(spec, &restricted); err != nil {
+		return err
+	}
+
+	sveltosCluster.Spec = restricted
+	return nil
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"gianlucam76/claudie-sveltos-integration/internal/controller"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+)
+
+var _ = Describe("Spec fields", func() {
+	It("ValidateSpecFields accepts SveltosCluster spec fields only", func() {
+		Expect(controller.ValidateSpecFields(nil)).To(Succeed())
+		Expect(controller.ValidateSpecFields([]string{"kubeconfigName", "consecutiveFailureThreshold", "paused"})).To(Succeed())
+		Expect(controller.ValidateSpecFields([]string{"kubeconfigName", randomString()})).ToNot(Succeed())
+		// Go field names are not accepted
+		Expect(controller.ValidateSpecFields([]string{"KubeconfigName"})).ToNot(Succeed())
+	})
+
+	It("restrictSpecUpdate reverts changes to fields outside the allow-list", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := getSecretReconciler(c)
+		reconciler.EnforcedSpecFields = []string{"kubeconfigName"}
+
+		original := &libsveltosv1alpha1.SveltosCluster{
+			Spec: libsveltosv1alpha1.SveltosClusterSpec{
+				KubeconfigName:              randomString(),
+				ConsecutiveFailureThreshold: 3,
+			},
+		}
+
+		desired := original.DeepCopy()
+		desired.Spec.KubeconfigName = randomString()
+		desired.Spec.ConsecutiveFailureThreshold = 10
+		desired.Spec.Paused = true
+
+		Expect(controller.RestrictSpecUpdate(reconciler, original, desired)).To(Succeed())
+		Expect(desired.Spec.KubeconfigName).ToNot(Equal(original.Spec.KubeconfigName))
+		Expect(desired.Spec.ConsecutiveFailureThreshold).To(Equal(original.Spec.ConsecutiveFailureThreshold))
+		Expect(desired.Spec.Paused).To(BeFalse())
+	})
+
+	It("createSveltosCluster only enforces allowed spec fields on update", func() {
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+		reconciler := getSecretReconciler(c)
+		reconciler.EnforcedSpecFields = []string{"paused"}
+
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+
+		sveltosClusterKey := types.NamespacedName{Namespace: secret.Namespace, Name: secret.Labels[controller.ClaudieCluster]}
+		currentSveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+		Expect(c.Get(context.TODO(), sveltosClusterKey, currentSveltosCluster)).To(Succeed())
+		// On create, all fields are set
+		Expect(currentSveltosCluster.Spec.KubeconfigName).To(Equal(secret.Name))
+
+		kubeconfigName := randomString()
+		currentSveltosCluster.Spec.KubeconfigName = kubeconfigName
+		Expect(c.Update(context.TODO(), currentSveltosCluster)).To(Succeed())
+
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+
+		Expect(c.Get(context.TODO(), sveltosClusterKey, currentSveltosCluster)).To(Succeed())
+		Expect(currentSveltosCluster.Spec.KubeconfigName).To(Equal(kubeconfigName))
+
+		// Once allowed, field is enforced
+		reconciler.EnforcedSpecFields = []string{"kubeconfigName"}
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+
+		Expect(c.Get(context.TODO(), sveltosClusterKey, currentSveltosCluster)).To(Succeed())
+		Expect(currentSveltosCluster.Spec.KubeconfigName).To(Equal(secret.Name))
+	})
+})
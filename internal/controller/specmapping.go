@@ -0,0 +1,155 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+	logs "github.com/projectsveltos/libsveltos/lib/logsettings"
+)
+
+// ParseSpecMapping parses entries in the form annotationKey=specFieldPath (e.g.
+// example.com/paused=paused) into a map annotation key -> spec field path
+func ParseSpecMapping(entries []string) (map[string]string, error) {
+	mapping := make(map[string]string, len(entries))
+	for i := range entries {
+		annotation, path, found := strings.Cut(entries[i], "=")
+		if !found || annotation == "" || path == "" {
+			return nil, fmt.Errorf("invalid mapping %q: expected annotationKey=specFieldPath", entries[i])
+		}
+		mapping[annotation] = path
+	}
+
+	return mapping, nil
+}
+
+// applySpecMapping sets, for each annotation in SpecMapping present on the Secret, the corresponding
+// SveltosCluster Spec field. Invalid mappings (unknown paths, unsupported types or values which cannot be
+// parsed) are skipped.
+func (r *SecretReconciler) applySpecMapping(sveltosCluster *libsveltosv1alpha1.SveltosCluster, secret *corev1.Secret,
+	logger logr.Logger) {
+
+	for annotation, path := range r.SpecMapping {
+		value, ok := secret.Annotations[annotation]
+		if !ok {
+			continue
+		}
+
+		if err := setSpecField(sveltosCluster, path, value); err != nil {
+			logger.V(logs.LogInfo).Info(fmt.Sprintf("skipping mapping of annotation %s to spec field %s: %v",
+				annotation, path, err))
+		}
+	}
+}
+
+// setSpecField sets the Spec field identified by path (JSON field names separated by dots) to value,
+// converted to the field type
+func setSpecField(sveltosCluster *libsveltosv1alpha1.SveltosCluster, path, value string) error {
+	fields := strings.Split(path, ".")
+
+	fieldType, err := getSpecFieldType(fields)
+	if err != nil {
+		return err
+	}
+
+	typedValue, err := convertSpecValue(fieldType, value)
+	if err != nil {
+		return err
+	}
+
+	spec, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&sveltosCluster.Spec)
+	if err != nil {
+		return err
+	}
+
+	if err := unstructured.SetNestedField(spec, typedValue, fields...); err != nil {
+		return err
+	}
+
+	updated := libsveltosv1alpha1.SveltosClusterSpec{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(spec, &updated); err != nil {
+		return err
+	}
+
+	sveltosCluster.Spec = updated
+	return nil
+}
+
+// getSpecFieldType returns the type of the SveltosCluster Spec field identified by fields
+func getSpecFieldType(fields []string) (reflect.Type, error) {
+	t := reflect.TypeOf(libsveltosv1alpha1.SveltosClusterSpec{})
+
+	for i := range fields {
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("%s is not a struct", strings.Join(fields[:i], "."))
+		}
+
+		found := false
+		for j := 0; j < t.NumField(); j++ {
+			name, _, _ := strings.Cut(t.Field(j).Tag.Get("json"), ",")
+			if name == fields[i] {
+				t = t.Field(j).Type
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown spec field %s", strings.Join(fields[:i+1], "."))
+		}
+	}
+
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	return t, nil
+}
+
+// convertSpecValue converts value to its unstructured representation for a field of type fieldType
+func convertSpecValue(fieldType reflect.Type, value string) (interface{}, error) {
+	if fieldType == reflect.TypeOf(metav1.Duration{}) {
+		if _, err := time.ParseDuration(value); err != nil {
+			return nil, err
+		}
+		return value, nil
+	}
+
+	switch fieldType.Kind() {
+	case reflect.String:
+		return value, nil
+	case reflect.Bool:
+		return strconv.ParseBool(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.ParseInt(value, 10, fieldType.Bits())
+	default:
+		return nil, fmt.Errorf("unsupported field type %s", fieldType)
+	}
+}
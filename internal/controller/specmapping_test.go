@@ -0,0 +1,90 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"gianlucam76/claudie-sveltos-integration/internal/controller"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+)
+
+var _ = Describe("Spec mapping", func() {
+	It("ParseSpecMapping parses annotationKey=specFieldPath entries", func() {
+		mapping, err := controller.ParseSpecMapping([]string{"example.com/paused=paused",
+			"example.com/threshold=consecutiveFailureThreshold"})
+		Expect(err).To(BeNil())
+		Expect(mapping).To(HaveLen(2))
+		Expect(mapping["example.com/paused"]).To(Equal("paused"))
+		Expect(mapping["example.com/threshold"]).To(Equal("consecutiveFailureThreshold"))
+
+		_, err = controller.ParseSpecMapping([]string{"example.com/paused"})
+		Expect(err).ToNot(BeNil())
+
+		_, err = controller.ParseSpecMapping([]string{"=paused"})
+		Expect(err).ToNot(BeNil())
+	})
+
+	It("applySpecMapping sets spec fields and skips invalid mappings", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := getSecretReconciler(c)
+		reconciler.SpecMapping = map[string]string{
+			"example.com/paused":    "paused",
+			"example.com/threshold": "consecutiveFailureThreshold",
+			"example.com/renew":     "tokenRequestRenewalOption.renewTokenRequestInterval",
+			"example.com/unknown":   "unknown.field",
+			"example.com/missing":   "kubeconfigName",
+		}
+
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		secret.Annotations = map[string]string{
+			"example.com/paused":    "true",
+			"example.com/threshold": "5",
+			"example.com/renew":     "1h",
+			"example.com/unknown":   randomString(),
+		}
+
+		kubeconfigName := randomString()
+		sveltosCluster := &libsveltosv1alpha1.SveltosCluster{
+			Spec: libsveltosv1alpha1.SveltosClusterSpec{
+				KubeconfigName: kubeconfigName,
+			},
+		}
+
+		controller.ApplySpecMapping(reconciler, sveltosCluster, secret, logr.Logger{})
+		Expect(sveltosCluster.Spec.Paused).To(BeTrue())
+		Expect(sveltosCluster.Spec.ConsecutiveFailureThreshold).To(Equal(5))
+		Expect(sveltosCluster.Spec.TokenRequestRenewalOption).ToNot(BeNil())
+		Expect(sveltosCluster.Spec.TokenRequestRenewalOption.RenewTokenRequestInterval.Duration).To(Equal(time.Hour))
+		// Annotation not present on the Secret, field is left untouched
+		Expect(sveltosCluster.Spec.KubeconfigName).To(Equal(kubeconfigName))
+
+		// Value which cannot be converted to the field type is skipped
+		secret.Annotations["example.com/paused"] = randomString()
+		sveltosCluster.Spec.Paused = false
+		secret.Annotations["example.com/threshold"] = randomString()
+		controller.ApplySpecMapping(reconciler, sveltosCluster, secret, logr.Logger{})
+		Expect(sveltosCluster.Spec.Paused).To(BeFalse())
+		Expect(sveltosCluster.Spec.ConsecutiveFailureThreshold).To(Equal(5))
+	})
+})
@@ -0,0 +1,175 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// timeoutClient bounds every read and write to the API server with a timeout, so a hung
+// request fails (and the Secret is requeued) instead of pinning a reconcile worker
+type timeoutClient struct {
+	client.Client
+	timeout time.Duration
+}
+
+// newTimeoutClient returns c with each call bounded by timeout. If timeout is not positive,
+// c is returned unchanged.
+func newTimeoutClient(c client.Client, timeout time.Duration) client.Client {
+	if timeout <= 0 {
+		return c
+	}
+	if tc, ok := c.(*timeoutClient); ok {
+		return &timeoutClient{Client: tc.Client, timeout: timeout}
+	}
+	return &timeoutClient{Client: c, timeout: timeout}
+}
+
+func (c *timeoutClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object,
+	opts ...client.GetOption) error {
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.Client.Get(ctx, key, obj, opts...)
+}
+
+func (c *timeoutClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.Client.List(ctx, list, opts...)
+}
+
+func (c *timeoutClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func (c *timeoutClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func (c *timeoutClient) Patch(ctx context.Context, obj client.Object, patch client.Patch,
+	opts ...client.PatchOption) error {
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func (c *timeoutClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+func (c *timeoutClient) DeleteAllOf(ctx context.Context, obj client.Object, opts ...client.DeleteAllOfOption) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.Client.DeleteAllOf(ctx, obj, opts...)
+}
+
+func (c *timeoutClient) Status() client.SubResourceWriter {
+	return &timeoutSubResourceWriter{SubResourceWriter: c.Client.Status(), timeout: c.timeout}
+}
+
+func (c *timeoutClient) SubResource(subResource string) client.SubResourceClient {
+	subResourceClient := c.Client.SubResource(subResource)
+	return &timeoutSubResourceClient{
+		timeoutSubResourceWriter: timeoutSubResourceWriter{SubResourceWriter: subResourceClient, timeout: c.timeout},
+		reader:                   subResourceClient,
+	}
+}
+
+// timeoutSubResourceWriter bounds every subresource (e.g. status) write with a timeout
+type timeoutSubResourceWriter struct {
+	client.SubResourceWriter
+	timeout time.Duration
+}
+
+func (w *timeoutSubResourceWriter) Create(ctx context.Context, obj client.Object, subResource client.Object,
+	opts ...client.SubResourceCreateOption) error {
+
+	ctx, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+	return w.SubResourceWriter.Create(ctx, obj, subResource, opts...)
+}
+
+func (w *timeoutSubResourceWriter) Update(ctx context.Context, obj client.Object,
+	opts ...client.SubResourceUpdateOption) error {
+
+	ctx, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+	return w.SubResourceWriter.Update(ctx, obj, opts...)
+}
+
+func (w *timeoutSubResourceWriter) Patch(ctx context.Context, obj client.Object, patch client.Patch,
+	opts ...client.SubResourcePatchOption) error {
+
+	ctx, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+	return w.SubResourceWriter.Patch(ctx, obj, patch, opts...)
+}
+
+// timeoutSubResourceClient bounds every subresource read and write with a timeout
+type timeoutSubResourceClient struct {
+	timeoutSubResourceWriter
+	reader client.SubResourceReader
+}
+
+func (c *timeoutSubResourceClient) Get(ctx context.Context, obj client.Object, subResource client.Object,
+	opts ...client.SubResourceGetOption) error {
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.reader.Get(ctx, obj, subResource, opts...)
+}
+
+// timeoutReader bounds every read with a timeout. It is used for readers bypassing the cache
+// (e.g. the manager APIReader), which are not wrapped by timeoutClient.
+type timeoutReader struct {
+	client.Reader
+	timeout time.Duration
+}
+
+// newTimeoutReader returns reader with each call bounded by timeout. If timeout is not positive,
+// reader is returned unchanged.
+func newTimeoutReader(reader client.Reader, timeout time.Duration) client.Reader {
+	if timeout <= 0 {
+		return reader
+	}
+	return &timeoutReader{Reader: reader, timeout: timeout}
+}
+
+func (r *timeoutReader) Get(ctx context.Context, key client.ObjectKey, obj client.Object,
+	opts ...client.GetOption) error {
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.Reader.Get(ctx, key, obj, opts...)
+}
+
+func (r *timeoutReader) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return r.Reader.List(ctx, list, opts...)
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"gianlucam76/claudie-sveltos-integration/internal/controller"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+)
+
+var _ = Describe("API call timeout", func() {
+	It("newTimeoutClient fails hung calls once timeout expires", func() {
+		// Simulate a hung API server
+		hung := func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		c := fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, _ client.WithWatch, _ client.ObjectKey, _ client.Object, _ ...client.GetOption) error {
+				return hung(ctx)
+			},
+			List: func(ctx context.Context, _ client.WithWatch, _ client.ObjectList, _ ...client.ListOption) error {
+				return hung(ctx)
+			},
+			Create: func(ctx context.Context, _ client.WithWatch, _ client.Object, _ ...client.CreateOption) error {
+				return hung(ctx)
+			},
+		}).Build()
+
+		timeoutClient := controller.NewTimeoutClient(c, 50*time.Millisecond)
+
+		secretKey := types.NamespacedName{Namespace: randomString(), Name: randomString()}
+		Expect(timeoutClient.Get(context.TODO(), secretKey, &corev1.Secret{})).To(MatchError(context.DeadlineExceeded))
+		Expect(timeoutClient.List(context.TODO(), &libsveltosv1alpha1.SveltosClusterList{})).
+			To(MatchError(context.DeadlineExceeded))
+		Expect(timeoutClient.Create(context.TODO(), &corev1.Secret{})).To(MatchError(context.DeadlineExceeded))
+	})
+
+	It("newTimeoutClient fails hung subresource calls once timeout expires", func() {
+		// Simulate a hung API server
+		hung := func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		c := fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(interceptor.Funcs{
+			SubResourceGet: func(ctx context.Context, _ client.Client, _ string, _ client.Object, _ client.Object,
+				_ ...client.SubResourceGetOption) error {
+				return hung(ctx)
+			},
+			SubResourceUpdate: func(ctx context.Context, _ client.Client, _ string, _ client.Object,
+				_ ...client.SubResourceUpdateOption) error {
+				return hung(ctx)
+			},
+			SubResourcePatch: func(ctx context.Context, _ client.Client, _ string, _ client.Object, _ client.Patch,
+				_ ...client.SubResourcePatchOption) error {
+				return hung(ctx)
+			},
+		}).Build()
+
+		timeoutClient := controller.NewTimeoutClient(c, 50*time.Millisecond)
+
+		sveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+		Expect(timeoutClient.Status().Update(context.TODO(), sveltosCluster)).To(MatchError(context.DeadlineExceeded))
+		Expect(timeoutClient.Status().Patch(context.TODO(), sveltosCluster, client.MergeFrom(sveltosCluster))).
+			To(MatchError(context.DeadlineExceeded))
+		Expect(timeoutClient.SubResource("status").Update(context.TODO(), sveltosCluster)).
+			To(MatchError(context.DeadlineExceeded))
+		Expect(timeoutClient.SubResource("status").Get(context.TODO(), sveltosCluster, &libsveltosv1alpha1.SveltosCluster{})).
+			To(MatchError(context.DeadlineExceeded))
+	})
+
+	It("newTimeoutReader fails hung reads once timeout expires", func() {
+		// Simulate a hung API server
+		hung := func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		c := fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, _ client.WithWatch, _ client.ObjectKey, _ client.Object, _ ...client.GetOption) error {
+				return hung(ctx)
+			},
+			List: func(ctx context.Context, _ client.WithWatch, _ client.ObjectList, _ ...client.ListOption) error {
+				return hung(ctx)
+			},
+		}).Build()
+
+		reader := controller.NewTimeoutReader(c, 50*time.Millisecond)
+
+		secretKey := types.NamespacedName{Namespace: randomString(), Name: randomString()}
+		Expect(reader.Get(context.TODO(), secretKey, &corev1.Secret{})).To(MatchError(context.DeadlineExceeded))
+		Expect(reader.List(context.TODO(), &libsveltosv1alpha1.SveltosClusterList{})).
+			To(MatchError(context.DeadlineExceeded))
+
+		unbounded := fake.NewClientBuilder().WithScheme(scheme).Build()
+		Expect(controller.NewTimeoutReader(unbounded, 0)).To(BeIdenticalTo(unbounded))
+	})
+
+	It("newTimeoutClient returns client unchanged when timeout is not set", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		Expect(controller.NewTimeoutClient(c, 0)).To(BeIdenticalTo(c))
+	})
+})
@@ -0,0 +1,125 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// clusterTracker keeps track of the SveltosCluster created for each Claudie Secret.
+// It is safe for concurrent use: lookups, the most frequent accesses, only take the read
+// lock so they do not serialize reconcile workers. The zero value is ready to use.
+type clusterTracker struct {
+	mux             sync.RWMutex
+	secretToCluster map[types.NamespacedName]types.NamespacedName
+}
+
+// Get returns the SveltosCluster tracked for Secret, if any
+func (t *clusterTracker) Get(secretKey types.NamespacedName) (types.NamespacedName, bool) {
+	secretToClusterOperations.WithLabelValues(operationLookup).Inc()
+
+	t.mux.RLock()
+	defer t.mux.RUnlock()
+
+	sveltosClusterKey, ok := t.secretToCluster[secretKey]
+	return sveltosClusterKey, ok
+}
+
+// GetSecret returns the Secret the SveltosCluster is tracked for, if any
+func (t *clusterTracker) GetSecret(sveltosClusterKey types.NamespacedName) (types.NamespacedName, bool) {
+	secretToClusterOperations.WithLabelValues(operationLookup).Inc()
+
+	t.mux.RLock()
+	defer t.mux.RUnlock()
+
+	for secretKey := range t.secretToCluster {
+		if t.secretToCluster[secretKey] == sveltosClusterKey {
+			return secretKey, true
+		}
+	}
+	return types.NamespacedName{}, false
+}
+
+// Set records the SveltosCluster for Secret
+func (t *clusterTracker) Set(secretKey, sveltosClusterKey types.NamespacedName) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	if t.secretToCluster == nil {
+		t.secretToCluster = make(map[types.NamespacedName]types.NamespacedName)
+	}
+
+	if _, ok := t.secretToCluster[secretKey]; !ok {
+		secretToClusterOperations.WithLabelValues(operationInsert).Inc()
+	}
+	t.secretToCluster[secretKey] = sveltosClusterKey
+	secretToClusterSize.Set(float64(len(t.secretToCluster)))
+}
+
+// Delete forgets the SveltosCluster tracked for Secret
+func (t *clusterTracker) Delete(secretKey types.NamespacedName) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	t.delete(secretKey)
+}
+
+// CompareAndDelete forgets the SveltosCluster tracked for Secret only if it is sveltosClusterKey.
+// Returns true if it was forgotten.
+func (t *clusterTracker) CompareAndDelete(secretKey, sveltosClusterKey types.NamespacedName) bool {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	current, ok := t.secretToCluster[secretKey]
+	if !ok || current != sveltosClusterKey {
+		return false
+	}
+
+	t.delete(secretKey)
+	return true
+}
+
+// Secrets returns all Secrets a SveltosCluster is tracked for
+func (t *clusterTracker) Secrets() []types.NamespacedName {
+	t.mux.RLock()
+	defer t.mux.RUnlock()
+
+	secrets := make([]types.NamespacedName, 0, len(t.secretToCluster))
+	for secretKey := range t.secretToCluster {
+		secrets = append(secrets, secretKey)
+	}
+	return secrets
+}
+
+// Len returns the number of tracked Secrets
+func (t *clusterTracker) Len() int {
+	t.mux.RLock()
+	defer t.mux.RUnlock()
+
+	return len(t.secretToCluster)
+}
+
+// delete forgets the SveltosCluster tracked for Secret. Must be called with mux held.
+func (t *clusterTracker) delete(secretKey types.NamespacedName) {
+	if _, ok := t.secretToCluster[secretKey]; ok {
+		secretToClusterOperations.WithLabelValues(operationDelete).Inc()
+		delete(t.secretToCluster, secretKey)
+	}
+	secretToClusterSize.Set(float64(len(t.secretToCluster)))
+}
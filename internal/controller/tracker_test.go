@@ -0,0 +1,94 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/types"
+
+	"gianlucam76/claudie-sveltos-integration/internal/controller"
+)
+
+var _ = Describe("clusterTracker", func() {
+	It("tracks the SveltosCluster of each Secret", func() {
+		tracker := &controller.ClusterTracker{}
+		Expect(tracker.Len()).To(BeZero())
+
+		secretKey := types.NamespacedName{Namespace: randomString(), Name: randomString()}
+		sveltosClusterKey := types.NamespacedName{Namespace: randomString(), Name: randomString()}
+
+		_, ok := tracker.Get(secretKey)
+		Expect(ok).To(BeFalse())
+
+		tracker.Set(secretKey, sveltosClusterKey)
+		current, ok := tracker.Get(secretKey)
+		Expect(ok).To(BeTrue())
+		Expect(current).To(Equal(sveltosClusterKey))
+		Expect(tracker.Len()).To(Equal(1))
+		Expect(tracker.Secrets()).To(ConsistOf(secretKey))
+
+		current, ok = tracker.GetSecret(sveltosClusterKey)
+		Expect(ok).To(BeTrue())
+		Expect(current).To(Equal(secretKey))
+
+		tracker.Delete(secretKey)
+		_, ok = tracker.Get(secretKey)
+		Expect(ok).To(BeFalse())
+		Expect(tracker.Len()).To(BeZero())
+	})
+
+	It("CompareAndDelete forgets a Secret only if tracking the given SveltosCluster", func() {
+		tracker := &controller.ClusterTracker{}
+
+		secretKey := types.NamespacedName{Namespace: randomString(), Name: randomString()}
+		sveltosClusterKey := types.NamespacedName{Namespace: randomString(), Name: randomString()}
+		tracker.Set(secretKey, sveltosClusterKey)
+
+		Expect(tracker.CompareAndDelete(secretKey, types.NamespacedName{Name: randomString()})).To(BeFalse())
+		Expect(tracker.Len()).To(Equal(1))
+
+		Expect(tracker.CompareAndDelete(secretKey, sveltosClusterKey)).To(BeTrue())
+		Expect(tracker.Len()).To(BeZero())
+	})
+
+	It("is safe for concurrent use", func() {
+		const workers = 20
+		tracker := &controller.ClusterTracker{}
+
+		var wg sync.WaitGroup
+		for i := 0; i < workers; i++ {
+			secretKey := types.NamespacedName{Namespace: randomString(), Name: randomString()}
+			sveltosClusterKey := types.NamespacedName{Namespace: randomString(), Name: randomString()}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				tracker.Set(secretKey, sveltosClusterKey)
+				tracker.Get(secretKey)
+				tracker.GetSecret(sveltosClusterKey)
+				tracker.Secrets()
+				tracker.Set(secretKey, sveltosClusterKey)
+			}()
+		}
+		wg.Wait()
+
+		Expect(tracker.Len()).To(Equal(workers))
+	})
+})
@@ -0,0 +1,176 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+	logs "github.com/projectsveltos/libsveltos/lib/logsettings"
+)
+
+const (
+	// ttlAnnotation can be set on a Claudie Secret to a duration (e.g. 24h). Once such duration
+	// has elapsed since the Secret creation, the SveltosCluster is automatically removed.
+	ttlAnnotation = "projectsveltos.io/claudie-ttl"
+
+	// ttlDeleteSecretAnnotation, when set to "true" on a Claudie Secret with a TTL, makes the
+	// Secret itself removed when the TTL expires
+	ttlDeleteSecretAnnotation = "projectsveltos.io/claudie-ttl-delete-secret"
+
+	// sveltosClusterExpiresAtAnnotation contains, in RFC3339 format, the time a SveltosCluster expires
+	sveltosClusterExpiresAtAnnotation = "projectsveltos.io/claudie-expires-at"
+)
+
+// getSecretExpiration returns the time the SveltosCluster for secret expires.
+// Returns a zero time if no TTL is set.
+func getSecretExpiration(secret *corev1.Secret) (time.Time, error) {
+	ttl, ok := secret.Annotations[ttlAnnotation]
+	if !ok {
+		return time.Time{}, nil
+	}
+
+	duration, err := time.ParseDuration(ttl)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid TTL %q: %w", ttl, err)
+	}
+	if duration <= 0 {
+		return time.Time{}, fmt.Errorf("invalid TTL %q: must be positive", ttl)
+	}
+
+	return secret.CreationTimestamp.Add(duration), nil
+}
+
+// isSecretExpired returns true if the TTL set on secret has expired. An invalid TTL is ignored.
+func isSecretExpired(secret *corev1.Secret, now time.Time, logger logr.Logger) bool {
+	expiration, err := getSecretExpiration(secret)
+	if err != nil {
+		logger.V(logs.LogInfo).Info(fmt.Sprintf("ignoring TTL: %v", err))
+		return false
+	}
+
+	return !expiration.IsZero() && !now.Before(expiration)
+}
+
+// getExpirationRequeue returns how long to wait before reconciling secret again so that its SveltosCluster
+// is removed as soon as TTL expires. Returns zero if no (valid) TTL is set.
+func getExpirationRequeue(secret *corev1.Secret, now time.Time) time.Duration {
+	expiration, err := getSecretExpiration(secret)
+	if err != nil || expiration.IsZero() {
+		return 0
+	}
+
+	// Wait a bit past expiration, so that the Secret is seen as expired when reconciled
+	return expiration.Sub(now) + time.Second
+}
+
+// expireSecret removes the SveltosCluster of a Secret whose TTL expired. The Secret itself is
+// removed as well if so requested.
+func (r *SecretReconciler) expireSecret(ctx context.Context, secret *corev1.Secret, logger logr.Logger) error {
+	secretKey := client.ObjectKeyFromObject(secret)
+	err := r.cleanSveltosCluster(ctx, ctrl.Request{NamespacedName: secretKey}, logger)
+	if err != nil {
+		return err
+	}
+
+	if secret.Annotations[ttlDeleteSecretAnnotation] != "true" {
+		return nil
+	}
+
+	logger.V(logs.LogInfo).Info("Secret TTL expired. Removing it")
+	return client.IgnoreNotFound(r.Delete(ctx, secret))
+}
+
+// addExpirationAnnotation reports on the SveltosCluster when it expires. Annotation is removed
+// when no (valid) TTL is set on the Secret.
+func (r *SecretReconciler) addExpirationAnnotation(sveltosCluster *libsveltosv1alpha1.SveltosCluster,
+	secret *corev1.Secret) {
+
+	expiration, err := getSecretExpiration(secret)
+	if err != nil || expiration.IsZero() {
+		delete(sveltosCluster.Annotations, sveltosClusterExpiresAtAnnotation)
+		return
+	}
+
+	if sveltosCluster.Annotations == nil {
+		sveltosCluster.Annotations = make(map[string]string)
+	}
+	sveltosCluster.Annotations[sveltosClusterExpiresAtAnnotation] = expiration.UTC().Format(time.RFC3339)
+}
+
+// isSveltosClusterExpired returns true if SveltosCluster has an expiration time in the past
+func isSveltosClusterExpired(sveltosCluster *libsveltosv1alpha1.SveltosCluster, now time.Time) bool {
+	expiresAt, ok := sveltosCluster.Annotations[sveltosClusterExpiresAtAnnotation]
+	if !ok {
+		return false
+	}
+
+	expiration, err := time.Parse(time.RFC3339, expiresAt)
+	if err != nil {
+		return false
+	}
+
+	return !now.Before(expiration)
+}
+
+// removeExpiredSveltosCluster deletes an expired SveltosCluster along with its mirrored kubeconfig.
+// The Claudie Secret is deleted as well if so requested.
+func removeExpiredSveltosCluster(ctx context.Context, c client.Client, sveltosCluster *libsveltosv1alpha1.SveltosCluster,
+	claudieSecret *types.NamespacedName, logger logr.Logger) {
+
+	logger.V(logs.LogInfo).Info(fmt.Sprintf("SveltosCluster %s/%s expired. Removing it",
+		sveltosCluster.Namespace, sveltosCluster.Name))
+
+	err := deleteWithRetry(ctx, c, sveltosCluster)
+	if err != nil {
+		logger.V(logs.LogInfo).Info(
+			fmt.Sprintf("failed to delete expired sveltosCluster %s/%s: %v",
+				sveltosCluster.Namespace, sveltosCluster.Name, err))
+		return
+	}
+
+	err = removeMirroredKubeconfig(ctx, c,
+		types.NamespacedName{Namespace: sveltosCluster.Namespace, Name: sveltosCluster.Name})
+	if err != nil {
+		logger.V(logs.LogInfo).Info(
+			fmt.Sprintf("failed to delete mirrored kubeconfig for sveltosCluster %s/%s: %v",
+				sveltosCluster.Namespace, sveltosCluster.Name, err))
+	}
+
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, *claudieSecret, secret); err != nil {
+		return
+	}
+
+	if secret.Annotations[ttlDeleteSecretAnnotation] != "true" {
+		return
+	}
+
+	err = c.Delete(ctx, secret)
+	if client.IgnoreNotFound(err) != nil {
+		logger.V(logs.LogInfo).Info(
+			fmt.Sprintf("failed to delete expired Secret %s: %v", claudieSecret, err))
+	}
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"gianlucam76/claudie-sveltos-integration/internal/controller"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+)
+
+var _ = Describe("TTL", func() {
+	It("createSveltosCluster reports expiration time on SveltosCluster", func() {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+		reconciler := getSecretReconciler(c)
+
+		created := metav1.NewTime(time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC))
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		secret.CreationTimestamp = created
+		secret.Annotations = map[string]string{controller.TTLAnnotation: "2h"}
+
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+
+		sveltosClusterKey := types.NamespacedName{Namespace: secret.Namespace, Name: secret.Labels[controller.ClaudieCluster]}
+		currentSveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+		Expect(c.Get(context.TODO(), sveltosClusterKey, currentSveltosCluster)).To(Succeed())
+		Expect(currentSveltosCluster.Annotations[controller.SveltosClusterExpiresAtAnnotation]).
+			To(Equal("2024-01-01T12:00:00Z"))
+
+		// TTL removed
+		secret.Annotations = nil
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+		Expect(c.Get(context.TODO(), sveltosClusterKey, currentSveltosCluster)).To(Succeed())
+		Expect(currentSveltosCluster.Annotations).ToNot(HaveKey(controller.SveltosClusterExpiresAtAnnotation))
+	})
+
+	It("removeStaleSveltosClusters removes expired SveltosClusters only", func() {
+		expiredSecret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		expiredSecret.Annotations = map[string]string{controller.TTLDeleteSecretAnnotation: "true"}
+		expiredSveltosCluster := getSveltosClusterForSecret(expiredSecret,
+			time.Now().Add(-time.Minute).UTC().Format(time.RFC3339))
+
+		validSecret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		validSveltosCluster := getSveltosClusterForSecret(validSecret,
+			time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+
+		c := fake.NewClientBuilder().WithScheme(scheme).
+			WithObjects(expiredSecret, expiredSveltosCluster, validSecret, validSveltosCluster).Build()
+
+		mapReady := make(chan struct{})
+		close(mapReady)
+		controller.RemoveStaleSveltosClusters(context.TODO(), c, c, controller.DefaultClaudieMetadata, 0, mapReady, logr.Logger{})
+
+		err := c.Get(context.TODO(),
+			types.NamespacedName{Namespace: expiredSveltosCluster.Namespace, Name: expiredSveltosCluster.Name},
+			&libsveltosv1alpha1.SveltosCluster{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+		err = c.Get(context.TODO(),
+			types.NamespacedName{Namespace: expiredSecret.Namespace, Name: expiredSecret.Name}, &corev1.Secret{})
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+		Expect(c.Get(context.TODO(),
+			types.NamespacedName{Namespace: validSveltosCluster.Namespace, Name: validSveltosCluster.Name},
+			&libsveltosv1alpha1.SveltosCluster{})).To(Succeed())
+		Expect(c.Get(context.TODO(),
+			types.NamespacedName{Namespace: validSecret.Namespace, Name: validSecret.Name}, &corev1.Secret{})).To(Succeed())
+	})
+
+	It("Reconcile does not recreate SveltosCluster once TTL expired", func() {
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		secret.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Hour))
+		secret.Annotations = map[string]string{controller.TTLAnnotation: "30m"}
+		Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+		reconciler := getSecretReconciler(c)
+
+		secretRef := reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name},
+		}
+		result, err := reconciler.Reconcile(context.TODO(), secretRef)
+		Expect(err).To(BeNil())
+		Expect(result.Requeue).To(BeFalse())
+
+		currentSveltosClusters := &libsveltosv1alpha1.SveltosClusterList{}
+		Expect(c.List(context.TODO(), currentSveltosClusters)).To(Succeed())
+		Expect(currentSveltosClusters.Items).To(BeEmpty())
+	})
+
+	It("Reconcile requeues Secret when TTL expires", func() {
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		secret.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Hour))
+		secret.Annotations = map[string]string{controller.TTLAnnotation: "90m"}
+		Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+		reconciler := getSecretReconciler(c)
+
+		secretRef := reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name},
+		}
+		result, err := reconciler.Reconcile(context.TODO(), secretRef)
+		Expect(err).To(BeNil())
+		Expect(result.RequeueAfter).To(BeNumerically("~", 30*time.Minute, time.Minute))
+
+		currentSveltosClusters := &libsveltosv1alpha1.SveltosClusterList{}
+		Expect(c.List(context.TODO(), currentSveltosClusters)).To(Succeed())
+		Expect(currentSveltosClusters.Items).To(HaveLen(1))
+
+		// Without TTL, no requeue
+		Expect(c.Get(context.TODO(), secretRef.NamespacedName, secret)).To(Succeed())
+		secret.Annotations = nil
+		Expect(c.Update(context.TODO(), secret)).To(Succeed())
+		result, err = reconciler.Reconcile(context.TODO(), secretRef)
+		Expect(err).To(BeNil())
+		Expect(result.RequeueAfter).To(BeZero())
+	})
+
+	It("Reconcile removes expired Secret when requested", func() {
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		secret.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Hour))
+		secret.Annotations = map[string]string{
+			controller.TTLAnnotation:             "30m",
+			controller.TTLDeleteSecretAnnotation: "true",
+		}
+		Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+		sveltosCluster := getSveltosClusterForSecret(secret,
+			time.Now().Add(-30*time.Minute).UTC().Format(time.RFC3339))
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret, sveltosCluster).Build()
+		reconciler := getSecretReconciler(c)
+		reconciler.TrackSveltosCluster(types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name},
+			types.NamespacedName{Namespace: sveltosCluster.Namespace, Name: sveltosCluster.Name})
+
+		secretRef := reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name},
+		}
+		_, err := reconciler.Reconcile(context.TODO(), secretRef)
+		Expect(err).To(BeNil())
+
+		currentSveltosClusters := &libsveltosv1alpha1.SveltosClusterList{}
+		Expect(c.List(context.TODO(), currentSveltosClusters)).To(Succeed())
+		Expect(currentSveltosClusters.Items).To(BeEmpty())
+
+		err = c.Get(context.TODO(), secretRef.NamespacedName, &corev1.Secret{})
+		Expect(err).ToNot(BeNil())
+		Expect(apierrors.IsNotFound(err)).To(BeTrue())
+	})
+})
+
+// getSveltosClusterForSecret returns a SveltosCluster created for secret and expiring at expiresAt
+func getSveltosClusterForSecret(secret *corev1.Secret, expiresAt string) *libsveltosv1alpha1.SveltosCluster {
+	return &libsveltosv1alpha1.SveltosCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: secret.Namespace,
+			Name:      secret.Labels[controller.ClaudieCluster],
+			Annotations: map[string]string{
+				controller.SveltosClusterClaudieAnnotation:   "ok",
+				controller.SveltosClusterExpiresAtAnnotation: expiresAt,
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					Kind:       "Secret",
+					APIVersion: "v1",
+					Name:       secret.Name,
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+	logs "github.com/projectsveltos/libsveltos/lib/logsettings"
+)
+
+const (
+	// unmanageAnnotation, when set to "true" on a SveltosCluster, releases it from Claudie
+	// management. The SveltosCluster is not updated nor deleted by this controller anymore.
+	unmanageAnnotation = "claudie.projectsveltos.io/unmanage"
+)
+
+// isUnmanaged returns true if SveltosCluster was released from Claudie management
+func isUnmanaged(sveltosCluster *libsveltosv1alpha1.SveltosCluster) bool {
+	return sveltosCluster.Annotations[unmanageAnnotation] == "true"
+}
+
+// releaseSveltosCluster stops managing sveltosCluster. Annotations and labels set by this controller are removed
+// along with Secret OwnerReferences (so SveltosCluster is not garbage collected when Secret is deleted).
+// Secret is removed from SecretToCluster map so SveltosCluster is not deleted when Secret is.
+func (r *SecretReconciler) releaseSveltosCluster(ctx context.Context, sveltosCluster *libsveltosv1alpha1.SveltosCluster,
+	secret *corev1.Secret, logger logr.Logger) error {
+
+	r.secretToCluster.Delete(types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name})
+
+	if !isSveltosClusterForClaudie(sveltosCluster) && getClaudieSecret(sveltosCluster) == nil {
+		// Already released
+		return nil
+	}
+
+	msg := fmt.Sprintf("SveltosCluster %s/%s released from Claudie management",
+		sveltosCluster.Namespace, sveltosCluster.Name)
+	logger.V(logs.LogInfo).Info(msg)
+
+	removeClaudieMetadata(sveltosCluster, r.getClaudieMetadata())
+
+	if err := r.writeSveltosCluster(ctx, sveltosCluster, false); err != nil {
+		return err
+	}
+
+	r.recordEvent(secret, corev1.EventTypeNormal, reasonSveltosClusterReleased, msg)
+	return nil
+}
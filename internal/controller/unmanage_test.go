@@ -0,0 +1,123 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	"gianlucam76/claudie-sveltos-integration/internal/controller"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+)
+
+var _ = Describe("Unmanage", func() {
+	It("createSveltosCluster releases a SveltosCluster annotated to be unmanaged", func() {
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+		reconciler := getSecretReconciler(c)
+		recorder := record.NewFakeRecorder(10)
+		reconciler.EventRecorder = recorder
+
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+		Expect(recorder.Events).To(Receive(ContainSubstring(controller.ReasonSveltosClusterCreated)))
+
+		secretKey := types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name}
+		sveltosClusterKey := types.NamespacedName{Namespace: secret.Namespace, Name: secret.Labels[controller.ClaudieCluster]}
+		Expect(reconciler.SecretToCluster()).To(HaveKey(secretKey))
+
+		currentSveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+		Expect(c.Get(context.TODO(), sveltosClusterKey, currentSveltosCluster)).To(Succeed())
+		currentSveltosCluster.Annotations[controller.UnmanageAnnotation] = "true"
+		Expect(c.Update(context.TODO(), currentSveltosCluster)).To(Succeed())
+
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+
+		Expect(c.Get(context.TODO(), sveltosClusterKey, currentSveltosCluster)).To(Succeed())
+		Expect(currentSveltosCluster.Annotations).ToNot(HaveKey(controller.SveltosClusterClaudieAnnotation))
+		Expect(currentSveltosCluster.Annotations).To(HaveKey(controller.UnmanageAnnotation))
+		Expect(currentSveltosCluster.OwnerReferences).To(BeEmpty())
+		Expect(reconciler.SecretToCluster()).ToNot(HaveKey(secretKey))
+		Expect(recorder.Events).To(Receive(ContainSubstring(controller.ReasonSveltosClusterReleased)))
+
+		// Further reconciliations do not manage it anymore
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+		Expect(c.Get(context.TODO(), sveltosClusterKey, currentSveltosCluster)).To(Succeed())
+		Expect(currentSveltosCluster.Annotations).ToNot(HaveKey(controller.SveltosClusterClaudieAnnotation))
+		Expect(recorder.Events).To(BeEmpty())
+
+		// Secret removal does not delete SveltosCluster
+		Expect(c.Delete(context.TODO(), secret)).To(Succeed())
+		Expect(controller.CleanSveltosCluster(reconciler, context.TODO(),
+			reconcile.Request{NamespacedName: secretKey}, logr.Logger{})).To(Succeed())
+		Expect(c.Get(context.TODO(), sveltosClusterKey, currentSveltosCluster)).To(Succeed())
+	})
+
+	It("cleanSveltosCluster and removeStaleSveltosClusters do not delete unmanaged SveltosClusters", func() {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: randomString(),
+				Name:      randomString(),
+			},
+		}
+
+		sveltosCluster := &libsveltosv1alpha1.SveltosCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: secret.Namespace,
+				Name:      randomString(),
+				Annotations: map[string]string{
+					controller.SveltosClusterClaudieAnnotation: "ok",
+					controller.UnmanageAnnotation:              "true",
+				},
+				OwnerReferences: []metav1.OwnerReference{
+					{Kind: "Secret", APIVersion: "v1", Name: secret.Name},
+				},
+			},
+		}
+
+		// Secret does not exist
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(sveltosCluster).Build()
+		reconciler := getSecretReconciler(c)
+
+		sveltosClusterKey := types.NamespacedName{Namespace: sveltosCluster.Namespace, Name: sveltosCluster.Name}
+		secretRef := reconcile.Request{
+			NamespacedName: types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name},
+		}
+		reconciler.TrackSveltosCluster(secretRef.NamespacedName, sveltosClusterKey)
+
+		Expect(controller.CleanSveltosCluster(reconciler, context.TODO(), secretRef, logr.Logger{})).To(Succeed())
+		Expect(c.Get(context.TODO(), sveltosClusterKey, &libsveltosv1alpha1.SveltosCluster{})).To(Succeed())
+		Expect(reconciler.SecretToCluster()).ToNot(HaveKey(secretRef.NamespacedName))
+
+		mapReady := make(chan struct{})
+		close(mapReady)
+		controller.RemoveStaleSveltosClusters(context.TODO(), c, c, controller.DefaultClaudieMetadata, 0, mapReady, logr.Logger{})
+		Expect(c.Get(context.TODO(), sveltosClusterKey, &libsveltosv1alpha1.SveltosCluster{})).To(Succeed())
+	})
+})
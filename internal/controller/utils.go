@@ -17,6 +17,10 @@ limitations under the License.
 package controller
 
 import (
+	"fmt"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 
@@ -34,3 +38,24 @@ func InitScheme() (*runtime.Scheme, error) {
 
 	return s, nil
 }
+
+// ValidateScheme verifies all types this controller works with are registered in the scheme.
+// A misbuilt scheme would otherwise cause confusing errors on every reconciliation.
+func ValidateScheme(s *runtime.Scheme) error {
+	requiredTypes := []runtime.Object{
+		&corev1.Secret{},
+		&corev1.SecretList{},
+		&corev1.Namespace{},
+		&libsveltosv1alpha1.SveltosCluster{},
+		&libsveltosv1alpha1.SveltosClusterList{},
+		&authorizationv1.SelfSubjectAccessReview{},
+	}
+
+	for i := range requiredTypes {
+		if _, _, err := s.ObjectKinds(requiredTypes[i]); err != nil {
+			return fmt.Errorf("type %T is not registered in the scheme: %w", requiredTypes[i], err)
+		}
+	}
+
+	return nil
+}
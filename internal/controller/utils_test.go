@@ -0,0 +1,53 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+
+	"gianlucam76/claudie-sveltos-integration/internal/controller"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+)
+
+var _ = Describe("Utils", func() {
+	It("ValidateScheme succeeds when all required types are registered", func() {
+		s, err := controller.InitScheme()
+		Expect(err).To(BeNil())
+		Expect(controller.ValidateScheme(s)).To(Succeed())
+	})
+
+	It("ValidateScheme fails when SveltosCluster is not registered", func() {
+		s := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(s)).To(Succeed())
+
+		err := controller.ValidateScheme(s)
+		Expect(err).ToNot(BeNil())
+		Expect(err.Error()).To(ContainSubstring("SveltosCluster"))
+	})
+
+	It("ValidateScheme fails when core types are not registered", func() {
+		s := runtime.NewScheme()
+		Expect(libsveltosv1alpha1.AddToScheme(s)).To(Succeed())
+
+		Expect(controller.ValidateScheme(s)).ToNot(Succeed())
+	})
+})
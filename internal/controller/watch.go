@@ -0,0 +1,92 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+)
+
+// getSveltosClusterPredicate returns the predicate letting through only SveltosCluster updates removing
+// the claudie annotation or Secret OwnerReferences, and deletions of Claudie SveltosClusters, so the owning
+// Secret is reconciled and they are restored (respectively SveltosCluster is recreated if Secret still exists).
+// SveltosClusters released from Claudie management are ignored.
+func getSveltosClusterPredicate() predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return false
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldSveltosCluster, ok := e.ObjectOld.(*libsveltosv1alpha1.SveltosCluster)
+			if !ok {
+				return false
+			}
+			newSveltosCluster, ok := e.ObjectNew.(*libsveltosv1alpha1.SveltosCluster)
+			if !ok {
+				return false
+			}
+
+			if isUnmanaged(newSveltosCluster) {
+				return false
+			}
+
+			if isSveltosClusterForClaudie(oldSveltosCluster) && !isSveltosClusterForClaudie(newSveltosCluster) {
+				return true
+			}
+
+			return len(getSecretOwnerReferences(newSveltosCluster)) < len(getSecretOwnerReferences(oldSveltosCluster))
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			sveltosCluster, ok := e.Object.(*libsveltosv1alpha1.SveltosCluster)
+			if !ok || isUnmanaged(sveltosCluster) {
+				return false
+			}
+
+			return isSveltosClusterForClaudie(sveltosCluster) || getClaudieSecret(sveltosCluster) != nil
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return false
+		},
+	}
+}
+
+// requeueClaudieSecret returns the request for the Claudie Secret a SveltosCluster was created for.
+// Secret is found from SveltosCluster OwnerReferences and annotations or, when those were removed,
+// from SecretToCluster map.
+func (r *SecretReconciler) requeueClaudieSecret(_ context.Context, o client.Object) []reconcile.Request {
+	sveltosCluster, ok := o.(*libsveltosv1alpha1.SveltosCluster)
+	if !ok || isUnmanaged(sveltosCluster) {
+		return nil
+	}
+
+	if secretKey := getClaudieSecret(sveltosCluster); secretKey != nil {
+		return []reconcile.Request{{NamespacedName: *secretKey}}
+	}
+
+	secretKey, ok := r.secretToCluster.GetSecret(client.ObjectKeyFromObject(sveltosCluster))
+	if !ok {
+		return nil
+	}
+
+	return []reconcile.Request{{NamespacedName: secretKey}}
+}
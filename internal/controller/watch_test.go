@@ -0,0 +1,136 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	"gianlucam76/claudie-sveltos-integration/internal/controller"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+)
+
+var _ = Describe("SveltosCluster watch", func() {
+	It("restores the claudie annotation and OwnerReference removed by users", func() {
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+		reconciler := getSecretReconciler(c)
+		reconciler.AdoptionPolicy = controller.AdoptionPolicyRefuse
+
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+
+		sveltosClusterKey := types.NamespacedName{Namespace: secret.Namespace, Name: secret.Labels[controller.ClaudieCluster]}
+		original := &libsveltosv1alpha1.SveltosCluster{}
+		Expect(c.Get(context.TODO(), sveltosClusterKey, original)).To(Succeed())
+
+		// Only the claudie annotation is removed: Secret is found from OwnerReferences
+		modified := original.DeepCopy()
+		delete(modified.Annotations, controller.SveltosClusterClaudieAnnotation)
+		Expect(controller.GetSveltosClusterPredicate().Update(
+			event.UpdateEvent{ObjectOld: original, ObjectNew: modified})).To(BeTrue())
+
+		// Both the annotations and the OwnerReferences are removed: Secret is found from SecretToCluster map
+		modified.Annotations = nil
+		modified.OwnerReferences = nil
+		Expect(controller.GetSveltosClusterPredicate().Update(
+			event.UpdateEvent{ObjectOld: original, ObjectNew: modified})).To(BeTrue())
+		Expect(c.Update(context.TODO(), modified)).To(Succeed())
+
+		requests := controller.RequeueClaudieSecret(reconciler, context.TODO(), modified)
+		Expect(requests).To(HaveLen(1))
+		Expect(requests[0].NamespacedName).To(Equal(types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name}))
+
+		_, err := reconciler.Reconcile(context.TODO(), requests[0])
+		Expect(err).To(BeNil())
+
+		current := &libsveltosv1alpha1.SveltosCluster{}
+		Expect(c.Get(context.TODO(), sveltosClusterKey, current)).To(Succeed())
+		Expect(current.Annotations).To(HaveKey(controller.SveltosClusterClaudieAnnotation))
+		Expect(current.OwnerReferences).To(HaveLen(1))
+		Expect(current.OwnerReferences[0].Name).To(Equal(secret.Name))
+	})
+
+	It("recreates a SveltosCluster manually deleted while its Secret still exists", func() {
+		secret := getClaudieSecretWithKubeconfig("cluster-a", "cluster-a")
+		Expect(addTypeInformationToObject(scheme, secret)).To(Succeed())
+
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+		reconciler := getSecretReconciler(c)
+
+		Expect(controller.CreateSveltosCluster(reconciler, context.TODO(), secret, logr.Logger{})).To(Succeed())
+
+		sveltosClusterKey := types.NamespacedName{Namespace: secret.Namespace, Name: secret.Labels[controller.ClaudieCluster]}
+		sveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+		Expect(c.Get(context.TODO(), sveltosClusterKey, sveltosCluster)).To(Succeed())
+
+		Expect(c.Delete(context.TODO(), sveltosCluster)).To(Succeed())
+		Expect(controller.GetSveltosClusterPredicate().Delete(event.DeleteEvent{Object: sveltosCluster})).To(BeTrue())
+
+		requests := controller.RequeueClaudieSecret(reconciler, context.TODO(), sveltosCluster)
+		Expect(requests).To(HaveLen(1))
+		Expect(requests[0].NamespacedName).To(Equal(types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name}))
+
+		_, err := reconciler.Reconcile(context.TODO(), requests[0])
+		Expect(err).To(BeNil())
+
+		current := &libsveltosv1alpha1.SveltosCluster{}
+		Expect(c.Get(context.TODO(), sveltosClusterKey, current)).To(Succeed())
+		Expect(current.Annotations).To(HaveKey(controller.SveltosClusterClaudieAnnotation))
+		Expect(current.Spec.KubeconfigName).To(Equal(secret.Name))
+	})
+
+	It("ignores deletions of SveltosClusters not managed by Claudie", func() {
+		sveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+		sveltosCluster.Namespace = randomString()
+		sveltosCluster.Name = randomString()
+		Expect(controller.GetSveltosClusterPredicate().Delete(event.DeleteEvent{Object: sveltosCluster})).To(BeFalse())
+
+		sveltosCluster.Annotations = map[string]string{
+			controller.SveltosClusterClaudieAnnotation: "ok",
+			controller.UnmanageAnnotation:              "true",
+		}
+		Expect(controller.GetSveltosClusterPredicate().Delete(event.DeleteEvent{Object: sveltosCluster})).To(BeFalse())
+	})
+
+	It("ignores SveltosCluster changes not removing what this controller relies on", func() {
+		sveltosCluster := &libsveltosv1alpha1.SveltosCluster{}
+		sveltosCluster.Namespace = randomString()
+		sveltosCluster.Name = randomString()
+		sveltosCluster.Annotations = map[string]string{controller.SveltosClusterClaudieAnnotation: "ok"}
+
+		modified := sveltosCluster.DeepCopy()
+		modified.Labels = map[string]string{randomString(): randomString()}
+		Expect(controller.GetSveltosClusterPredicate().Update(
+			event.UpdateEvent{ObjectOld: sveltosCluster, ObjectNew: modified})).To(BeFalse())
+
+		// Released SveltosClusters are left alone
+		modified = sveltosCluster.DeepCopy()
+		modified.Annotations = map[string]string{controller.UnmanageAnnotation: "true"}
+		Expect(controller.GetSveltosClusterPredicate().Update(
+			event.UpdateEvent{ObjectOld: sveltosCluster, ObjectNew: modified})).To(BeFalse())
+		Expect(controller.RequeueClaudieSecret(getSecretReconciler(nil), context.TODO(), modified)).To(BeEmpty())
+	})
+})
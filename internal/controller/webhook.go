@@ -0,0 +1,123 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+)
+
+const (
+	// GarbageCollectorUser is the user Kubernetes garbage collector updates OwnerReferences as
+	GarbageCollectorUser = "system:serviceaccount:kube-system:generic-garbage-collector"
+)
+
+// SveltosClusterValidator rejects changes to the fields this controller relies on (Spec.KubeconfigName
+// and Secret OwnerReferences) of SveltosClusters managed by this controller, unless they are made by
+// one of AllowedUsers. SveltosClusters released from Claudie management can be freely modified.
+type SveltosClusterValidator struct {
+	// AllowedUsers lists the users (e.g. this controller service account) allowed to modify any field
+	AllowedUsers []string
+}
+
+//+kubebuilder:webhook:path=/validate-lib-projectsveltos-io-v1alpha1-sveltoscluster,mutating=false,failurePolicy=ignore,sideEffects=None,groups=lib.projectsveltos.io,resources=sveltosclusters,verbs=update,versions=v1alpha1,name=vsveltoscluster.claudie.projectsveltos.io,admissionReviewVersions=v1
+
+// SetupWebhookWithManager registers the validating webhook with the Manager
+func (v *SveltosClusterValidator) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&libsveltosv1alpha1.SveltosCluster{}).
+		WithValidator(v).
+		Complete()
+}
+
+// ValidateCreate allows all creations
+func (v *SveltosClusterValidator) ValidateCreate(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// ValidateUpdate rejects changes to Spec.KubeconfigName and Secret OwnerReferences of Claudie managed
+// SveltosClusters made by users not in AllowedUsers
+func (v *SveltosClusterValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object,
+) (admission.Warnings, error) {
+
+	oldSveltosCluster, ok := oldObj.(*libsveltosv1alpha1.SveltosCluster)
+	if !ok {
+		return nil, fmt.Errorf("expected a SveltosCluster but got %T", oldObj)
+	}
+	newSveltosCluster, ok := newObj.(*libsveltosv1alpha1.SveltosCluster)
+	if !ok {
+		return nil, fmt.Errorf("expected a SveltosCluster but got %T", newObj)
+	}
+
+	if !isSveltosClusterForClaudie(oldSveltosCluster) || isUnmanaged(oldSveltosCluster) {
+		return nil, nil
+	}
+
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if v.isAllowedUser(req.UserInfo.Username) {
+		return nil, nil
+	}
+
+	if oldSveltosCluster.Spec.KubeconfigName != newSveltosCluster.Spec.KubeconfigName {
+		return nil, fmt.Errorf("spec.kubeconfigName of SveltosClusters managed by Claudie cannot be modified. "+
+			"Set the %s annotation to release it first", unmanageAnnotation)
+	}
+
+	if !reflect.DeepEqual(getSecretOwnerReferences(oldSveltosCluster), getSecretOwnerReferences(newSveltosCluster)) {
+		return nil, fmt.Errorf("secret OwnerReferences of SveltosClusters managed by Claudie cannot be modified. "+
+			"Set the %s annotation to release it first", unmanageAnnotation)
+	}
+
+	return nil, nil
+}
+
+// ValidateDelete allows all deletions
+func (v *SveltosClusterValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// isAllowedUser returns true if user can modify any field of Claudie managed SveltosClusters
+func (v *SveltosClusterValidator) isAllowedUser(user string) bool {
+	for i := range v.AllowedUsers {
+		if v.AllowedUsers[i] == user {
+			return true
+		}
+	}
+	return false
+}
+
+// getSecretOwnerReferences returns the Secret OwnerReferences of sveltosCluster
+func getSecretOwnerReferences(sveltosCluster *libsveltosv1alpha1.SveltosCluster) []metav1.OwnerReference {
+	var ownerReferences []metav1.OwnerReference
+	for i := range sveltosCluster.OwnerReferences {
+		if sveltosCluster.OwnerReferences[i].Kind == "Secret" {
+			ownerReferences = append(ownerReferences, sveltosCluster.OwnerReferences[i])
+		}
+	}
+	return ownerReferences
+}
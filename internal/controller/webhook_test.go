@@ -0,0 +1,119 @@
+/*
+Copyright 2023. projectsveltos.io. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"gianlucam76/claudie-sveltos-integration/internal/controller"
+
+	libsveltosv1alpha1 "github.com/projectsveltos/libsveltos/api/v1alpha1"
+)
+
+const controllerUser = "system:serviceaccount:projectsveltos:claudie-sveltos-controller"
+
+var _ = Describe("SveltosCluster validating webhook", func() {
+	var validator *controller.SveltosClusterValidator
+	var sveltosCluster *libsveltosv1alpha1.SveltosCluster
+
+	BeforeEach(func() {
+		validator = &controller.SveltosClusterValidator{
+			AllowedUsers: []string{controllerUser, controller.GarbageCollectorUser},
+		}
+
+		sveltosCluster = &libsveltosv1alpha1.SveltosCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: randomString(),
+				Name:      randomString(),
+				Annotations: map[string]string{
+					controller.SveltosClusterClaudieAnnotation: "ok",
+				},
+				OwnerReferences: []metav1.OwnerReference{
+					{APIVersion: "v1", Kind: "Secret", Name: randomString(), UID: "secret-uid"},
+				},
+			},
+			Spec: libsveltosv1alpha1.SveltosClusterSpec{
+				KubeconfigName: randomString(),
+			},
+		}
+	})
+
+	It("rejects changes to kubeconfigName and Secret OwnerReferences made by other users", func() {
+		ctx := getAdmissionContext("jane")
+
+		modified := sveltosCluster.DeepCopy()
+		modified.Spec.KubeconfigName = randomString()
+		_, err := validator.ValidateUpdate(ctx, sveltosCluster, modified)
+		Expect(err).ToNot(BeNil())
+
+		modified = sveltosCluster.DeepCopy()
+		modified.OwnerReferences = nil
+		_, err = validator.ValidateUpdate(ctx, sveltosCluster, modified)
+		Expect(err).ToNot(BeNil())
+
+		// Any other change is allowed
+		modified = sveltosCluster.DeepCopy()
+		modified.Labels = map[string]string{randomString(): randomString()}
+		modified.Spec.Paused = true
+		_, err = validator.ValidateUpdate(ctx, sveltosCluster, modified)
+		Expect(err).To(BeNil())
+	})
+
+	It("allows the controller and the garbage collector to modify any field", func() {
+		modified := sveltosCluster.DeepCopy()
+		modified.Spec.KubeconfigName = randomString()
+		modified.OwnerReferences = nil
+
+		for _, user := range []string{controllerUser, controller.GarbageCollectorUser} {
+			_, err := validator.ValidateUpdate(getAdmissionContext(user), sveltosCluster, modified)
+			Expect(err).To(BeNil())
+		}
+	})
+
+	It("allows any change to SveltosClusters not managed by Claudie", func() {
+		ctx := getAdmissionContext("jane")
+
+		unmanaged := sveltosCluster.DeepCopy()
+		unmanaged.Annotations[controller.UnmanageAnnotation] = "true"
+		notClaudie := sveltosCluster.DeepCopy()
+		delete(notClaudie.Annotations, controller.SveltosClusterClaudieAnnotation)
+
+		for _, current := range []*libsveltosv1alpha1.SveltosCluster{unmanaged, notClaudie} {
+			modified := current.DeepCopy()
+			modified.Spec.KubeconfigName = randomString()
+			modified.OwnerReferences = nil
+			_, err := validator.ValidateUpdate(ctx, current, modified)
+			Expect(err).To(BeNil())
+		}
+	})
+})
+
+// getAdmissionContext returns a context carrying an admission request made by user
+func getAdmissionContext(user string) context.Context {
+	return admission.NewContextWithRequest(context.TODO(), admission.Request{
+		AdmissionRequest: admissionv1.AdmissionRequest{
+			UserInfo: authenticationv1.UserInfo{Username: user},
+		},
+	})
+}
@@ -126,5 +126,28 @@ func getClaudieSecret(namePrefix string) *corev1.Secret {
 				"app.kubernetes.io/part-of": "claudie",
 			},
 		},
+		Data: map[string][]byte{
+			"kubeconfig": []byte(kubeconfig),
+		},
 	}
 }
+
+// kubeconfig is a syntactically valid kubeconfig. Controller does not create
+// SveltosClusters for Secrets without a valid kubeconfig.
+const kubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: gcp-cluster
+  cluster:
+    server: https://gcp-cluster.example.com:6443
+contexts:
+- name: gcp-cluster
+  context:
+    cluster: gcp-cluster
+    user: gcp-cluster
+current-context: gcp-cluster
+users:
+- name: gcp-cluster
+  user:
+    token: token
+`